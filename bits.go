@@ -0,0 +1,98 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	bitSetsMu sync.RWMutex
+	bitSets   = map[string]map[string]uint64{}
+)
+
+// RegisterBits makes the bit names of bits available under name to the
+// `bits` tag, replacing any set registered under the same name. Integer
+// fields with the tag read a |-separated list of names and hold the union of
+// their bits:
+//
+//	func init() {
+//		env.RegisterBits("perms", map[string]uint64{
+//			"read":  1 << 0,
+//			"write": 1 << 1,
+//			"admin": 1 << 2,
+//		})
+//	}
+//
+//	type Config struct {
+//	  Perms uint `env:"PERMS" bits:"perms"` // e.g. PERMS=read|write
+//	}
+//
+// An empty variable sets no bits. RegisterBits is meant to be called from
+// init functions.
+func RegisterBits(name string, bits map[string]uint64) {
+	set := make(map[string]uint64, len(bits))
+	for bit, value := range bits {
+		set[bit] = value
+	}
+
+	bitSetsMu.Lock()
+	defer bitSetsMu.Unlock()
+	bitSets[name] = set
+}
+
+func lookupBits(name string) (map[string]uint64, bool) {
+	bitSetsMu.RLock()
+	defer bitSetsMu.RUnlock()
+	set, ok := bitSets[name]
+	return set, ok
+}
+
+// checkBits reports a `bits` tag on field naming a set that is not
+// registered, or on a field that cannot hold bits.
+func checkBits(field reflect.StructField, env, name string) error {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return errors.New("bits tag requires an integer field: " + env)
+	}
+
+	if _, ok := lookupBits(name); !ok {
+		return errors.New("invalid bits tag for environment variable: " + env + ": unknown set " + strconv.Quote(name))
+	}
+	return nil
+}
+
+// mapBits returns the union of the bits named in raw as a number, if field
+// has a `bits` tag, or raw otherwise.
+func mapBits(field reflect.StructField, env, raw string) (string, error) {
+	name := field.Tag.Get("bits")
+	if name == "" {
+		return raw, nil
+	}
+
+	set, _ := lookupBits(name) // checked by checkTags
+	var mask uint64
+	for _, bit := range splitList(raw, "|") {
+		value, ok := set[bit]
+		if !ok {
+			names := make([]string, 0, len(set))
+			for name := range set {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "", errors.New("invalid value for environment variable: " + env +
+				": unknown bit " + strconv.Quote(bit) + ", expected " + strings.Join(names, ", "))
+		}
+		mask |= value
+	}
+	return strconv.FormatUint(mask, 10), nil
+}
@@ -0,0 +1,55 @@
+package env
+
+import "testing"
+
+func TestParse_Bits(t *testing.T) {
+	RegisterBits("test-perms", map[string]uint64{"read": 1, "write": 2, "admin": 4})
+
+	type Config struct {
+		Perms uint  `env:"PERMS" bits:"test-perms"`
+		Caps  *int8 `env:"CAPS" bits:"test-perms" default:"read"`
+		None  int   `env:"NONE" bits:"test-perms"`
+	}
+
+	var c Config
+	err := Parse(&c, WithNoOSEnv(), WithSource(mapSource{"PERMS": "read | admin", "NONE": ""}))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if c.Perms != 5 {
+		t.Errorf("Expected Perms to be 5, got %d", c.Perms)
+	}
+	if c.Caps == nil || *c.Caps != 1 {
+		t.Errorf("Expected Caps to be 1, got %v", c.Caps)
+	}
+	if c.None != 0 {
+		t.Errorf("Expected None to be 0, got %d", c.None)
+	}
+
+	err = Parse(&c, WithNoOSEnv(), WithSource(mapSource{"PERMS": "read|exec", "NONE": ""}))
+	expected := `invalid value for environment variable: PERMS: unknown bit "exec", expected admin, read, write`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestParse_BitsInvalidTag(t *testing.T) {
+	var unknown struct {
+		Perms uint `env:"PERMS" bits:"no-such-set"`
+	}
+	err := Parse(&unknown, WithNoOSEnv(), WithSource(mapSource{"PERMS": "read"}))
+	expected := `invalid bits tag for environment variable: PERMS: unknown set "no-such-set"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	RegisterBits("test-float", map[string]uint64{"a": 1})
+	var float struct {
+		Perms float64 `env:"PERMS" bits:"test-float"`
+	}
+	err = Parse(&float, WithNoOSEnv(), WithSource(mapSource{"PERMS": "a"}))
+	expected = "bits tag requires an integer field: PERMS"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,50 @@
+package env
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// GetBase64 returns the value of the environment variable named by the key
+// decoded as base64, in either the standard or the URL-safe alphabet, with
+// or without padding. If the variable is not present, def is returned. If
+// its value is not valid base64, an error is returned.
+func GetBase64(key string, def []byte) ([]byte, error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+
+	b, err := decodeBase64(strings.TrimSpace(value))
+	if err != nil {
+		return nil, errors.New("invalid value for environment variable: " + key + ": " + err.Error())
+	}
+	return b, nil
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(value, "-_") {
+		enc = base64.RawURLEncoding
+	}
+	return enc.DecodeString(strings.TrimRight(value, "="))
+}
+
+// GetHexBytes returns the value of the environment variable named by the
+// key decoded as hexadecimal, such as an HMAC key or a trace ID. If the
+// variable is not present, def is returned. If its value has an odd length
+// or contains a character that is not a hex digit, an error is returned.
+func GetHexBytes(key string, def []byte) ([]byte, error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+
+	b, err := hex.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, errors.New("invalid value for environment variable: " + key + ": " + err.Error())
+	}
+	return b, nil
+}
@@ -0,0 +1,61 @@
+package env
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetBase64(t *testing.T) {
+	key := []byte{0xfb, 0xff, 0xbf, 0x01}
+
+	clearenv(t)
+	for _, value := range []string{"+/+/AQ==", "+/+/AQ", "-_-_AQ==", "-_-_AQ", " +/+/AQ==\n"} {
+		t.Setenv("SIGNING_KEY", value)
+		b, err := GetBase64("SIGNING_KEY", nil)
+		if err != nil {
+			t.Errorf("Failed to decode %q: %v", value, err)
+		} else if !bytes.Equal(b, key) {
+			t.Errorf("Expected %x for %q, got %x", key, value, b)
+		}
+	}
+
+	for _, value := range []string{"not base64!", "+/-_AQ"} {
+		t.Setenv("SIGNING_KEY", value)
+		if _, err := GetBase64("SIGNING_KEY", nil); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+
+	def := []byte("default")
+	if b, err := GetBase64("MISSING", def); err != nil || !bytes.Equal(b, def) {
+		t.Errorf("Expected the default, got %q, %v", b, err)
+	}
+}
+
+func TestGetHexBytes(t *testing.T) {
+	clearenv(t)
+	t.Setenv("TRACE_ID", "4bf92f3577b34da6A3CE929D0E0E4736")
+
+	b, err := GetHexBytes("TRACE_ID", nil)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if len(b) != 16 || b[0] != 0x4b || b[15] != 0x36 {
+		t.Errorf("Unexpected bytes %x", b)
+	}
+
+	t.Setenv("TRACE_ID", "abc")
+	if _, err := GetHexBytes("TRACE_ID", nil); err == nil || err.Error() != "invalid value for environment variable: TRACE_ID: encoding/hex: odd length hex string" {
+		t.Errorf("Expected an odd length error, got %v", err)
+	}
+
+	t.Setenv("TRACE_ID", "zz")
+	if _, err := GetHexBytes("TRACE_ID", nil); err == nil || err.Error() != "invalid value for environment variable: TRACE_ID: encoding/hex: invalid byte: U+007A 'z'" {
+		t.Errorf("Expected an invalid byte error, got %v", err)
+	}
+
+	def := []byte{1}
+	if b, err := GetHexBytes("MISSING", def); err != nil || !bytes.Equal(b, def) {
+		t.Errorf("Expected the default, got %x, %v", b, err)
+	}
+}
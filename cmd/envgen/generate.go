@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caleflat/env/internal/dotenv"
+	"github.com/caleflat/env/spec"
+)
+
+// types maps the names accepted by @type to Go types, and to the import they
+// need, if any.
+var types = map[string][2]string{
+	"string":   {"string", ""},
+	"int":      {"int", ""},
+	"int64":    {"int64", ""},
+	"uint":     {"uint", ""},
+	"uint64":   {"uint64", ""},
+	"bool":     {"bool", ""},
+	"float64":  {"float64", ""},
+	"duration": {"time.Duration", "time"},
+	"path":     {"env.Path", "github.com/caleflat/env"},
+	"filemode": {"os.FileMode", "os"},
+}
+
+// initialisms are the name parts spelled in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"API": true, "CPU": true, "DB": true, "DNS": true, "DSN": true, "GID": true,
+	"HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true,
+	"SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true, "UID": true,
+	"URI": true, "URL": true, "UUID": true,
+}
+
+// variable is a variable of the .env file and the field it becomes.
+type variable struct {
+	spec.Variable
+	field string
+}
+
+// generate reads an annotated .env.example from r and returns the source of
+// a Go file declaring the struct typeName in package pkg, with accessors.
+func generate(r io.Reader, source, pkg, typeName string) ([]byte, error) {
+	entries, err := dotenv.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := map[string]bool{"sync": true, "github.com/caleflat/env": true}
+
+	// declared maps the exported identifiers of the generated file to what
+	// declares them, so that accessors cannot redeclare them.
+	declared := map[string]string{"Load": "the generated Load function", typeName: "the type " + typeName}
+
+	vars := make([]variable, 0, len(entries))
+	for _, e := range entries {
+		v := variable{Variable: spec.Variable{Name: e.Key, Type: "string"}, field: fieldName(e.Key)}
+		if other, ok := declared[v.field]; ok {
+			return nil, errors.New("line " + strconv.Itoa(e.Line) + ": " + e.Key + " would declare " + v.field +
+				", which is already declared by " + other)
+		}
+		declared[v.field] = e.Key
+
+		var doc []string
+		for _, c := range e.Comments {
+			directive, arg, _ := strings.Cut(c, " ")
+			arg = strings.TrimSpace(arg)
+			switch directive {
+			case "@type":
+				t, ok := types[arg]
+				if !ok {
+					return nil, errors.New("line " + strconv.Itoa(e.Line) + ": unknown type " + strconv.Quote(arg))
+				}
+				v.Type = t[0]
+				if t[1] != "" {
+					imports[t[1]] = true
+				}
+			case "@default":
+				def := arg
+				v.Default = &def
+			default:
+				doc = append(doc, c)
+			}
+		}
+		v.Description = strings.Join(doc, "\n")
+		v.Required = v.Default == nil
+
+		vars = append(vars, v)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by envgen from " + source + ". DO NOT EDIT.\n\n")
+	b.WriteString("package " + pkg + "\n\n")
+
+	b.WriteString("import (\n")
+	for _, path := range sortedKeys(imports) {
+		if !strings.Contains(path, ".") {
+			b.WriteString(strconv.Quote(path) + "\n")
+		}
+	}
+	b.WriteString("\n")
+	for _, path := range sortedKeys(imports) {
+		if strings.Contains(path, ".") {
+			b.WriteString(strconv.Quote(path) + "\n")
+		}
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// " + typeName + " holds the variables declared in " + source + ".\n")
+	b.WriteString("type " + typeName + " struct {\n")
+	for i, v := range vars {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeComment(&b, v.Description)
+		tag := `env:"` + v.Name + `"`
+		if v.Default != nil {
+			tag += ` default:` + strconv.Quote(*v.Default)
+		}
+		b.WriteString(v.field + " " + v.Type + " " + quoteTag(tag) + "\n")
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString(`var (
+	loadOnce sync.Once
+	loaded   ` + typeName + `
+	loadErr  error
+)
+
+// Load parses the environment into the package configuration. Only the first
+// call reads the environment; later calls return the same result.
+func Load() (*` + typeName + `, error) {
+	loadOnce.Do(func() {
+		loadErr = env.Parse(&loaded)
+	})
+	return &loaded, loadErr
+}
+
+func get() *` + typeName + ` {
+	c, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+`)
+
+	for _, v := range vars {
+		b.WriteString("\n// " + v.field + " returns the value of " + v.Name + ". It panics if Load fails.\n")
+		b.WriteString("func " + v.field + "() " + v.Type + " {\nreturn get()." + v.field + "\n}\n")
+	}
+
+	return format.Source(b.Bytes())
+}
+
+// fieldName turns a variable name such as HTTP_ADDR into a Go identifier
+// such as HTTPAddr.
+func fieldName(key string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(key, "_") {
+		if part == "" {
+			continue
+		}
+		upper := strings.ToUpper(part)
+		if initialisms[upper] {
+			b.WriteString(upper)
+		} else {
+			b.WriteString(upper[:1] + strings.ToLower(part[1:]))
+		}
+	}
+
+	name := b.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "V" + name
+	}
+	return name
+}
+
+// quoteTag returns tag as a Go string literal, preferring a raw string.
+func quoteTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+func writeComment(b *bytes.Buffer, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		b.WriteString("// " + line + "\n")
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
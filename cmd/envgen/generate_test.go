@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	input := `# Address the HTTP server listens on.
+# @default :8080
+HTTP_ADDR=:8080
+
+# How long to wait for a request.
+# @type duration
+HTTP_TIMEOUT=30s
+
+DATABASE_URL=postgres://localhost/app
+`
+
+	src, err := generate(strings.NewReader(input), ".env.example", "config", "Config")
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	for _, want := range []string{
+		"// Code generated by envgen from .env.example. DO NOT EDIT.",
+		"package config",
+		`"time"`,
+		"// Address the HTTP server listens on.\n\tHTTPAddr string `env:\"HTTP_ADDR\" default:\":8080\"`",
+		"HTTPTimeout time.Duration `env:\"HTTP_TIMEOUT\"`",
+		"DatabaseURL string `env:\"DATABASE_URL\"`",
+		"func HTTPTimeout() time.Duration {",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("Generated source does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerate_UnknownType(t *testing.T) {
+	_, err := generate(strings.NewReader("# @type complex128\nX=1\n"), ".env.example", "config", "Config")
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an unknown type error for line 2, got %v", err)
+	}
+}
+
+func TestGenerate_Collision(t *testing.T) {
+	tests := map[string]string{
+		"LOAD=1\n":                    "LOAD would declare Load, which is already declared by the generated Load function",
+		"CONFIG=1\n":                  "CONFIG would declare Config, which is already declared by the type Config",
+		"HTTP_ADDR=1\nHTTP__ADDR=2\n": "line 2: HTTP__ADDR would declare HTTPAddr, which is already declared by HTTP_ADDR",
+	}
+
+	for input, want := range tests {
+		_, err := generate(strings.NewReader(input), ".env.example", "config", "Config")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error containing %q for %q, got %v", want, input, err)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"PORT":         "Port",
+		"HTTP_ADDR":    "HTTPAddr",
+		"DB_MAX_CONNS": "DBMaxConns",
+		"API__KEY":     "APIKey",
+		"2FA_ISSUER":   "V2faIssuer",
+	}
+
+	for key, want := range tests {
+		if got := fieldName(key); got != want {
+			t.Errorf("fieldName(%q) = %q, expected %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Command envgen generates a Go config struct and typed accessors from an
+// annotated .env.example file, for projects that treat the example file as
+// the source of truth.
+//
+// Usage:
+//
+//	envgen [-in .env.example] [-out config_gen.go] [-package config] [-type Config]
+//
+// It is typically run through go:generate:
+//
+//	//go:generate go run github.com/caleflat/env/cmd/envgen -package config
+//
+// Comment lines directly above a variable become its doc comment, except for
+// these directives:
+//
+//	# @type <type>     Go type of the field: string (the default), int, int64,
+//	#                  uint, uint64, bool, float64, duration, path or filemode
+//	# @default <value> value used when the variable is not set; variables
+//	#                  without a default are required
+//
+// For example:
+//
+//	# Address the HTTP server listens on.
+//	# @default :8080
+//	HTTP_ADDR=:8080
+//
+//	# How long to wait for a request.
+//	# @type duration
+//	HTTP_TIMEOUT=30s
+//
+// Each variable gets a field and an accessor named after it, such as
+// HTTPAddr for HTTP_ADDR. envgen fails if two variables get the same name,
+// or if a name is that of the type or of the generated Load function.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	in := flag.String("in", ".env.example", "annotated env file to read")
+	out := flag.String("out", "config_gen.go", "Go file to write")
+	pkg := flag.String("package", "config", "package name of the generated file")
+	typeName := flag.String("type", "Config", "name of the generated struct")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg, *typeName); err != nil {
+		fmt.Fprintln(os.Stderr, "envgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg, typeName string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	src, err := generate(f, filepath.Base(in), pkg, typeName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+
+	return os.WriteFile(out, src, 0o644)
+}
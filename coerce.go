@@ -0,0 +1,83 @@
+package env
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// WithCoercion makes Parse accept the spellings that sources decoded from
+// JSON or YAML commonly produce for booleans and numbers, so that a struct
+// binds the same way whichever source fed it. Before conversion, the raw
+// value of a bool, integer or float field is rewritten by these rules:
+//
+//	any      surrounding white space is removed, then one pair of matching
+//	         quotes: "1" and '1' become 1
+//	bool     yes, y, on and enabled become true; no, n, off, disabled and
+//	         the empty string become false, ignoring case
+//	integer  numbers with a zero fraction or an exponent, such as 8080.0 or
+//	         1e3, become integers
+//
+// Values the rules do not apply to are converted as usual. Fields with other
+// types, including time.Duration and TextUnmarshaler types, are unaffected.
+func WithCoercion() Option {
+	return func(o *options) {
+		o.coerce = true
+	}
+}
+
+// coerce rewrites raw for conversion to t by the rules of WithCoercion.
+func coerce(t reflect.Type, raw string) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType || t == fileModeType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return raw
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return coerceBool(unquote(raw))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return coerceInt(unquote(raw))
+	case reflect.Float32, reflect.Float64:
+		return unquote(raw)
+	}
+	return raw
+}
+
+// unquote removes surrounding white space and one pair of matching quotes.
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+func coerceBool(s string) string {
+	switch strings.ToLower(s) {
+	case "yes", "y", "on", "enabled":
+		return "true"
+	case "no", "n", "off", "disabled", "":
+		return "false"
+	}
+	return s
+}
+
+func coerceInt(s string) string {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return s
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return s
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) >= 1<<63 {
+		return s
+	}
+	return strconv.FormatFloat(f, 'f', 0, 64)
+}
@@ -0,0 +1,54 @@
+package env
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithCoercion(t *testing.T) {
+	type Config struct {
+		Debug   bool          `env:"DEBUG"`
+		Verbose bool          `env:"VERBOSE"`
+		Quiet   *bool         `env:"QUIET"`
+		Port    int           `env:"PORT"`
+		Limit   uint64        `env:"LIMIT"`
+		Ratio   float64       `env:"RATIO"`
+		Name    string        `env:"NAME"`
+		Timeout time.Duration `env:"TIMEOUT"`
+	}
+
+	src := mapSource{
+		"DEBUG":   "on",
+		"VERBOSE": `"1"`,
+		"QUIET":   "No",
+		"PORT":    "8080.0",
+		"LIMIT":   " '1e3' ",
+		"RATIO":   `"0.5"`,
+		"NAME":    `"quoted"`,
+		"TIMEOUT": "5s",
+	}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src), WithCoercion()); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if !config.Debug || !config.Verbose || config.Quiet == nil || *config.Quiet {
+		t.Errorf("Unexpected booleans %v, %v, %v", config.Debug, config.Verbose, config.Quiet)
+	}
+	if config.Port != 8080 || config.Limit != 1000 || config.Ratio != 0.5 {
+		t.Errorf("Unexpected numbers %d, %d, %v", config.Port, config.Limit, config.Ratio)
+	}
+	if config.Name != `"quoted"` {
+		t.Errorf("Expected strings to be left alone, got %q", config.Name)
+	}
+
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src)); err == nil {
+		t.Error("Expected an error without coercion")
+	}
+
+	src["PORT"] = "8080.5"
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src), WithCoercion()); err == nil {
+		t.Error("Expected an error for a fractional integer")
+	}
+}
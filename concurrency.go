@@ -0,0 +1,73 @@
+package env
+
+import (
+	"sync"
+	"time"
+)
+
+// cachedLookup is the result of a lookup made ahead of time.
+type cachedLookup struct {
+	value string
+	ttl   time.Duration
+	ok    bool
+}
+
+// prefetchConcurrently looks up keys using up to o.concurrency goroutines
+// and caches the results, or returns o.ctx.Err() if o.ctx is done first.
+func (o *options) prefetchConcurrently(keys []string) error {
+	seen := make(map[string]bool, len(keys))
+	var unique []string
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+
+	workers := o.concurrency
+	if workers > len(unique) {
+		workers = len(unique)
+	}
+
+	results := make([]cachedLookup, len(unique))
+	jobs := make(chan int)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				value, ttl, ok := o.lookupSources(unique[i])
+				results[i] = cachedLookup{value: value, ttl: ttl, ok: ok}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for i := range unique {
+		select {
+		case jobs <- i:
+		case <-o.ctx.Done():
+			close(jobs)
+			return o.ctx.Err()
+		}
+	}
+	close(jobs)
+
+	select {
+	case <-done:
+	case <-o.ctx.Done():
+		return o.ctx.Err()
+	}
+
+	o.cached = make(map[string]cachedLookup, len(unique))
+	for i, key := range unique {
+		o.cached[key] = results[i]
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowSource is a Source that takes delay to answer each lookup.
+type slowSource struct {
+	mapSource
+	delay   time.Duration
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (s *slowSource) Lookup(key string) (string, bool) {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(s.delay)
+	return s.mapSource.Lookup(key)
+}
+
+type slowConfig struct {
+	A string `env:"A"`
+	B string `env:"B"`
+	C string `env:"C"`
+	D string `env:"D"`
+	E string `env:"E" default:"e"`
+}
+
+func TestWithConcurrency(t *testing.T) {
+	src := &slowSource{
+		mapSource: mapSource{"A": "a", "B": "b", "C": "c", "D": "d"},
+		delay:     20 * time.Millisecond,
+	}
+
+	var config slowConfig
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src), WithConcurrency(2)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := slowConfig{A: "a", B: "b", C: "c", D: "d", E: "e"}
+	if config != expected {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+
+	if peak := src.peak.Load(); peak != 2 {
+		t.Errorf("Expected 2 concurrent lookups, got %d", peak)
+	}
+}
+
+func TestParseContext_Cancelled(t *testing.T) {
+	src := &slowSource{mapSource: mapSource{"A": "a"}, delay: time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := ParseContext(ctx, &slowConfig{}, WithNoOSEnv(), WithSource(src), WithConcurrency(5))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected ParseContext to stop waiting, took %v", elapsed)
+	}
+}
+
+func TestParseContext_Sequential(t *testing.T) {
+	src := &slowSource{mapSource: mapSource{"A": "a", "B": "b", "C": "c", "D": "d"}, delay: 50 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var config slowConfig
+	err := ParseContext(ctx, &config, WithNoOSEnv(), WithSource(src))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if config.A != "a" || config.B != "" {
+		t.Errorf("Expected only A to be resolved, got %+v", config)
+	}
+}
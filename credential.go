@@ -0,0 +1,99 @@
+package env
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ExpirySuffix is appended to the variable of a Credential field to name the
+// variable holding its expiry time.
+const ExpirySuffix = "_EXPIRES_AT"
+
+var credentialType = reflect.TypeOf(Credential{})
+
+// isCredential reports whether t, the type of a field, is Credential or a
+// pointer to one.
+func isCredential(t reflect.Type) bool {
+	return t == credentialType || t == reflect.PtrTo(credentialType)
+}
+
+// Credential is a secret with an optional expiry time, for services that
+// need to refresh rotating credentials before they run out.
+//
+// A Credential field reads its value from its variable, and its expiry from
+// the companion variable named by appending ExpirySuffix, if present:
+//
+//	type Config struct {
+//	  Token env.Credential `env:"API_TOKEN"` // expiry from API_TOKEN_EXPIRES_AT
+//	}
+//
+// The expiry is given as an RFC 3339 time or in seconds since the Unix epoch.
+type Credential struct {
+	Value string
+	// ExpiresAt is the expiry time, or the zero time if the credential
+	// does not expire.
+	ExpiresAt time.Time
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It sets the value and
+// clears the expiry.
+func (c *Credential) UnmarshalText(text []byte) error {
+	*c = Credential{Value: string(text)}
+	return nil
+}
+
+// String returns a placeholder, so that credentials are not logged by
+// accident.
+func (c Credential) String() string {
+	return "[REDACTED]"
+}
+
+// Expired reports whether the credential has expired.
+func (c Credential) Expired() bool {
+	return !c.ExpiresAt.IsZero() && !time.Now().Before(c.ExpiresAt)
+}
+
+// TTL returns how long the credential remains valid, or 0 once it has
+// expired. If it does not expire, TTL returns the maximum Duration.
+func (c Credential) TTL() time.Duration {
+	if c.ExpiresAt.IsZero() {
+		return math.MaxInt64
+	}
+	if ttl := time.Until(c.ExpiresAt); ttl > 0 {
+		return ttl
+	}
+	return 0
+}
+
+// setExpiry reads the expiry of the Credential c read from env.
+func setExpiry(c *Credential, env string, o *options) error {
+	key := env + ExpirySuffix
+
+	raw, ok := o.lookup(key)
+	if !ok {
+		return nil
+	}
+
+	expiresAt, err := parseExpiry(raw)
+	if err != nil {
+		return errors.New("invalid value for environment variable: " + key + ": " + strconv.Quote(raw))
+	}
+
+	c.ExpiresAt = expiresAt
+	return nil
+}
+
+func parseExpiry(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
@@ -0,0 +1,54 @@
+package env
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParse_Credential(t *testing.T) {
+	type Config struct {
+		Token   Credential `env:"API_TOKEN"`
+		Forever Credential `env:"STATIC_TOKEN"`
+		Old     Credential `env:"OLD_TOKEN"`
+	}
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	clearenv(t)
+	t.Setenv("API_TOKEN", "t0k3n")
+	t.Setenv("API_TOKEN_EXPIRES_AT", expiresAt.Format(time.RFC3339))
+	t.Setenv("STATIC_TOKEN", "static")
+	t.Setenv("OLD_TOKEN", "old")
+	t.Setenv("OLD_TOKEN_EXPIRES_AT", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if config.Token.Value != "t0k3n" || !config.Token.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Unexpected credential %q expiring at %v", config.Token.Value, config.Token.ExpiresAt)
+	}
+
+	if config.Token.Expired() || config.Token.TTL() <= 59*time.Minute {
+		t.Errorf("Expected about an hour left, got %v", config.Token.TTL())
+	}
+
+	if config.Forever.Expired() || config.Forever.TTL() < 100*365*24*time.Hour {
+		t.Errorf("Expected a credential without expiry not to expire, got %v", config.Forever.TTL())
+	}
+
+	if !config.Old.Expired() || config.Old.TTL() != 0 {
+		t.Errorf("Expected an expired credential, got %v", config.Old.TTL())
+	}
+
+	if s := config.Token.String(); s != "[REDACTED]" {
+		t.Errorf("Expected String to redact the credential, got %q", s)
+	}
+
+	t.Setenv("API_TOKEN_EXPIRES_AT", "tomorrow")
+	if err := Parse(&Config{}); err == nil {
+		t.Error("Expected an error for a malformed expiry")
+	}
+}
@@ -0,0 +1,70 @@
+package env
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fieldTiming is how long resolving the variable key took.
+type fieldTiming struct {
+	key string
+	d   time.Duration
+}
+
+// WithStartupDeadline makes Parse fail if resolving the config takes longer
+// than d. The error lists how long each variable took, slowest first, so
+// that slow configuration dependencies show up instead of making startup
+// mysteriously slow:
+//
+//	startup deadline of 2s exceeded after 3.2s: DB_PASSWORD 3.1s, API_KEY 80ms, PORT 0s
+//
+// Once d has passed, the remaining variables are not looked up.
+func WithStartupDeadline(d time.Duration) Option {
+	return func(o *options) {
+		o.deadline = d
+	}
+}
+
+// time runs fn, recording how long it took against key if a startup
+// deadline is set.
+func (o *options) time(key string, fn func() error) error {
+	if o.deadline <= 0 {
+		return fn()
+	}
+
+	start := time.Now()
+	err := fn()
+	o.timings = append(o.timings, fieldTiming{key: key, d: time.Since(start)})
+	return err
+}
+
+// stopped reports whether Parse should stop resolving variables, because
+// o.ctx is done or the startup deadline has passed.
+func (o *options) stopped() bool {
+	if o.ctx.Err() != nil {
+		return true
+	}
+	return o.deadline > 0 && time.Since(o.start) > o.deadline
+}
+
+// checkDeadline reports an error if elapsed exceeds the startup deadline.
+func (o *options) checkDeadline(elapsed time.Duration) error {
+	if o.deadline <= 0 || elapsed <= o.deadline {
+		return nil
+	}
+
+	timings := append([]fieldTiming(nil), o.timings...)
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].d > timings[j].d
+	})
+
+	parts := make([]string, len(timings))
+	for i, t := range timings {
+		parts[i] = t.key + " " + t.d.Round(time.Millisecond).String()
+	}
+
+	return errors.New("startup deadline of " + o.deadline.String() + " exceeded after " +
+		elapsed.Round(time.Millisecond).String() + ": " + strings.Join(parts, ", "))
+}
@@ -0,0 +1,48 @@
+package env
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithStartupDeadline(t *testing.T) {
+	type Config struct {
+		Port     string `env:"PORT"`
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	fast := mapSource{"PORT": "8080"}
+	slow := &slowSource{mapSource: mapSource{"DB_PASSWORD": "secret"}, delay: 30 * time.Millisecond}
+
+	err := Parse(&Config{}, WithNoOSEnv(), WithSource(fast), WithSource(slow), WithStartupDeadline(10*time.Millisecond))
+	if err == nil {
+		t.Fatal("Expected the startup deadline to be exceeded")
+	}
+
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "startup deadline of 10ms exceeded after ") {
+		t.Errorf("Unexpected error %q", msg)
+	}
+	if i, j := strings.Index(msg, "DB_PASSWORD"), strings.Index(msg, "PORT"); i < 0 || j < 0 || i > j {
+		t.Errorf("Expected the slowest variable first, got %q", msg)
+	}
+
+	err = Parse(&Config{}, WithNoOSEnv(), WithSource(fast), WithSource(slow), WithStartupDeadline(time.Second))
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestWithStartupDeadline_Stops(t *testing.T) {
+	src := &slowSource{mapSource: mapSource{"A": "a", "B": "b", "C": "c", "D": "d"}, delay: 30 * time.Millisecond}
+
+	var config slowConfig
+	err := Parse(&config, WithNoOSEnv(), WithSource(src), WithStartupDeadline(10*time.Millisecond))
+	if err == nil || !strings.HasPrefix(err.Error(), "startup deadline of 10ms exceeded after ") {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if config.A != "a" || config.B != "" {
+		t.Errorf("Expected the variables after the deadline not to be looked up, got %+v", config)
+	}
+}
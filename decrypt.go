@@ -0,0 +1,48 @@
+package env
+
+import (
+	"errors"
+	"strings"
+)
+
+// decryptor is a decryption function registered with WithDecryptor.
+type decryptor struct {
+	prefix string
+	fn     func(ciphertext string) (string, error)
+}
+
+// WithDecryptor makes Parse decrypt values starting with prefix with fn,
+// which is given the value without the prefix, so that encrypted values can
+// be mixed into otherwise plain environments:
+//
+//	// DB_PASSWORD=age:YWdlLWVuY3J5cHRpb24ub3JnL3Yx...
+//	err := env.Parse(&config, env.WithDecryptor("age:", decryptAge))
+//
+// Values are decrypted before any transformers run, including values of
+// `default` tags. Several decryptors may be given for different prefixes;
+// the first matching one is used. Decrypted values are secret: errors about
+// them, including those of fn, which may quote the ciphertext, are reported
+// without the value.
+func WithDecryptor(prefix string, fn func(ciphertext string) (string, error)) Option {
+	return func(o *options) {
+		o.decryptors = append(o.decryptors, decryptor{prefix, fn})
+	}
+}
+
+// decrypt decrypts the raw value of env if it starts with the prefix of a
+// decryptor, and reports which prefix it had.
+func (o *options) decrypt(env, raw string) (string, string, error) {
+	for _, d := range o.decryptors {
+		ciphertext, ok := strings.CutPrefix(raw, d.prefix)
+		if !ok {
+			continue
+		}
+
+		plaintext, err := d.fn(ciphertext)
+		if err != nil {
+			return "", d.prefix, errors.New("cannot decrypt environment variable: " + env)
+		}
+		return plaintext, d.prefix, nil
+	}
+	return raw, "", nil
+}
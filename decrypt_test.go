@@ -0,0 +1,91 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// reverse is a stand-in for real decryption.
+func reverse(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", errors.New("empty ciphertext")
+	}
+	b := []byte(ciphertext)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b), nil
+}
+
+func TestWithDecryptor(t *testing.T) {
+	type Config struct {
+		Password string `env:"DB_PASSWORD"`
+		Token    string `env:"TOKEN" transform:"upper"`
+		Host     string `env:"HOST"`
+		Key      string `env:"KEY" default:"enc:tluafed"`
+	}
+
+	src := mapSource{"DB_PASSWORD": "age:2retnuh", "TOKEN": "enc:nekot", "HOST": "localhost"}
+	upper := func(s string) (string, error) { return strings.ToUpper(s), nil }
+
+	var c Config
+	err := Parse(&c, WithNoOSEnv(), WithSource(src), WithDecryptor("age:", reverse), WithDecryptor("enc:", reverse),
+		WithDecryptor("age:", upper))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := Config{Password: "hunter2", Token: "TOKEN", Host: "localhost", Key: "default"}
+	if c != expected {
+		t.Errorf("Expected %+v, got %+v", expected, c)
+	}
+
+	src["DB_PASSWORD"] = "age:"
+	err = Parse(&c, WithNoOSEnv(), WithSource(src), WithDecryptor("age:", reverse))
+	if expected := "cannot decrypt environment variable: DB_PASSWORD"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestWithDecryptor_ErrorsRedacted(t *testing.T) {
+	type Config struct {
+		Pass string `env:"PASS" oneof:"a,b"`
+		Key  string `env:"KEY"`
+	}
+
+	leaky := func(ciphertext string) (string, error) {
+		if ciphertext == "bad" {
+			return "", errors.New("cannot decrypt " + ciphertext)
+		}
+		return reverse(ciphertext)
+	}
+
+	src := mapSource{"PASS": "enc:zyx-nialp", "KEY": "x"}
+	err := Parse(&Config{}, WithNoOSEnv(), WithSource(src), WithDecryptor("enc:", leaky))
+	if expected := "invalid value for environment variable: PASS: [REDACTED]"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	src = mapSource{"PASS": "a", "KEY": "enc:bad"}
+	err = Parse(&Config{}, WithNoOSEnv(), WithSource(src), WithDecryptor("enc:", leaky))
+	if expected := "cannot decrypt environment variable: KEY"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestExplain_Decryptor(t *testing.T) {
+	type Config struct {
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	r, err := Explain(&Config{}, "DB_PASSWORD", WithNoOSEnv(),
+		WithSource(mapSource{"DB_PASSWORD": "age:2retnuh"}), WithDecryptor("age:", reverse))
+	if err != nil {
+		t.Fatalf("Failed to explain DB_PASSWORD: %v", err)
+	}
+
+	if s := r.String(); strings.Contains(s, "hunter2") || !strings.Contains(s, "decrypt age:") {
+		t.Errorf("Expected the decrypted value to be redacted, got:\n%s", s)
+	}
+}
@@ -0,0 +1,59 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirSource returns a Source serving the files of dir, each under its file
+// name, with the file's contents as the value. It reads secrets mounted the
+// way Kubernetes and Docker do, one file per secret:
+//
+//	err := env.Parse(&config, env.WithSource(env.DirSource("/run/secrets")))
+//
+// A single trailing newline is removed from the contents. Files are read on
+// every lookup, so that secrets rotated in place are picked up by reloads.
+// Keys that are not plain file names, and files that cannot be read, are
+// reported as not present.
+func DirSource(dir string) Source {
+	return dirSource(dir)
+}
+
+type dirSource string
+
+func (d dirSource) Lookup(key string) (string, bool) {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		return "", false
+	}
+
+	b, err := os.ReadFile(filepath.Join(string(d), key))
+	if err != nil {
+		return "", false
+	}
+
+	value := strings.TrimSuffix(string(b), "\n")
+	return strings.TrimSuffix(value, "\r"), true
+}
+
+func (d dirSource) file(key string) string {
+	return filepath.Join(string(d), key)
+}
+
+func (d dirSource) String() string {
+	return "dir(" + string(d) + ")"
+}
+
+// secretsDir is a dirSource whose files are named after keys in lower case,
+// for WithSecretsDir.
+type secretsDir struct {
+	dirSource
+}
+
+func (d secretsDir) Lookup(key string) (string, bool) {
+	return d.dirSource.Lookup(strings.ToLower(key))
+}
+
+func (d secretsDir) file(key string) string {
+	return d.dirSource.file(strings.ToLower(key))
+}
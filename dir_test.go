@@ -0,0 +1,109 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"DB_PASSWORD": "hunter2\n",
+		"API_KEY":     "line one\nline two\n\n",
+		"EMPTY":       "",
+		"CRLF":        "value\r\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "NESTED"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	src := DirSource(dir)
+	tests := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"DB_PASSWORD", "hunter2", true},
+		{"API_KEY", "line one\nline two\n", true},
+		{"EMPTY", "", true},
+		{"CRLF", "value", true},
+		{"MISSING", "", false},
+		{"NESTED", "", false},
+		{"../DB_PASSWORD", "", false},
+		{"..", "", false},
+	}
+	for _, tt := range tests {
+		value, ok := src.Lookup(tt.key)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("Expected %s to be %q, %v, got %q, %v", tt.key, tt.value, tt.ok, value, ok)
+		}
+	}
+
+	if expected := "dir(" + dir + ")"; sourceName(src) != expected {
+		t.Errorf("Expected %s, got %s", expected, sourceName(src))
+	}
+}
+
+func TestParse_DirSource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "DB_PASSWORD"), []byte("one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Password string `env:"DB_PASSWORD" secret:"true"`
+	}
+
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(DirSource(dir)))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if r.Get().Password != "one" {
+		t.Errorf("Expected one, got %q", r.Get().Password)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "DB_PASSWORD"), []byte("two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if r.Get().Password != "two" {
+		t.Errorf("Expected the rotated secret, got %q", r.Get().Password)
+	}
+}
+
+func TestWithSecretsDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"db_password": "hunter2\n",
+		"api_key":     "from-file\n",
+		"PORT":        "9090\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type Config struct {
+		Password string `env:"DB_PASSWORD"`
+		APIKey   string `env:"API_KEY"`
+		Port     int    `env:"PORT" default:"8080"`
+	}
+
+	var c Config
+	err := Parse(&c, WithSecretsDir(dir), WithNoOSEnv(), WithSource(mapSource{"API_KEY": "from-env"}))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := Config{Password: "hunter2", APIKey: "from-env", Port: 8080}
+	if c != expected {
+		t.Errorf("Expected %+v, got %+v", expected, c)
+	}
+}
@@ -0,0 +1,14 @@
+// Package env parses environment variables into Go structs.
+//
+// The env module depends on the standard library only. Integrations with
+// secret stores, remote sources and flag parsing live in sub-packages of
+// this module, such as envvault and envflag, so that programs only build
+// what they import; they use the standard library only too, speaking the
+// protocols of the services they read from directly:
+//
+//	github.com/caleflat/env          core
+//	github.com/caleflat/env/<name>   optional integrations
+//
+// Code that only works on some platforms is gated with build tags in the
+// package that provides it, so the core builds unchanged everywhere.
+package env
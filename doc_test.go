@@ -0,0 +1,45 @@
+package env
+
+import (
+	"go/build"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModuleHasNoDependencies(t *testing.T) {
+	const module = "github.com/caleflat/env"
+
+	for _, goos := range []string{"linux", "darwin", "windows"} {
+		ctx := build.Default
+		ctx.GOOS = goos
+
+		err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return err
+			}
+			if name := d.Name(); path != "." && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+
+			pkg, err := ctx.ImportDir(path, 0)
+			if _, ok := err.(*build.NoGoError); ok {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			imports := append(append(pkg.Imports, pkg.TestImports...), pkg.XTestImports...)
+			for _, imp := range imports {
+				if imp != module && !strings.HasPrefix(imp, module+"/") && strings.Contains(strings.Split(imp, "/")[0], ".") {
+					t.Errorf("%s (GOOS=%s) imports non-standard package %q", path, goos, imp)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to walk module: %v", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/caleflat/env/internal/dotenv"
+)
+
+// DotenvFS returns a Source holding the variables of the .env files names
+// in fsys, with later files overriding earlier ones. Files that do not exist
+// are skipped, so that optional local overrides can be listed. It serves
+// defaults embedded in the binary and fixtures in tests alike:
+//
+//	//go:embed defaults.env
+//	var defaults embed.FS
+//
+//	src, err := env.DotenvFS(defaults, "defaults.env")
+//	err = env.Parse(&config, env.WithSource(src))
+//
+// The .env format is KEY=VALUE lines, optionally prefixed with "export",
+// with # comments and single or double quoted values.
+//
+// Files encrypted with sops are decrypted with the function set by
+// SetSopsDecrypter, so that secrets can be committed encrypted.
+func DotenvFS(fsys fs.FS, names ...string) (Source, error) {
+	values := make(mapValues)
+	var loaded []loadedFile
+
+	for _, name := range names {
+		start := time.Now()
+		f, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := dotenv.Parse(bytes.NewReader(data))
+		if err == nil && isSops(entries) {
+			entries, err = decryptSops(data)
+		}
+		if err != nil {
+			return nil, errors.New(name + ": " + err.Error())
+		}
+
+		for key, value := range dotenv.Map(entries) {
+			values[key] = value
+		}
+		loaded = append(loaded, loadedFile{path: name, d: time.Since(start)})
+	}
+
+	return dotenvSource{values, loaded}, nil
+}
+
+// dotenvSource names the files its values were read from.
+type dotenvSource struct {
+	mapValues
+	files []loadedFile
+}
+
+func (s dotenvSource) String() string {
+	names := make([]string, len(s.files))
+	for i, f := range s.files {
+		names[i] = f.path
+	}
+	return "dotenv(" + strings.Join(names, ", ") + ")"
+}
+
+func (s dotenvSource) loadedFiles() []loadedFile {
+	return s.files
+}
+
+// sopsDecrypter is set by SetSopsDecrypter.
+var sopsDecrypter atomic.Pointer[func(encrypted []byte) ([]byte, error)]
+
+// SetSopsDecrypter sets the function DotenvFS decrypts .env files encrypted
+// with sops with. It is given the encrypted file and returns the decrypted
+// one, also in .env format. SopsCommand decrypts with the sops command; the
+// sops Go library can be used instead:
+//
+//	env.SetSopsDecrypter(func(encrypted []byte) ([]byte, error) {
+//		return decrypt.Data(encrypted, "dotenv")
+//	})
+//
+// Without a decrypter, DotenvFS reports encrypted files as errors rather
+// than serving their ciphertext. A nil fn removes the decrypter.
+func SetSopsDecrypter(fn func(encrypted []byte) ([]byte, error)) {
+	if fn == nil {
+		sopsDecrypter.Store(nil)
+		return
+	}
+	sopsDecrypter.Store(&fn)
+}
+
+// SopsCommand returns a decrypter for SetSopsDecrypter that runs the sops
+// command found in PATH.
+func SopsCommand() func(encrypted []byte) ([]byte, error) {
+	return func(encrypted []byte) ([]byte, error) {
+		dir, err := os.MkdirTemp("", "env-sops")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		// sops needs a file; only the encrypted contents are written.
+		path := filepath.Join(dir, "encrypted.env")
+		if err := os.WriteFile(path, encrypted, 0o600); err != nil {
+			return nil, err
+		}
+
+		var stderr bytes.Buffer
+		cmd := exec.Command("sops", "--decrypt", "--input-type", "dotenv", "--output-type", "dotenv", path)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, errors.New("sops: " + msg)
+			}
+			return nil, errors.New("sops: " + err.Error())
+		}
+		return out, nil
+	}
+}
+
+// isSops reports whether entries are those of a file encrypted with sops,
+// which adds its metadata as sops_ variables.
+func isSops(entries []dotenv.Entry) bool {
+	for _, e := range entries {
+		if e.Key == "sops_mac" || e.Key == "sops_version" {
+			return true
+		}
+	}
+	return false
+}
+
+// decryptSops decrypts a .env file encrypted with sops and parses it.
+func decryptSops(data []byte) ([]dotenv.Entry, error) {
+	fn := sopsDecrypter.Load()
+	if fn == nil {
+		return nil, errors.New("encrypted with sops, but no decrypter is set; see SetSopsDecrypter")
+	}
+
+	plain, err := (*fn)(data)
+	if err != nil {
+		return nil, errors.New("cannot decrypt: " + err.Error())
+	}
+	return dotenv.Parse(bytes.NewReader(plain))
+}
@@ -0,0 +1,115 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDotenvFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.env": {Data: []byte("# defaults\nHOST=localhost\nPORT=8080\n")},
+		".env.local":   {Data: []byte("export PORT='9090'\n")},
+		"broken.env":   {Data: []byte("NOT A VARIABLE\n")},
+	}
+
+	src, err := DotenvFS(fsys, "defaults.env", "missing.env", ".env.local")
+	if err != nil {
+		t.Fatalf("Failed to load .env files: %v", err)
+	}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if expected := (Config{Host: "localhost", Port: 9090}); config != expected {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+
+	if name := sourceName(src); name != "dotenv(defaults.env, .env.local)" {
+		t.Errorf("Unexpected source name %q", name)
+	}
+
+	_, err = DotenvFS(fsys, "broken.env")
+	if err == nil || err.Error() != "broken.env: line 1: expected KEY=VALUE" {
+		t.Errorf("Expected a parse error naming the file, got %v", err)
+	}
+}
+
+// sopsFile is a .env file in the format sops encrypts them to.
+const sopsFile = `DB_PASSWORD=ENC[AES256_GCM,data:nBE1xYhK,iv:Ue2S0A==,tag:cH2ZZg==,type:str]
+sops_version=3.8.1
+sops_mac=ENC[AES256_GCM,data:7Yb0,iv:Qm5u,tag:uQ==,type:str]
+`
+
+func TestDotenvFS_Sops(t *testing.T) {
+	fsys := fstest.MapFS{"secrets.env": {Data: []byte(sopsFile)}}
+
+	_, err := DotenvFS(fsys, "secrets.env")
+	expected := "secrets.env: encrypted with sops, but no decrypter is set; see SetSopsDecrypter"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	var got []byte
+	SetSopsDecrypter(func(encrypted []byte) ([]byte, error) {
+		got = encrypted
+		return []byte("DB_PASSWORD=hunter2\n"), nil
+	})
+	defer SetSopsDecrypter(nil)
+
+	src, err := DotenvFS(fsys, "secrets.env")
+	if err != nil {
+		t.Fatalf("Failed to load .env files: %v", err)
+	}
+	if string(got) != sopsFile {
+		t.Errorf("Expected the decrypter to get the encrypted file, got %q", got)
+	}
+	if value, _ := src.Lookup("DB_PASSWORD"); value != "hunter2" {
+		t.Errorf("Expected the decrypted value, got %q", value)
+	}
+	if _, ok := src.Lookup("sops_mac"); ok {
+		t.Error("Expected the sops metadata to be dropped")
+	}
+
+	SetSopsDecrypter(func([]byte) ([]byte, error) {
+		return nil, errors.New("no key")
+	})
+	_, err = DotenvFS(fsys, "secrets.env")
+	if expected := "secrets.env: cannot decrypt: no key"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestSopsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of sops")
+	}
+
+	// A fake sops checking its arguments and printing a decrypted file.
+	dir := t.TempDir()
+	script := `#!/bin/sh
+[ "$1 $2 $3 $4 $5" = "--decrypt --input-type dotenv --output-type dotenv" ] || { echo "bad arguments: $*" >&2; exit 1; }
+while IFS= read -r line; do case "$line" in sops_mac=*) found=1 ;; esac; done < "$6"
+[ -n "$found" ] || { echo "not encrypted" >&2; exit 1; }
+echo DB_PASSWORD=hunter2
+`
+	if err := os.WriteFile(filepath.Join(dir, "sops"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	plain, err := SopsCommand()([]byte(sopsFile))
+	if err != nil || string(plain) != "DB_PASSWORD=hunter2\n" {
+		t.Errorf("Expected the decrypted file, got %q, %v", plain, err)
+	}
+
+	_, err = SopsCommand()([]byte("HOST=localhost\n"))
+	if expected := "sops: not encrypted"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
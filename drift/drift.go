@@ -0,0 +1,217 @@
+// Package drift detects configuration drift between replicas of a service
+// by comparing the fingerprints of their configs, as computed by
+// env.Fingerprint.
+//
+// Every replica serves its fingerprint with Handler, and runs a Detector
+// that polls its peers:
+//
+//	fp := env.Fingerprint(&config)
+//	http.Handle("/config/fingerprint", drift.Handler(fp))
+//
+//	d := &drift.Detector{
+//		Fingerprint: fp,
+//		Peers:       []string{"http://10.0.0.2:8080/config/fingerprint"},
+//		OnDrift: func(d drift.Drift) {
+//			driftGauge.WithLabelValues(d.Peer).Set(1)
+//		},
+//		OnRecover: func(peer string) {
+//			driftGauge.WithLabelValues(peer).Set(0)
+//		},
+//	}
+//	go d.Run(ctx, time.Minute)
+//
+// Detector.Healthy fits health checks that should fail while replicas
+// disagree, or may disagree because some cannot be reached.
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// response is the JSON document served by Handler.
+type response struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Handler returns an http.Handler serving fingerprint as a JSON document
+// such as {"fingerprint":"9f86d0..."}.
+func Handler(fingerprint string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response{Fingerprint: fingerprint})
+	})
+}
+
+// Drift is a peer whose fingerprint differs from the local one.
+type Drift struct {
+	Peer   string
+	Local  string
+	Remote string
+}
+
+// Detector compares the local fingerprint with those served by peers.
+type Detector struct {
+	// Fingerprint is the local fingerprint.
+	Fingerprint string
+	// Peers are the URLs of the peers' Handlers.
+	Peers []string
+	// Client is used for requests to peers; http.DefaultClient if nil.
+	Client *http.Client
+	// OnDrift, if not nil, is called for every peer found to differ by
+	// Check.
+	OnDrift func(Drift)
+	// OnRecover, if not nil, is called by Check for every peer that
+	// differed when it was last reached and now agrees.
+	OnRecover func(peer string)
+
+	mu    sync.Mutex
+	drift []Drift
+	// reached holds the peers reached at the last Check.
+	reached map[string]bool
+	// drifted holds the peers that differed when they were last reached.
+	drifted map[string]bool
+}
+
+// Check fetches the fingerprint of every peer and returns those that differ
+// from the local one. Peers that cannot be reached are reported in the
+// returned error and are not considered drifted.
+func (d *Detector) Check(ctx context.Context) ([]Drift, error) {
+	var drift []Drift
+	var agree []string
+	var errs []error
+	for _, peer := range d.Peers {
+		remote, err := d.fetch(ctx, peer)
+		if err != nil {
+			errs = append(errs, errors.New(peer+": "+err.Error()))
+			continue
+		}
+		if remote != d.Fingerprint {
+			drift = append(drift, Drift{Peer: peer, Local: d.Fingerprint, Remote: remote})
+		} else {
+			agree = append(agree, peer)
+		}
+	}
+
+	var recovered []string
+	d.mu.Lock()
+	d.drift = drift
+	d.reached = make(map[string]bool, len(drift)+len(agree))
+	for _, dr := range drift {
+		d.reached[dr.Peer] = true
+	}
+	for _, peer := range agree {
+		d.reached[peer] = true
+	}
+	if d.drifted == nil {
+		d.drifted = make(map[string]bool)
+	}
+	for _, dr := range drift {
+		d.drifted[dr.Peer] = true
+	}
+	for _, peer := range agree {
+		if d.drifted[peer] {
+			recovered = append(recovered, peer)
+			delete(d.drifted, peer)
+		}
+	}
+	d.mu.Unlock()
+
+	if d.OnDrift != nil {
+		for _, dr := range drift {
+			d.OnDrift(dr)
+		}
+	}
+	if d.OnRecover != nil {
+		for _, peer := range recovered {
+			d.OnRecover(peer)
+		}
+	}
+	return drift, errors.Join(errs...)
+}
+
+// Run calls Check every interval until ctx is done, and returns ctx.Err().
+// Errors reaching peers are ignored; the last result is available from
+// Healthy. Run returns an error at once if interval is not positive.
+func (d *Detector) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("drift: non-positive interval " + interval.String())
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		d.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Healthy returns an error naming the peers that differed at the last
+// Check, and those whose config is unknown because they could not be
+// reached or have not been checked yet, or nil if all peers agreed.
+func (d *Detector) Healthy() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []error
+	if len(d.drift) > 0 {
+		peers := make([]string, len(d.drift))
+		for i, dr := range d.drift {
+			peers[i] = dr.Peer
+		}
+		errs = append(errs, errors.New("config differs from peers: "+strings.Join(peers, ", ")))
+	}
+
+	var unknown []string
+	for _, peer := range d.Peers {
+		if !d.reached[peer] {
+			unknown = append(unknown, peer)
+		}
+	}
+	if len(unknown) > 0 {
+		errs = append(errs, errors.New("config unknown for peers: "+strings.Join(unknown, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Detector) fetch(ctx context.Context, peer string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer, nil)
+	if err != nil {
+		return "", err
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("unexpected status " + resp.Status)
+	}
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", err
+	}
+	if r.Fingerprint == "" {
+		return "", errors.New("missing fingerprint")
+	}
+	return r.Fingerprint, nil
+}
@@ -0,0 +1,102 @@
+package drift
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetector(t *testing.T) {
+	same := httptest.NewServer(Handler("aaa"))
+	defer same.Close()
+	other := httptest.NewServer(Handler("bbb"))
+	defer other.Close()
+	broken := httptest.NewServer(http.NotFoundHandler())
+	defer broken.Close()
+
+	var reported []Drift
+	var recovered []string
+	d := &Detector{
+		Fingerprint: "aaa",
+		Peers:       []string{same.URL, other.URL, broken.URL},
+		OnDrift: func(dr Drift) {
+			reported = append(reported, dr)
+		},
+		OnRecover: func(peer string) {
+			recovered = append(recovered, peer)
+		},
+	}
+
+	drift, err := d.Check(context.Background())
+	if err == nil {
+		t.Error("Expected an error for the unreachable peer")
+	}
+
+	expected := []Drift{{Peer: other.URL, Local: "aaa", Remote: "bbb"}}
+	if !reflect.DeepEqual(drift, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, drift)
+	}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Errorf("Expected OnDrift to be called with %+v, got %+v", expected, reported)
+	}
+
+	expectedHealth := "config differs from peers: " + other.URL + "\nconfig unknown for peers: " + broken.URL
+	if err := d.Healthy(); err == nil || err.Error() != expectedHealth {
+		t.Errorf("Unexpected health %v", err)
+	}
+
+	if len(recovered) != 0 {
+		t.Errorf("Expected no recovery yet, got %v", recovered)
+	}
+
+	d.Fingerprint = "bbb"
+	d.Peers = []string{other.URL}
+	if _, err := d.Check(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := d.Healthy(); err != nil {
+		t.Errorf("Expected healthy after agreeing, got %v", err)
+	}
+	if expected := []string{other.URL}; !reflect.DeepEqual(recovered, expected) {
+		t.Errorf("Expected OnRecover to be called with %v, got %v", expected, recovered)
+	}
+
+	if _, err := d.Check(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(recovered) != 1 {
+		t.Errorf("Expected a recovery to be reported once, got %v", recovered)
+	}
+}
+
+func TestDetector_HealthyUnreachable(t *testing.T) {
+	other := httptest.NewServer(Handler("bbb"))
+	d := &Detector{Fingerprint: "aaa", Peers: []string{other.URL}}
+
+	if err := d.Healthy(); err == nil {
+		t.Error("Expected peers not checked yet to be unhealthy")
+	}
+
+	d.Check(context.Background())
+	other.Close()
+	if _, err := d.Check(context.Background()); err == nil {
+		t.Fatal("Expected an error for the unreachable peer")
+	}
+
+	expected := "config unknown for peers: " + other.URL
+	if err := d.Healthy(); err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestDetector_RunInterval(t *testing.T) {
+	d := &Detector{Fingerprint: "aaa"}
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := d.Run(context.Background(), interval); err == nil {
+			t.Errorf("Expected an error for interval %v", interval)
+		}
+	}
+}
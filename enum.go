@@ -0,0 +1,26 @@
+package env
+
+// GetEnum returns the value of the environment variable named by the key,
+// which must be one of allowed:
+//
+//	mode, err := env.GetEnum("MODE", []string{"dev", "staging", "prod"}, "dev")
+//
+// T may be any string type, so that typed constants can be used directly.
+// If the variable is not present, def is returned. If its value is not
+// allowed, an error listing the allowed values is returned.
+func GetEnum[T ~string](key string, allowed []T, def T) (T, error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+
+	names := make([]string, len(allowed))
+	for i, a := range allowed {
+		names[i] = string(a)
+	}
+
+	if err := checkAllowed(key, value, names); err != nil {
+		return def, err
+	}
+	return T(value), nil
+}
@@ -0,0 +1,26 @@
+package env
+
+import "testing"
+
+func TestGetEnum(t *testing.T) {
+	type Mode string
+
+	modes := []Mode{"dev", "staging", "prod"}
+
+	clearenv(t)
+	t.Setenv("MODE", "staging")
+
+	if mode, err := GetEnum("MODE", modes, "dev"); err != nil || mode != "staging" {
+		t.Errorf("Expected staging, got %q, %v", mode, err)
+	}
+
+	if mode, err := GetEnum("MISSING", modes, "dev"); err != nil || mode != "dev" {
+		t.Errorf("Expected the default, got %q, %v", mode, err)
+	}
+
+	t.Setenv("MODE", "qa")
+	_, err := GetEnum("MODE", []string{"dev", "staging", "prod"}, "dev")
+	if err == nil || err.Error() != `invalid value for environment variable: MODE: "qa" is not one of dev, staging, prod` {
+		t.Errorf("Expected an error listing the allowed values, got %v", err)
+	}
+}
@@ -1,15 +1,30 @@
 package env
 
 import (
+	"context"
+	"encoding"
 	"errors"
+	"fmt"
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/caleflat/env/spec"
 )
 
 const (
 	// DefaultTag is the default tag name used for struct tags.
-	DefaultTag = "env"
+	DefaultTag = spec.Tag
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	fileModeType        = reflect.TypeOf(os.FileMode(0))
+	stringMapType       = reflect.TypeOf(map[string]string(nil))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 )
 
 // Parse takes a struct and parses the environment variables into it.
@@ -29,52 +44,448 @@ const (
 //
 //	fmt.Println(config.Port)
 //
+// The accepted values of a field can be restricted with a `oneof` tag listing
+// them comma-separated, and numeric and time.Duration fields can be bounded
+// with inclusive `min` and `max` tags. String values can be checked against a
+// regular expression with a `match` tag:
+//
+//	type Config struct {
+//	  Service   string        `env:"SERVICE" match:"^[a-z0-9-]+$"`
+//	  LogFormat string        `env:"LOG_FORMAT" oneof:"json,text,console"`
+//	  Workers   int           `env:"WORKERS" min:"1" max:"256"`
+//	  Timeout   time.Duration `env:"TIMEOUT" max:"1m"`
+//	}
+//
+// Numeric fields can accept words instead of numbers with an `envValues` tag
+// mapping each word to its value. The variable must then hold one of the
+// words:
+//
+//	type Config struct {
+//	  Priority int `env:"PRIORITY" envValues:"low=1,medium=5,high=10"`
+//	}
+//
+// Integer fields can hold sets of named bits, such as PERMS=read|write, with
+// a `bits` tag naming a set registered with RegisterBits.
+//
+// A field may list older names for its variable in a `fallback` tag. They are
+// consulted in order when the primary variable is not set:
+//
+//	type Config struct {
+//	  Port int `env:"PORT" fallback:"HTTP_PORT,LISTEN_PORT"`
+//	}
+//
+// Besides the basic kinds and time.Duration, os.FileMode fields are parsed
+// as octal permissions (0640 or 0o640), map[string]string fields as
+// comma-separated key=value pairs, and any field whose pointer implements
+// encoding.TextUnmarshaler is set by its UnmarshalText method.
+//
+// A field whose variable is not set takes the value of its `default` tag:
+//
+//	type Config struct {
+//	  Host string `env:"HOST" default:"localhost"`
+//	}
+//
+// Defaults can also be set in code. Before the environment is read, the
+// SetDefaults method of every struct in config that implements
+//
+//	SetDefaults()
+//
+// is called, nested structs before the structs containing them. A field
+// whose value SetDefaults changes keeps that value if its variable is not
+// set. Fields with neither kind of default require their variable, whatever
+// they held before Parse, so that parsing a struct again reports variables
+// that have gone away; WithRequiredIfNoDefault makes fields without a
+// `default` tag require their variable even if SetDefaults sets them.
+//
+// Once the fields are set, the Validate method of every struct in config that
+// implements
+//
+//	Validate() error
+//
+// is called, nested structs before the structs containing them, so that
+// invariants spanning several fields can be checked. Their errors are joined
+// with the error returned by Parse.
+//
+// The fields of a nested struct read variables prefixed with the nested
+// struct's own env tag, if it has one:
+//
+//	type Config struct {
+//	  Primary DB `env:"PRIMARY"` // reads PRIMARY_DSN
+//	  Replica DB `env:"REPLICA"` // reads REPLICA_DSN
+//	}
+//
+//	type DB struct {
+//	  DSN string `env:"DSN"`
+//	}
+//
+// If config is not a non-nil pointer to a struct, an error is returned.
+// If two fields read the same variable, an error is returned.
 // If the environment variable is not present, an error is returned.
 // If the environment variable is present, but the field cannot be set, an error
 // is returned.
-func Parse(config interface{}) error {
-	return parse(config, "")
+func Parse(config interface{}, opts ...Option) error {
+	return parseWith(newOptions(opts), config)
+}
+
+// ParseContext is like Parse, but stops resolving variables once ctx is
+// done, returning ctx.Err(). A lookup already in progress is waited for,
+// except for those made with WithConcurrency, which are given up on.
+func ParseContext(ctx context.Context, config interface{}, opts ...Option) error {
+	o := newOptions(opts)
+	o.ctx = ctx
+	return parseWith(o, config)
+}
+
+// parseWith is Parse with its options already applied, for any number of
+// configs.
+func parseWith(o *options, configs ...interface{}) error {
+	var fields []fieldInfo
+	configFields := make([][]fieldInfo, len(configs))
+	for i, config := range configs {
+		if err := checkTarget(config); err != nil {
+			return err
+		}
+		f, err := specFields(reflect.TypeOf(config).Elem())
+		if err != nil {
+			return o.named(config, err)
+		}
+		configFields[i] = f
+		fields = append(fields, f...)
+	}
+
+	if err := checkDuplicates(fields); err != nil {
+		return err
+	}
+
+	if o.strictNames {
+		if err := checkNames(fields); err != nil {
+			return err
+		}
+	}
+
+	if err := checkTransformers(o.transformers); err != nil {
+		return err
+	}
+
+	o.emitFiles()
+
+	start := time.Now()
+	o.start = start
+	if err := o.prefetch(lookupKeys(fields)); err != nil {
+		return err
+	}
+	if o.batched != nil || o.cached != nil {
+		o.timings = append(o.timings, fieldTiming{key: "(prefetch)", d: time.Since(start)})
+	}
+
+	var err error
+	for i, config := range configs {
+		for key := range setDefaults(reflect.ValueOf(config), configFields[i]) {
+			o.preset[key] = true
+		}
+		err = errors.Join(err, o.named(config, parse(config, configFields[i], o)))
+	}
+	if ctxErr := o.ctx.Err(); ctxErr != nil {
+		return errors.Join(err, ctxErr)
+	}
+	err = errors.Join(err, o.checkDeadline(time.Since(start)))
+	for _, config := range configs {
+		err = errors.Join(err, o.named(config, validateConfig(config, o)))
+	}
+	if err == nil && o.readiness != nil {
+		o.readiness.markReady()
+	}
+	return err
+}
+
+// named prefixes err with the name config was registered under, if any.
+func (o *options) named(config interface{}, err error) error {
+	if name, ok := o.names[config]; ok && err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return err
+}
+
+// ParseAll parses several configs together, for programs that split their
+// configuration across packages:
+//
+//	err := env.ParseAll(&db.Config, &http.Config, &log.Config)
+//
+// Options may be given among the configs. The configs are checked for
+// fields of different configs reading the same variable, sources that
+// support it are queried once for all of them, and the errors of all
+// configs are returned together, rather than stopping at the first config
+// that fails.
+func ParseAll(configs ...interface{}) error {
+	var opts []Option
+	var targets []interface{}
+	for _, c := range configs {
+		if opt, ok := c.(Option); ok {
+			opts = append(opts, opt)
+		} else {
+			targets = append(targets, c)
+		}
+	}
+
+	return parseWith(newOptions(opts), targets...)
+}
+
+// validateConfig calls the Validate methods of config and the validators
+// registered with WithValidator.
+func validateConfig(config interface{}, o *options) error {
+	err := callValidate(reflect.ValueOf(config))
+	for _, validate := range o.validators {
+		err = errors.Join(err, validate(config))
+	}
+	o.emit(Event{Kind: EventValidate, Error: errorString(err)})
+	return err
+}
+
+// parse resolves fields, the fields of config that read a variable, in
+// order. It stops early once o.ctx is done or the startup deadline has
+// passed, leaving parseWith to report why.
+func parse(config interface{}, fields []fieldInfo, o *options) error {
+	v := reflect.ValueOf(config).Elem()
+	for _, f := range fields {
+		if o.stopped() {
+			break
+		}
+		value := v.FieldByIndex(f.index)
+		err := o.time(f.key, func() error {
+			return resolve(f, value, o)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve sets value, the value of the field f, from its variable.
+func resolve(f fieldInfo, value reflect.Value, o *options) error {
+	field, env := f.field, f.key
+	if err := checkTags(field, env); err != nil {
+		return err
+	}
+
+	raw, ok := lookup(env, field.Tag.Get("fallback"), o)
+	if !ok {
+		def, hasDefault := field.Tag.Lookup("default")
+		switch {
+		case o.keepPreset(f):
+			o.emit(Event{Kind: EventDefault, Key: env})
+			o.set(env, value, true, f.secret)
+			return nil
+		case !hasDefault:
+			return errors.New("environment variable not found: " + env)
+		}
+		o.emit(Event{Kind: EventDefault, Key: env})
+		raw = def
+	}
+
+	raw, secret, err := o.prepare(f, raw, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := o.store(f, value, raw, secret); err != nil {
+		return err
+	}
+
+	if isCredential(field.Type) {
+		if err := setExpiry(reflect.Indirect(value).Addr().Interface().(*Credential), env, o); err != nil {
+			return err
+		}
+	}
+
+	if field.Type == secretType && field.Tag.Get("secret") == "once" {
+		value.Interface().(Secret).s.once = true
+	}
+
+	o.set(env, value, !ok, secret)
+	return nil
+}
+
+// keepPreset reports whether the field f keeps the value SetDefaults gave
+// it when its variable is not set.
+func (o *options) keepPreset(f fieldInfo) bool {
+	_, hasDefault := f.field.Tag.Lookup("default")
+	return o.preset[f.key] && (hasDefault || !o.requiredIfNoDefault)
+}
+
+// prepare turns raw, the value of the variable of f, into the value its
+// field is set from: it decrypts and transforms it, maps the names of
+// `envValues` and `bits` tags and coerces it. It also reports whether the
+// value is secret. trace, if not nil, is called with the result of every
+// decryption and transformer, and whether it is secret.
+//
+// resolve and Explain share prepare and store, so that Explain tells how
+// Parse resolves a field.
+func (o *options) prepare(f fieldInfo, raw string, trace func(name, value string, secret bool)) (string, bool, error) {
+	raw, prefix, err := o.decrypt(f.key, raw)
+	if err != nil {
+		return "", true, err
+	}
+	secret := f.secret || prefix != ""
+	if prefix != "" && trace != nil {
+		trace("decrypt "+prefix, raw, secret)
+	}
+
+	for _, name := range o.transformerNames(f.field.Tag.Get("transform")) {
+		if raw, err = o.applyTransformer(f.key, name, raw); err != nil {
+			return "", secret, redactError(err, f.key, secret)
+		}
+		if trace != nil {
+			trace(name, raw, secret)
+		}
+	}
+
+	if raw, err = mapValue(f.field, f.key, raw); err != nil {
+		return "", secret, redactError(err, f.key, secret)
+	}
+	if raw, err = mapBits(f.field, f.key, raw); err != nil {
+		return "", secret, redactError(err, f.key, secret)
+	}
+	if o.coerce {
+		raw = coerce(f.field.Type, raw)
+	}
+	return raw, secret, nil
 }
 
-func parse(config interface{}, prefix string) error {
-	if prefix != "" {
-		prefix += "_"
+// store validates raw and sets value, the value of the field f, from it.
+// Errors about secret values do not quote them.
+func (o *options) store(f fieldInfo, value reflect.Value, raw string, secret bool) error {
+	err := redactError(validate(f.field, f.key, raw), f.key, secret)
+	o.emit(Event{Kind: EventValidate, Key: f.key, Error: errorString(err)})
+	if err != nil {
+		return err
 	}
 
+	err = redactError(setField(value, f.key, raw), f.key, secret)
+	o.emit(Event{Kind: EventConvert, Key: f.key, Type: f.field.Type.String(), Error: errorString(err)})
+	return err
+}
+
+// checkTarget reports an error unless config is a non-nil pointer to a
+// struct.
+func checkTarget(config interface{}) error {
 	v := reflect.ValueOf(config)
+	switch {
+	case !v.IsValid():
+		return errors.New("config must be a pointer to a struct, got nil")
+	case v.Kind() != reflect.Ptr:
+		return errors.New("config must be a pointer to a struct, got " + v.Type().String())
+	case v.IsNil():
+		return errors.New("config must be a non-nil pointer to a struct, got nil " + v.Type().String())
+	case v.Elem().Kind() != reflect.Struct:
+		return errors.New("config must be a pointer to a struct, got pointer to " + v.Elem().Kind().String())
+	}
+
+	return nil
+}
+
+// setDefaults calls the SetDefaults methods of config, a pointer to a
+// struct, and returns the keys of the fields among fields whose values they
+// changed.
+func setDefaults(config reflect.Value, fields []fieldInfo) map[string]bool {
+	v := config.Elem()
+	before := make([]interface{}, len(fields))
+	for i, f := range fields {
+		before[i] = v.FieldByIndex(f.index).Interface()
+	}
+
+	callSetDefaults(config)
+
+	preset := make(map[string]bool)
+	for i, f := range fields {
+		if !reflect.DeepEqual(before[i], v.FieldByIndex(f.index).Interface()) {
+			preset[f.key] = true
+		}
+	}
+	return preset
+}
+
+// callSetDefaults calls the SetDefaults methods of the struct v points to
+// and of its nested structs.
+func callSetDefaults(v reflect.Value) {
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 
-	t := v.Type()
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
+	if v.Kind() != reflect.Struct {
+		return
+	}
 
-		if value.Kind() == reflect.Struct {
-			if err := parse(value.Addr().Interface(), field.Tag.Get(DefaultTag)); err != nil {
-				return err
-			}
-		} else {
-			env := field.Tag.Get(DefaultTag)
-			if env == "" {
-				continue
-			}
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Field(i); field.Kind() == reflect.Struct && field.CanAddr() {
+			callSetDefaults(field.Addr())
+		}
+	}
 
-			if err := setField(value, env); err != nil {
-				return err
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if defaulter, ok := v.Addr().Interface().(interface{ SetDefaults() }); ok {
+			defaulter.SetDefaults()
+		}
+	}
+}
+
+// callValidate calls the Validate methods of the struct v points to and of
+// its nested structs, and joins their errors.
+func callValidate(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Field(i); field.Kind() == reflect.Struct && field.CanAddr() {
+			errs = append(errs, callValidate(field.Addr()))
+		}
+	}
+
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if validator, ok := v.Addr().Interface().(interface{ Validate() error }); ok {
+			errs = append(errs, validator.Validate())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// lookup returns the value of the environment variable env. If it is not set,
+// the comma-separated fallback names are tried in order and the deprecation
+// handler, if any, is told which old name was used.
+func lookup(env, fallback string, o *options) (string, bool) {
+	if value, ok := o.lookup(env); ok {
+		return value, true
+	}
+
+	if fallback == "" {
+		return "", false
+	}
+
+	for _, old := range strings.Split(fallback, ",") {
+		old = strings.TrimSpace(old)
+		if value, ok := o.lookup(old); ok {
+			if o.onDeprecated != nil {
+				o.onDeprecated(old, env)
 			}
+			return value, true
 		}
 	}
 
-	return nil
+	return "", false
 }
 
-// setField sets the value of the field to the environment variable.
-// If the environment variable is not present, an error is returned.
-// If the environment variable is present, but the field cannot be set, an error
-// is returned.
-func setField(value reflect.Value, env string) error {
+// setField sets the value of the field to the raw value of the environment
+// variable env.
+// If the field cannot be set or the value cannot be converted to the field's
+// type, an error is returned.
+func setField(value reflect.Value, env, raw string) error {
 	if !value.CanSet() {
 		return errors.New("cannot set field value")
 	}
@@ -84,49 +495,125 @@ func setField(value reflect.Value, env string) error {
 	}
 
 	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
 		value = value.Elem()
 	}
 
+	if u, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(raw)); err != nil {
+			return errors.New("invalid value for environment variable: " + env + ": " + err.Error())
+		}
+		return nil
+	}
+
+	if value.Type() == fileModeType {
+		m, err := parseFileMode(raw)
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + env + ": " + err.Error())
+		}
+		value.SetUint(uint64(m))
+		return nil
+	}
+
+	if value.Type() == durationType {
+		d, err := parseDuration(raw)
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + env)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
 	switch value.Kind() {
 	case reflect.String:
-		if s, ok := GetString(env); ok {
-			value.SetString(s)
-		} else {
-			return errors.New("environment variable not found: " + env)
-		}
+		value.SetString(raw)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if i, ok := GetInt64(env); ok {
-			value.SetInt(i)
-		} else {
-			return errors.New("environment variable not found: " + env)
+		i, err := parseInt(raw)
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + env)
 		}
+		value.SetInt(i)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if u, ok := GetUint64(env); ok {
-			value.SetUint(u)
-		} else {
-			return errors.New("environment variable not found: " + env)
+		u, err := parseUint(raw)
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + env)
 		}
+		value.SetUint(u)
 	case reflect.Bool:
-		if b, ok := GetBool(env); ok {
-			value.SetBool(b)
-		} else {
-			return errors.New("environment variable not found: " + env)
+		b, err := parseBool(raw)
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + env)
 		}
+		value.SetBool(b)
 	case reflect.Float32, reflect.Float64:
-		if f, ok := GetFloat64(env); ok {
-			value.SetFloat(f)
-		} else {
-			return errors.New("environment variable not found: " + env)
+		f, err := parseFloat(raw)
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + env)
+		}
+		value.SetFloat(f)
+	case reflect.Map:
+		if value.Type() != stringMapType {
+			return errors.New("unsupported map type for environment variable: " + env)
 		}
+		m, err := parseMap(raw, ",", "=", nil)
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + env + ": " + err.Error())
+		}
+		value.Set(reflect.ValueOf(m))
 	}
 
 	return nil
 }
 
+// strict is set by SetStrict.
+var strict atomic.Bool
+
+// SetStrict controls what the Get functions do with values that are present
+// but malformed, such as PORT=80x0. By default they report them like missing
+// values; in strict mode they panic instead, so typos cannot go unnoticed.
+// SetStrict is meant to be called once, early in main.
+func SetStrict(enabled bool) {
+	strict.Store(enabled)
+}
+
+// errorLogger is set by SetErrorLogger.
+var errorLogger atomic.Pointer[func(key string, err error)]
+
+// SetErrorLogger registers fn to be called whenever a Get function ignores a
+// value that is present but malformed and falls back to its default, so
+// that such values can be logged without checking every call:
+//
+//	env.SetErrorLogger(func(key string, err error) {
+//		log.Printf("ignoring %s: %v", key, err)
+//	})
+//
+// fn is called in strict mode too, before the Get function panics. Passing
+// nil removes the logger. fn may be called from several goroutines at once.
+func SetErrorLogger(fn func(key string, err error)) {
+	if fn == nil {
+		errorLogger.Store(nil)
+		return
+	}
+	errorLogger.Store(&fn)
+}
+
+// malformed is called by the Get functions when the value of key cannot be
+// parsed.
+func malformed(key string, err error) {
+	if fn := errorLogger.Load(); fn != nil {
+		(*fn)(key, err)
+	}
+	if strict.Load() {
+		panic(errors.New("env: invalid value for environment variable: " + key + ": " + err.Error()))
+	}
+}
+
 // GetString returns the value of the environment variable named by the key.
 // If the variable is not present in the environment, an empty string and false are returned.
 func GetString(key string) (string, bool) {
-	value, ok := os.LookupEnv(key)
+	value, ok := lookupEnv(key)
 	return value, ok
 }
 
@@ -140,13 +627,14 @@ func GetInt(key string) (int, bool) {
 // GetInt64 returns the value of the environment variable named by the key.
 // If the variable is not present in the environment, 0 and false are returned.
 func GetInt64(key string) (int64, bool) {
-	value, ok := os.LookupEnv(key)
+	value, ok := lookupEnv(key)
 	if !ok {
 		return 0, false
 	}
 
 	i, err := parseInt(value)
 	if err != nil {
+		malformed(key, err)
 		return 0, false
 	}
 
@@ -163,13 +651,14 @@ func GetUint(key string) (uint, bool) {
 // GetUint64 returns the value of the environment variable named by the key.
 // If the variable is not present in the environment, 0 and false are returned.
 func GetUint64(key string) (uint64, bool) {
-	value, ok := os.LookupEnv(key)
+	value, ok := lookupEnv(key)
 	if !ok {
 		return 0, false
 	}
 
 	u, err := parseUint(value)
 	if err != nil {
+		malformed(key, err)
 		return 0, false
 	}
 
@@ -179,13 +668,14 @@ func GetUint64(key string) (uint64, bool) {
 // GetBool returns the value of the environment variable named by the key.
 // If the variable is not present in the environment, false and false are returned.
 func GetBool(key string) (bool, bool) {
-	value, ok := os.LookupEnv(key)
+	value, ok := lookupEnv(key)
 	if !ok {
 		return false, false
 	}
 
 	b, err := parseBool(value)
 	if err != nil {
+		malformed(key, err)
 		return false, false
 	}
 
@@ -195,19 +685,38 @@ func GetBool(key string) (bool, bool) {
 // GetFloat64 returns the value of the environment variable named by the key.
 // If the variable is not present in the environment, 0 and false are returned.
 func GetFloat64(key string) (float64, bool) {
-	value, ok := os.LookupEnv(key)
+	value, ok := lookupEnv(key)
 	if !ok {
 		return 0, false
 	}
 
 	f, err := parseFloat(value)
 	if err != nil {
+		malformed(key, err)
 		return 0, false
 	}
 
 	return f, true
 }
 
+// GetDuration returns the value of the environment variable named by the key
+// parsed as a time.Duration, such as "300ms" or "1h30m".
+// If the variable is not present or cannot be parsed, def is returned.
+func GetDuration(key string, def time.Duration) time.Duration {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+
+	d, err := parseDuration(value)
+	if err != nil {
+		malformed(key, err)
+		return def
+	}
+
+	return d
+}
+
 // ParseInt parses the string value into an int64.
 // If the string is empty or parsing fails, 0 and false are returned.
 func ParseInt(value string) (int64, bool) {
@@ -283,3 +792,38 @@ func parseBool(value string) (bool, error) {
 func parseFloat(value string) (float64, error) {
 	return strconv.ParseFloat(value, 64)
 }
+
+func parseDuration(value string) (time.Duration, error) {
+	return time.ParseDuration(value)
+}
+
+// parseFileMode parses octal permission bits such as 0640, 0o640 or 640.
+// The setuid, setgid and sticky bits (04000, 02000, 01000) are translated to
+// their os.FileMode counterparts.
+func parseFileMode(value string) (os.FileMode, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "0o"), "0O")
+	if digits == "" {
+		return 0, errors.New("empty file mode")
+	}
+
+	bits, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil {
+		return 0, errors.New("file mode must be octal: " + strconv.Quote(value))
+	}
+	if bits > 0o7777 {
+		return 0, errors.New("file mode out of range: " + strconv.Quote(value))
+	}
+
+	mode := os.FileMode(bits & 0o777)
+	if bits&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+
+	return mode, nil
+}
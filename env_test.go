@@ -3,7 +3,9 @@ package env
 import (
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type Config struct {
@@ -11,10 +13,25 @@ type Config struct {
 	Host string `env:"HOST"`
 }
 
+// clearenv empties the environment for the duration of the test.
+func clearenv(t *testing.T) {
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); key != "" {
+			unsetenv(t, key)
+		}
+	}
+}
+
+// unsetenv unsets key for the duration of the test.
+func unsetenv(t *testing.T, key string) {
+	t.Setenv(key, "") // restores the current value when the test ends
+	os.Unsetenv(key)
+}
+
 func TestParse(t *testing.T) {
 	// Set environment variables for testing
-	os.Setenv("PORT", "8080")
-	os.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "8080")
+	t.Setenv("HOST", "localhost")
 
 	var config Config
 	err := Parse(&config)
@@ -30,7 +47,7 @@ func TestParse(t *testing.T) {
 
 func TestParse_EnvironmentVariablesNotSet(t *testing.T) {
 	// Clear environment variables for testing
-	os.Clearenv()
+	clearenv(t)
 
 	var config Config
 	err := Parse(&config)
@@ -46,7 +63,7 @@ func TestParse_EnvironmentVariablesNotSet(t *testing.T) {
 }
 
 func TestParse_InvalidEnvironmentVariable(t *testing.T) {
-	os.Setenv("PORT", "invalid")
+	t.Setenv("PORT", "invalid")
 
 	var config Config
 	err := Parse(&config)
@@ -56,8 +73,8 @@ func TestParse_InvalidEnvironmentVariable(t *testing.T) {
 }
 
 func TestParse_NestedStruct(t *testing.T) {
-	os.Setenv("PORT", "9090")
-	os.Setenv("DSN", "localhost")
+	t.Setenv("PORT", "9090")
+	t.Setenv("DSN", "localhost")
 
 	type NestedConfig struct {
 		DSN string `env:"DSN"`
@@ -79,3 +96,402 @@ func TestParse_NestedStruct(t *testing.T) {
 		t.Errorf("Parsed config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, config)
 	}
 }
+
+type defaultsConfig struct {
+	Port    int    `env:"PORT"`
+	Host    string `env:"HOST"`
+	Backend backendConfig
+}
+
+func (c *defaultsConfig) SetDefaults() {
+	c.Port = 8080
+	c.Backend.Retries = 5
+}
+
+type backendConfig struct {
+	Retries int `env:"RETRIES"`
+	Timeout int `env:"TIMEOUT_SECONDS"`
+}
+
+func (c *backendConfig) SetDefaults() {
+	c.Retries = 3
+	c.Timeout = 30
+}
+
+func TestParse_SetDefaults(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOST", "localhost")
+	t.Setenv("TIMEOUT_SECONDS", "10")
+
+	var config defaultsConfig
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	expectedConfig := defaultsConfig{
+		Port:    8080,
+		Host:    "localhost",
+		Backend: backendConfig{Retries: 5, Timeout: 10},
+	}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Errorf("Parsed config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, config)
+	}
+
+	clearenv(t)
+	config = defaultsConfig{}
+	if err := Parse(&config); err == nil {
+		t.Error("Expected an error for HOST, which has no default")
+	}
+}
+
+func TestSetStrict(t *testing.T) {
+	t.Setenv("PORT", "80x0")
+	defer SetStrict(false)
+
+	if _, ok := GetInt("PORT"); ok {
+		t.Error("Expected GetInt to report a malformed value as missing")
+	}
+
+	SetStrict(true)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected GetInt to panic on a malformed value in strict mode")
+		}
+	}()
+	GetInt("PORT")
+}
+
+func TestSetErrorLogger(t *testing.T) {
+	t.Setenv("PORT", "80x0")
+	t.Setenv("TIMEOUT", "5")
+
+	var keys []string
+	SetErrorLogger(func(key string, err error) {
+		if err == nil {
+			t.Errorf("Expected an error for %s, got nil", key)
+		}
+		keys = append(keys, key)
+	})
+	defer SetErrorLogger(nil)
+
+	if _, ok := GetInt("PORT"); ok {
+		t.Error("Expected GetInt to report a malformed value as missing")
+	}
+	if d := GetDuration("TIMEOUT", time.Second); d != time.Second {
+		t.Errorf("Expected the default, got %v", d)
+	}
+
+	if expected := []string{"PORT", "TIMEOUT"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v to be logged, got %v", expected, keys)
+	}
+
+	SetErrorLogger(nil)
+	GetInt("PORT")
+	if len(keys) != 2 {
+		t.Errorf("Expected no calls after removing the logger, got %v", keys)
+	}
+}
+
+func TestParse_DefaultTag(t *testing.T) {
+	type Config struct {
+		Host    string `env:"HOST" default:"localhost"`
+		Port    int    `env:"PORT" default:"8080"`
+		Verbose bool   `env:"VERBOSE" default:"false"`
+	}
+
+	clearenv(t)
+	t.Setenv("PORT", "9090")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	expectedConfig := Config{Host: "localhost", Port: 9090}
+	if config != expectedConfig {
+		t.Errorf("Parsed config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, config)
+	}
+}
+
+type presetConfig struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port int    `env:"PORT"`
+}
+
+func (c *presetConfig) SetDefaults() {
+	c.Port = 8080
+}
+
+func TestParse_RequiredIfNoDefault(t *testing.T) {
+	clearenv(t)
+
+	var config presetConfig
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	config = presetConfig{}
+	err := Parse(&config, WithRequiredIfNoDefault())
+	if err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Errorf("Expected PORT to be required, got %v", err)
+	}
+
+	t.Setenv("PORT", "9090")
+	config = presetConfig{}
+	if err := Parse(&config, WithRequiredIfNoDefault()); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+}
+
+func TestParse_Again(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "8080")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	unsetenv(t, "HOST")
+	err := Parse(&config)
+	if err == nil || err.Error() != "environment variable not found: HOST" {
+		t.Errorf("Expected HOST to be required when parsing again, got %v", err)
+	}
+}
+
+func TestParse_FileMode(t *testing.T) {
+	type Config struct {
+		Mode os.FileMode `env:"MODE"`
+	}
+
+	tests := []struct {
+		value   string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{value: "0640", want: 0o640},
+		{value: "0o750", want: 0o750},
+		{value: "600", want: 0o600},
+		{value: "1777", want: os.ModeSticky | 0o777},
+		{value: "4755", want: os.ModeSetuid | 0o755},
+		{value: "0648", wantErr: true},
+		{value: "10000", wantErr: true},
+		{value: "rw-r-----", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("MODE", tt.value)
+
+		var config Config
+		err := Parse(&config)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected an error for %q, got %v", tt.value, config.Mode)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", tt.value, err)
+		} else if config.Mode != tt.want {
+			t.Errorf("Parsing %q: expected %v, got %v", tt.value, tt.want, config.Mode)
+		}
+	}
+}
+
+func TestParse_InvalidTarget(t *testing.T) {
+	var nilConfig *Config
+	var port int
+
+	tests := []struct {
+		name    string
+		config  interface{}
+		wantErr string
+	}{
+		{"nil", nil, "got nil"},
+		{"non-pointer", Config{}, "got env.Config"},
+		{"nil pointer", nilConfig, "got nil *env.Config"},
+		{"pointer to non-struct", &port, "got pointer to int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Parse(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParse_UnexportedField(t *testing.T) {
+	type Nested struct {
+		DSN string `env:"DSN"`
+	}
+
+	type TaggedConfig struct {
+		Port int    `env:"PORT"`
+		host string `env:"HOST"`
+	}
+
+	type NestedConfig struct {
+		Port   int `env:"PORT"`
+		nested Nested
+		cache  map[string]string
+	}
+
+	t.Setenv("PORT", "8080")
+	t.Setenv("HOST", "localhost")
+	t.Setenv("DSN", "localhost")
+
+	var tagged TaggedConfig
+	err := Parse(&tagged)
+	if err == nil || !strings.Contains(err.Error(), "TaggedConfig.host") {
+		t.Errorf("Expected an error naming the unexported field, got %v", err)
+	}
+
+	var nested NestedConfig
+	err = Parse(&nested)
+	if err == nil || !strings.Contains(err.Error(), "NestedConfig.nested") {
+		t.Errorf("Expected an error naming the unexported nested struct, got %v", err)
+	}
+
+	type PlainConfig struct {
+		Port  int `env:"PORT"`
+		count int
+		state struct{ ready bool }
+	}
+
+	var plain PlainConfig
+	if err := Parse(&plain); err != nil {
+		t.Errorf("Unexported fields without tags should be ignored, got %v", err)
+	}
+}
+
+func TestParse_NestedPrefix(t *testing.T) {
+	type DB struct {
+		DSN string `env:"DSN"`
+	}
+
+	type Storage struct {
+		Primary DB `env:"PRIMARY"`
+		Replica DB `env:"REPLICA"`
+	}
+
+	type Config struct {
+		Storage Storage `env:"STORAGE"`
+		Cache   DB
+	}
+
+	clearenv(t)
+	t.Setenv("STORAGE_PRIMARY_DSN", "primary")
+	t.Setenv("STORAGE_REPLICA_DSN", "replica")
+	t.Setenv("DSN", "cache")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	expectedConfig := Config{
+		Storage: Storage{Primary: DB{DSN: "primary"}, Replica: DB{DSN: "replica"}},
+		Cache:   DB{DSN: "cache"},
+	}
+	if config != expectedConfig {
+		t.Errorf("Parsed config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, config)
+	}
+}
+
+func TestParse_DuplicateKeys(t *testing.T) {
+	type DB struct {
+		DSN string `env:"DSN"`
+	}
+
+	type DirectConfig struct {
+		Host    string `env:"HOST"`
+		Address string `env:"HOST"`
+	}
+
+	type PrefixConfig struct {
+		PrimaryDSN string `env:"DB_DSN"`
+		Replica    DB     `env:"DB"`
+	}
+
+	t.Setenv("HOST", "localhost")
+	t.Setenv("DB_DSN", "localhost")
+
+	var direct DirectConfig
+	err := Parse(&direct)
+	if err == nil || !strings.Contains(err.Error(), "DirectConfig.Host and env.DirectConfig.Address") {
+		t.Errorf("Expected a duplicate key error, got %v", err)
+	}
+
+	var prefixed PrefixConfig
+	err = Parse(&prefixed)
+	if err == nil || !strings.Contains(err.Error(), "DB_DSN") || !strings.Contains(err.Error(), "Replica.DSN") {
+		t.Errorf("Expected a duplicate key error, got %v", err)
+	}
+}
+
+func TestGetDuration(t *testing.T) {
+	clearenv(t)
+	t.Setenv("TIMEOUT", "1m30s")
+	t.Setenv("BAD_TIMEOUT", "90")
+
+	if got := GetDuration("TIMEOUT", 5*time.Second); got != 90*time.Second {
+		t.Errorf("Expected 1m30s, got %v", got)
+	}
+
+	if got := GetDuration("MISSING", 5*time.Second); got != 5*time.Second {
+		t.Errorf("Expected the default for a missing variable, got %v", got)
+	}
+
+	if got := GetDuration("BAD_TIMEOUT", 5*time.Second); got != 5*time.Second {
+		t.Errorf("Expected the default for a malformed variable, got %v", got)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	type DBConfig struct {
+		DSN string `env:"DSN"`
+	}
+	type HTTPConfig struct {
+		Port int `env:"PORT"`
+	}
+	type LogConfig struct {
+		Level string `env:"LEVEL" oneof:"debug,info"`
+	}
+
+	src := &batchSource{mapSource: mapSource{"DSN": "postgres://db", "PORT": "8080", "LEVEL": "info"}}
+
+	var db DBConfig
+	var http HTTPConfig
+	var log LogConfig
+	if err := ParseAll(&db, &http, &log, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if db.DSN != "postgres://db" || http.Port != 8080 || log.Level != "info" {
+		t.Errorf("Unexpected configs %+v, %+v, %+v", db, http, log)
+	}
+	if src.batches != 1 {
+		t.Errorf("Expected a single batch lookup, got %d", src.batches)
+	}
+
+	src.mapSource["PORT"] = "x"
+	src.mapSource["LEVEL"] = "trace"
+	err := ParseAll(&db, &http, &log, WithNoOSEnv(), WithSource(src))
+	if err == nil || !strings.Contains(err.Error(), "PORT") || !strings.Contains(err.Error(), "LEVEL") {
+		t.Errorf("Expected errors for both configs, got %v", err)
+	}
+
+	type OtherHTTPConfig struct {
+		Port int `env:"PORT"`
+	}
+	err = ParseAll(&http, &OtherHTTPConfig{}, WithNoOSEnv(), WithSource(src))
+	if err == nil || err.Error() != "environment variable PORT is read by both env.HTTPConfig.Port and env.OtherHTTPConfig.Port" {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+}
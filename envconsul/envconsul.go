@@ -0,0 +1,258 @@
+// Package envconsul provides an env.Source backed by the entries under a
+// prefix of the Consul KV store, using only the standard library.
+//
+// The entries are read when the source is created and served under names
+// derived from their keys:
+//
+//	src, err := envconsul.New(ctx, "myapp/prod/")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	r, err := env.NewReloadable[Config](env.WithSource(src))
+//	go r.WatchSources(ctx, logError)
+//
+// The entry myapp/prod/db/password is served as DB_PASSWORD. The source is
+// an env.Watcher using blocking queries, so that a Reloadable watching it
+// picks up changes as soon as they are made.
+package envconsul
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithAddress sets the address of the Consul agent, instead of the one in
+// CONSUL_HTTP_ADDR or http://127.0.0.1:8500.
+func WithAddress(addr string) Option {
+	return func(s *Source) {
+		s.addr = addr
+	}
+}
+
+// WithToken sets the ACL token, instead of the one in CONSUL_HTTP_TOKEN.
+func WithToken(token string) Option {
+	return func(s *Source) {
+		s.token = token
+	}
+}
+
+// WithWait sets how long the blocking queries made by Watch wait for a
+// change, 5 minutes by default.
+func WithWait(d time.Duration) Option {
+	return func(s *Source) {
+		s.wait = d
+	}
+}
+
+// WithClient makes the source use client for its requests instead of
+// http.DefaultClient.
+func WithClient(client *http.Client) Option {
+	return func(s *Source) {
+		s.client = client
+	}
+}
+
+// Source is an env.Source serving the entries under a prefix of the Consul
+// KV store.
+type Source struct {
+	addr   string
+	prefix string
+	token  string
+	wait   time.Duration
+	client *http.Client
+
+	mu     sync.RWMutex
+	values map[string]string
+	index  uint64
+}
+
+// New reads the entries under prefix and returns a Source serving them. An
+// entry's name is the rest of its key after prefix, upper-cased, with
+// slashes, dashes and dots replaced by underscores. An empty prefix serves
+// the whole store.
+func New(ctx context.Context, prefix string, opts ...Option) (*Source, error) {
+	s := &Source{
+		addr:   os.Getenv("CONSUL_HTTP_ADDR"),
+		token:  os.Getenv("CONSUL_HTTP_TOKEN"),
+		prefix: strings.Trim(prefix, "/"),
+		wait:   5 * time.Minute,
+		client: http.DefaultClient,
+	}
+	if s.prefix != "" {
+		s.prefix += "/"
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.addr == "" {
+		s.addr = "127.0.0.1:8500"
+	}
+	if !strings.Contains(s.addr, "://") {
+		s.addr = "http://" + s.addr
+	}
+	s.addr = strings.TrimSuffix(s.addr, "/")
+
+	if _, err := s.fetch(ctx, 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup implements env.Source.
+func (s *Source) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// String implements fmt.Stringer.
+func (s *Source) String() string {
+	return "consul(" + s.prefix + ")"
+}
+
+// Watch implements env.Watcher. It makes blocking queries for changes under
+// the prefix and calls changed whenever the entries change, until ctx is
+// done. Failed queries, and queries after which Consul gave no index to
+// block on or the index went backwards, are retried with an increasing
+// delay of up to a minute, so that the watch does not spin.
+func (s *Source) Watch(ctx context.Context, changed func()) error {
+	backoff := minBackoff
+	for {
+		s.mu.RLock()
+		index := s.index
+		s.mu.RUnlock()
+
+		ok, err := s.fetch(ctx, index)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if ok {
+			changed()
+		}
+
+		s.mu.RLock()
+		blocking := s.index > 0
+		s.mu.RUnlock()
+		if err == nil && blocking {
+			backoff = minBackoff
+			continue
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+// minBackoff is the first delay before Watch queries again after a failed
+// or non-blocking query.
+var minBackoff = time.Second
+
+// fetch reads the entries under the prefix and reports whether they
+// changed. With a non-zero index, it blocks until the entries change or the
+// wait time passes.
+func (s *Source) fetch(ctx context.Context, index uint64) (bool, error) {
+	query := url.Values{"recurse": {"true"}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", strconv.FormatInt(int64(s.wait/time.Second), 10)+"s")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.addr+"/v1/kv/"+s.prefix+"?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	if s.token != "" {
+		req.Header.Set("X-Consul-Token", s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, errors.New("envconsul: " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.New("envconsul: " + err.Error())
+	}
+
+	// Consul answers 404 when no key has the prefix.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		msg := strings.TrimSpace(string(data))
+		if msg == "" {
+			msg = "unexpected status " + resp.Status
+		}
+		return false, errors.New("envconsul: " + msg)
+	}
+
+	var entries []struct {
+		Key   string
+		Value []byte
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return false, errors.New("envconsul: " + err.Error())
+		}
+	}
+
+	values := make(map[string]string, len(entries))
+	for _, e := range entries {
+		name := strings.TrimPrefix(e.Key, s.prefix)
+		if name == "" || strings.HasSuffix(name, "/") {
+			continue // a folder
+		}
+		values[keyOf(name)] = string(e.Value)
+	}
+
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if newIndex < index {
+		// The index went backwards, for example after a restore; start over.
+		newIndex = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := !equal(s.values, values)
+	s.values, s.index = values, newIndex
+	return changed, nil
+}
+
+// keyOf returns the variable name an entry at the relative key name is
+// served under.
+func keyOf(name string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(name))
+}
+
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
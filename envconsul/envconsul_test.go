@@ -0,0 +1,223 @@
+package envconsul
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caleflat/env"
+)
+
+// server is a fake Consul agent supporting blocking queries on one prefix.
+type server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	entries map[string]string
+	index   uint64
+	updated chan struct{}
+}
+
+func newServer(t *testing.T, entries map[string]string) *server {
+	s := &server{entries: entries, index: 1, updated: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "t0ken" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("ACL not found\n"))
+			return
+		}
+		if r.URL.Query().Get("recurse") != "true" {
+			t.Error("Expected a recursive query")
+		}
+
+		s.mu.Lock()
+		index, updated := s.index, s.updated
+		s.mu.Unlock()
+
+		if wait := r.URL.Query().Get("index"); wait == strconv.FormatUint(index, 10) {
+			select {
+			case <-updated:
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+		type entry struct {
+			Key   string
+			Value []byte
+		}
+		entries := []entry{{Key: prefix}}
+		for key, value := range s.entries {
+			if strings.HasPrefix(key, prefix) {
+				entries = append(entries, entry{key, []byte(value)})
+			}
+		}
+
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(s.index, 10))
+		if len(entries) == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(entries)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *server) set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = value
+	s.index++
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+func TestNew(t *testing.T) {
+	srv := newServer(t, map[string]string{
+		"myapp/prod/db/password": "hunter2",
+		"myapp/prod/log-level":   "debug",
+		"myapp/staging/PORT":     "9090",
+	})
+
+	s, err := New(context.Background(), "/myapp/prod", WithAddress(srv.URL), WithToken("t0ken"))
+	if err != nil {
+		t.Fatalf("Failed to read entries: %v", err)
+	}
+
+	var c struct {
+		Password string `env:"DB_PASSWORD"`
+		LogLevel string `env:"LOG_LEVEL"`
+		Port     int    `env:"PORT" default:"8080"`
+	}
+	if err := env.Parse(&c, env.WithNoOSEnv(), env.WithSource(s)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if c.Password != "hunter2" || c.LogLevel != "debug" || c.Port != 8080 {
+		t.Errorf("Unexpected config: %+v", c)
+	}
+	if s.String() != "consul(myapp/prod/)" {
+		t.Errorf("Unexpected name %s", s)
+	}
+}
+
+func TestNew_Empty(t *testing.T) {
+	srv := newServer(t, map[string]string{})
+
+	s, err := New(context.Background(), "myapp", WithAddress(strings.TrimPrefix(srv.URL, "http://")), WithToken("t0ken"))
+	if err != nil {
+		t.Fatalf("Expected an empty prefix to be read, got %v", err)
+	}
+	if _, ok := s.Lookup("PORT"); ok {
+		t.Error("Expected no entries")
+	}
+}
+
+func TestNew_Error(t *testing.T) {
+	srv := newServer(t, nil)
+
+	_, err := New(context.Background(), "myapp", WithAddress(srv.URL), WithToken("wrong"))
+	if expected := "envconsul: ACL not found"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestSource_Watch(t *testing.T) {
+	srv := newServer(t, map[string]string{"myapp/PORT": "8080"})
+
+	s, err := New(context.Background(), "myapp", WithAddress(srv.URL), WithToken("t0ken"), WithWait(time.Second))
+	if err != nil {
+		t.Fatalf("Failed to read entries: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	srv.set("myapp/PORT", "9090")
+
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change to be reported")
+	}
+	if value, _ := s.Lookup("PORT"); value != "9090" {
+		t.Errorf("Expected PORT to be 9090, got %q", value)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestNew_Root(t *testing.T) {
+	srv := newServer(t, map[string]string{"myapp/PORT": "8080", "LOG_LEVEL": "debug"})
+
+	for _, prefix := range []string{"", "/"} {
+		s, err := New(context.Background(), prefix, WithAddress(srv.URL), WithToken("t0ken"))
+		if err != nil {
+			t.Fatalf("Failed to read entries: %v", err)
+		}
+		if value, _ := s.Lookup("MYAPP_PORT"); value != "8080" {
+			t.Errorf("Expected MYAPP_PORT to be 8080 for prefix %q, got %q", prefix, value)
+		}
+		if value, _ := s.Lookup("LOG_LEVEL"); value != "debug" {
+			t.Errorf("Expected LOG_LEVEL to be debug for prefix %q, got %q", prefix, value)
+		}
+		if s.String() != "consul()" {
+			t.Errorf("Unexpected name %s", s)
+		}
+	}
+}
+
+func TestSource_Watch_NoIndex(t *testing.T) {
+	defer func(d time.Duration) { minBackoff = d }(minBackoff)
+	minBackoff = 100 * time.Millisecond
+
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Write([]byte(`[{"Key":"myapp/PORT","Value":"ODA4MA=="}]`)) // no X-Consul-Index
+	}))
+	defer srv.Close()
+
+	s, err := New(context.Background(), "myapp", WithAddress(srv.URL))
+	if err != nil {
+		t.Fatalf("Failed to read entries: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	if err := s.Watch(ctx, func() {}); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests > 4 {
+		t.Errorf("Expected the watch to back off without an index, got %d requests", requests)
+	}
+}
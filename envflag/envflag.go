@@ -0,0 +1,234 @@
+// Package envflag turns the fields of an env-tagged config struct into
+// command line flags, so that command line programs can reuse their config
+// structs, with flags taking precedence over the environment.
+//
+// With the standard flag package:
+//
+//	set, err := envflag.For(&config)
+//	set.Register(flag.CommandLine)
+//	flag.Parse()
+//	err = set.Parse(&config)
+//
+// The values of the flags implement the Value interface of
+// github.com/spf13/pflag too, so they can be added to cobra commands without
+// this package depending on either:
+//
+//	for _, f := range set.Flags() {
+//		pf := cmd.Flags().VarPF(f.Value, f.Name, "", f.Usage)
+//		pf.DefValue = f.Value.Default()
+//		if f.Bool {
+//			pf.NoOptDefVal = "true"
+//		}
+//	}
+//	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+//		return set.Parse(&config)
+//	}
+//
+// A variable such as DB_HOST becomes the flag db-host. Secret fields get no
+// flag, since command lines are visible to other users of the machine.
+package envflag
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/caleflat/env"
+	"github.com/caleflat/env/spec"
+)
+
+// Flag is the command line flag of a variable.
+type Flag struct {
+	// Name is the name of the flag, such as "db-host".
+	Name string
+	// Usage is the description of the variable followed by its name.
+	Usage string
+	// Value holds the value of the flag.
+	Value *Value
+	// Bool is true for flags that may be given without a value, such as
+	// --verbose.
+	Bool bool
+	// Variable is the variable the flag sets.
+	Variable spec.Variable
+}
+
+// Value is the value of a Flag. It implements flag.Value and the Value
+// interface of pflag.
+type Value struct {
+	typ   string
+	def   string
+	value string
+	set   bool
+	bool  bool
+}
+
+// String returns the value of the flag, or its default if it was not set.
+// Help texts should show Default instead, which does not change when the
+// flag is set.
+func (v *Value) String() string {
+	if v == nil {
+		return ""
+	}
+	if v.set {
+		return v.value
+	}
+	return v.def
+}
+
+// Default returns the default of the flag, from the `default` tag of its
+// field.
+func (v *Value) Default() string {
+	if v == nil {
+		return ""
+	}
+	return v.def
+}
+
+// Set sets the value of the flag. It is checked when the config is parsed.
+func (v *Value) Set(s string) error {
+	v.value, v.set = s, true
+	return nil
+}
+
+// Type returns the name of the type of the flag, for pflag's help text.
+func (v *Value) Type() string {
+	return v.typ
+}
+
+// IsBoolFlag reports whether the flag may be given without a value, for the
+// standard flag package.
+func (v *Value) IsBoolFlag() bool {
+	return v.bool
+}
+
+// IsSet reports whether the flag was given on the command line.
+func (v *Value) IsSet() bool {
+	return v.set
+}
+
+// Set is the flags of a config.
+type Set struct {
+	flags []*Flag
+}
+
+// For returns the flags of the variables read by config, a struct or a
+// pointer to one.
+func For(config interface{}) (*Set, error) {
+	vars, err := spec.For(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Set{}
+	for _, v := range vars {
+		if v.Secret {
+			continue
+		}
+
+		t := v.StructField.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		usage := v.Description
+		if usage != "" {
+			usage += " "
+		}
+		usage += "($" + v.Name + ")"
+
+		value := &Value{typ: typeName(t), bool: t.Kind() == reflect.Bool}
+		if v.Default != nil {
+			value.def = *v.Default
+		}
+
+		s.flags = append(s.flags, &Flag{
+			Name:     FlagName(v.Name),
+			Usage:    usage,
+			Value:    value,
+			Bool:     value.bool,
+			Variable: v,
+		})
+	}
+	return s, nil
+}
+
+// FlagName returns the flag name of the variable key, such as db-host for
+// DB_HOST.
+func FlagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// Flags returns the flags, in the order of the fields of the config.
+func (s *Set) Flags() []*Flag {
+	return s.flags
+}
+
+// Register defines the flags in fs, with their defaults as DefValue even if
+// they were already set.
+func (s *Set) Register(fs *flag.FlagSet) {
+	for _, f := range s.flags {
+		fs.Var(f.Value, f.Name, f.Usage)
+		fs.Lookup(f.Name).DefValue = f.Value.def
+	}
+}
+
+// Source returns a Source serving the values of the flags that were set,
+// under the names of their variables, for example to add to the flags layer
+// of an env.Resolver.
+func (s *Set) Source() env.Source {
+	return source{s}
+}
+
+// Parse parses config, the config the flags were made for, with the values
+// of the flags that were set taking precedence over the process environment,
+// which takes precedence over the sources given in opts.
+func (s *Set) Parse(config interface{}, opts ...env.Option) error {
+	return env.Parse(config, append([]env.Option{
+		env.WithNoOSEnv(),
+		env.WithSource(s.Source()),
+		env.WithSource(env.OS()),
+	}, opts...)...)
+}
+
+type source struct {
+	s *Set
+}
+
+func (src source) Lookup(key string) (string, bool) {
+	for _, f := range src.s.flags {
+		if f.Variable.Name == key && f.Value.set {
+			return f.Value.value, true
+		}
+	}
+	return "", false
+}
+
+func (source) String() string {
+	return "flags"
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// typeName returns the name pflag shows for a flag of type t.
+func typeName(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Map:
+		return "list"
+	}
+	return "value"
+}
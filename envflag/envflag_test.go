@@ -0,0 +1,132 @@
+package envflag
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caleflat/env"
+)
+
+type config struct {
+	Host    string        `env:"DB_HOST" default:"localhost" desc:"Database host"`
+	Port    int           `env:"DB_PORT" default:"5432"`
+	Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+	Verbose bool          `env:"VERBOSE" default:"false"`
+	Token   string        `env:"TOKEN" secret:"true" default:""`
+}
+
+// pflagValue is the Value interface of github.com/spf13/pflag.
+type pflagValue interface {
+	String() string
+	Set(string) error
+	Type() string
+}
+
+var _ pflagValue = (*Value)(nil)
+
+func TestFor(t *testing.T) {
+	set, err := For(&config{})
+	if err != nil {
+		t.Fatalf("Failed to make flags: %v", err)
+	}
+
+	var names []string
+	for _, f := range set.Flags() {
+		names = append(names, f.Name+" "+f.Value.Type())
+	}
+	expected := "db-host string, db-port int, timeout duration, verbose bool"
+	if got := strings.Join(names, ", "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	if f := set.Flags()[0]; f.Usage != "Database host ($DB_HOST)" || f.Value.String() != "localhost" {
+		t.Errorf("Unexpected flag %+v", f)
+	}
+	if f := set.Flags()[3]; !f.Bool || !f.Value.IsBoolFlag() {
+		t.Errorf("Expected verbose to be a bool flag")
+	}
+}
+
+func TestSet_Parse(t *testing.T) {
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("DB_PORT", "6543")
+	t.Setenv("TOKEN", "s3cret")
+
+	set, err := For(&config{})
+	if err != nil {
+		t.Fatalf("Failed to make flags: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.Register(fs)
+	if err := fs.Parse([]string{"-db-port", "7000", "-verbose", "-timeout=1m"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	var c config
+	if err := set.Parse(&c, env.WithSource(env.MapSource(map[string]string{"DB_HOST": "ignored"}))); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+
+	expected := config{Host: "db.internal", Port: 7000, Timeout: time.Minute, Verbose: true, Token: "s3cret"}
+	if c != expected {
+		t.Errorf("Expected %+v, got %+v", expected, c)
+	}
+
+	if fs.Lookup("token") != nil {
+		t.Error("Expected no flag for the secret field")
+	}
+}
+
+func TestSet_ParseInvalid(t *testing.T) {
+	set, _ := For(&config{})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.Register(fs)
+	fs.Parse([]string{"-db-port", "many"})
+
+	var c config
+	err := set.Parse(&c)
+	if err == nil || !strings.Contains(err.Error(), "DB_PORT") {
+		t.Errorf("Expected an error naming DB_PORT, got %v", err)
+	}
+}
+
+func TestSet_Register(t *testing.T) {
+	set, _ := For(&config{})
+
+	var out bytes.Buffer
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&out)
+	set.Register(fs)
+	fs.PrintDefaults()
+
+	if !strings.Contains(out.String(), "Database host ($DB_HOST) (default localhost)") {
+		t.Errorf("Expected the defaults in the help text, got:\n%s", out.String())
+	}
+}
+
+func TestSet_RegisterSet(t *testing.T) {
+	set, _ := For(&config{})
+	f := set.Flags()[0]
+	f.Value.Set("db.internal")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.Register(fs)
+
+	if def := fs.Lookup(f.Name).DefValue; def != "localhost" {
+		t.Errorf("Expected the default localhost, got %q", def)
+	}
+	if f.Value.Default() != "localhost" || f.Value.String() != "db.internal" {
+		t.Errorf("Expected default localhost and value db.internal, got %q and %q", f.Value.Default(), f.Value.String())
+	}
+}
+
+func TestFlagName(t *testing.T) {
+	if got := FlagName("DB_HOST"); got != "db-host" {
+		t.Errorf("Expected db-host, got %s", got)
+	}
+}
@@ -0,0 +1,240 @@
+package env
+
+import (
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caleflat/env/spec"
+)
+
+// Environ returns the environment that Parse would read config, a struct
+// or a pointer to one, back from, as KEY=VALUE pairs in the form of
+// os.Environ, for passing resolved configuration to child processes or
+// writing snapshots:
+//
+//	cmd.Env, err = env.Environ(&config)
+//
+// Values are written the way their tags and types read them: fields with
+// `envValues` and `bits` tags by name, Secrets revealed, without using up
+// one-time secrets, and Credentials with their expiry in the companion
+// variable. The transformers of `transform` tags are undone where that is
+// possible, by encoding base64 again, and values that could not be read
+// back unchanged, such as map entries containing commas or values that
+// another transformer would change, are reported as errors. Nil pointers
+// are left out.
+//
+// Values are written as Parse sees them after decryption and transforms,
+// so the environment rebuilds config when parsed without the WithDecryptor
+// and WithTransformers options that config was parsed with.
+func Environ(config interface{}) ([]string, error) {
+	vars, err := spec.For(config)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(config))
+	var environ []string
+	for _, variable := range vars {
+		value, err := v.FieldByIndexErr(variable.Index)
+		if err != nil || value.Kind() == reflect.Ptr && value.IsNil() {
+			continue
+		}
+
+		raw, err := marshalField(variable.StructField, reflect.Indirect(value))
+		if err == nil {
+			raw, err = untransform(variable.StructField.Tag.Get("transform"), raw)
+		}
+		if err != nil {
+			return nil, errors.New("cannot represent environment variable: " + variable.Name + ": " + err.Error())
+		}
+		environ = append(environ, variable.Name+"="+raw)
+
+		if c, ok := reflect.Indirect(value).Interface().(Credential); ok && !c.ExpiresAt.IsZero() {
+			environ = append(environ, variable.Name+ExpirySuffix+"="+c.ExpiresAt.Format(time.RFC3339Nano))
+		}
+	}
+	return environ, nil
+}
+
+// marshalField returns the raw value that sets value, the value of field,
+// the reverse of resolve.
+func marshalField(field reflect.StructField, value reflect.Value) (string, error) {
+	if field.Tag.Get("envValues") != "" {
+		if name, ok := valueName(field, value); ok {
+			return name, nil
+		}
+		return "", errors.New(fmt.Sprint(value.Interface()) + " is not one of " + strings.Join(valueNames(field), ", "))
+	}
+	if name := field.Tag.Get("bits"); name != "" {
+		return marshalBits(name, value)
+	}
+
+	switch v := value.Interface().(type) {
+	case Secret:
+		b, err := v.peek()
+		return string(b), err
+	case Credential:
+		return v.Value, nil
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		return string(b), err
+	case fmt.Stringer:
+		if reflect.PtrTo(value.Type()).Implements(textUnmarshalerType) {
+			return v.String(), nil
+		}
+	}
+
+	switch value.Type() {
+	case fileModeType:
+		return formatFileMode(os.FileMode(value.Uint())), nil
+	case durationType:
+		return time.Duration(value.Int()).String(), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits()), nil
+	case reflect.Map:
+		if value.Type() == stringMapType {
+			return marshalMap(value.Interface().(map[string]string))
+		}
+	}
+	return "", errors.New("unsupported type " + value.Type().String())
+}
+
+// inverseTransformers undo the built-in transformers that can be undone.
+// Those that only normalize a value leave it as it is, as it is already
+// normal after parsing.
+var inverseTransformers = map[string]Transformer{
+	"trim":   func(value string) (string, error) { return value, nil },
+	"lower":  func(value string) (string, error) { return value, nil },
+	"upper":  func(value string) (string, error) { return value, nil },
+	"base64": func(value string) (string, error) { return base64.StdEncoding.EncodeToString([]byte(value)), nil },
+}
+
+// untransform returns the raw value that the transformers named by tag turn
+// into value, the reverse of transform.
+func untransform(tag, value string) (string, error) {
+	names := splitList(tag, ",")
+	raw := value
+	for i := len(names) - 1; i >= 0; i-- {
+		inverse, ok := inverseTransformers[names[i]]
+		if !ok {
+			return "", errors.New("transformer " + names[i] + " cannot be undone")
+		}
+		raw, _ = inverse(raw)
+	}
+
+	// Check that the transformers give value back, as the normalizing ones
+	// and replaced built-ins may not.
+	check := raw
+	for _, name := range names {
+		transformersMu.RLock()
+		t := transformers[name]
+		transformersMu.RUnlock()
+
+		var err error
+		if check, err = t(check); err != nil {
+			return "", errors.New(name + ": " + err.Error())
+		}
+	}
+	if check != value {
+		return "", errors.New(strconv.Quote(value) + " would be changed by transform " + strconv.Quote(tag))
+	}
+	return raw, nil
+}
+
+// marshalBits returns the names of the set of bits called name whose union
+// is value, joined with |.
+func marshalBits(name string, value reflect.Value) (string, error) {
+	set, ok := lookupBits(name)
+	if !ok {
+		return "", errors.New("unknown set " + strconv.Quote(name))
+	}
+
+	var mask uint64
+	if value.CanInt() {
+		mask = uint64(value.Int())
+	} else {
+		mask = value.Uint()
+	}
+
+	// Prefer names covering more bits, so that aliases such as rw=3 are
+	// used over their parts.
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if set[names[i]] != set[names[j]] {
+			return set[names[i]] > set[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	var used []string
+	var union uint64
+	for _, name := range names {
+		if bits := set[name]; bits != 0 && bits&^mask == 0 && bits&^union != 0 {
+			used = append(used, name)
+			union |= bits
+		}
+	}
+	if union != mask {
+		return "", errors.New(strconv.FormatUint(mask, 10) + " is not a union of bits in " + strconv.Quote(name))
+	}
+	sort.Strings(used)
+	return strings.Join(used, "|"), nil
+}
+
+// marshalMap formats m as comma separated key=value pairs with sorted keys.
+func marshalMap(m map[string]string) (string, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		v := m[k]
+		if k == "" || strings.ContainsAny(k, ",=") || strings.Contains(v, ",") ||
+			strings.TrimSpace(k) != k || strings.TrimSpace(v) != v {
+			return "", errors.New("cannot represent map entry " + strconv.Quote(k+"="+v))
+		}
+		pairs[i] = k + "=" + v
+	}
+	return strings.Join(pairs, ","), nil
+}
+
+// formatFileMode returns the octal permission bits of mode, the reverse of
+// parseFileMode.
+func formatFileMode(mode os.FileMode) string {
+	bits := uint64(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return "0" + strconv.FormatUint(bits, 8)
+}
@@ -0,0 +1,225 @@
+package env
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnviron(t *testing.T) {
+	RegisterBits("environ-perms", map[string]uint64{"read": 1, "write": 2, "exec": 4, "rw": 3})
+
+	type Config struct {
+		Name     string            `env:"NAME"`
+		Port     int               `env:"PORT"`
+		Ratio    float32           `env:"RATIO"`
+		Debug    bool              `env:"DEBUG"`
+		Timeout  time.Duration     `env:"TIMEOUT"`
+		Mode     os.FileMode       `env:"MODE"`
+		Labels   map[string]string `env:"LABELS"`
+		Priority *int              `env:"PRIORITY" envValues:"low=1,high=10"`
+		Perms    uint8             `env:"PERMS" bits:"environ-perms"`
+		Started  time.Time         `env:"STARTED"`
+		Password Secret            `env:"PASSWORD" secret:"once"`
+		Token    Credential        `env:"TOKEN"`
+		Missing  *string           `env:"MISSING" default:""`
+		DB       struct {
+			Host string `env:"HOST"`
+		} `env:"DB"`
+	}
+
+	var c Config
+	err := Parse(&c, WithNoOSEnv(), WithSource(mapSource{
+		"NAME":             "app",
+		"PORT":             "8080",
+		"RATIO":            "0.1",
+		"DEBUG":            "true",
+		"TIMEOUT":          "1m30s",
+		"MODE":             "02750",
+		"LABELS":           "team=core,tier=1",
+		"PRIORITY":         "high",
+		"PERMS":            "exec|rw",
+		"STARTED":          "2024-05-01T12:00:00Z",
+		"PASSWORD":         "hunter2",
+		"TOKEN":            "t0k3n",
+		"TOKEN_EXPIRES_AT": "2030-01-01T00:00:00Z",
+		"DB_HOST":          "db.internal",
+	}))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	environ, err := Environ(&c)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	expected := []string{
+		"NAME=app",
+		"PORT=8080",
+		"RATIO=0.1",
+		"DEBUG=true",
+		"TIMEOUT=1m30s",
+		"MODE=02750",
+		"LABELS=team=core,tier=1",
+		"PRIORITY=high",
+		"PERMS=exec|rw",
+		"STARTED=2024-05-01T12:00:00Z",
+		"PASSWORD=hunter2",
+		"TOKEN=t0k3n",
+		"TOKEN_EXPIRES_AT=2030-01-01T00:00:00Z",
+		"MISSING=",
+		"DB_HOST=db.internal",
+	}
+	if !reflect.DeepEqual(environ, expected) {
+		t.Errorf("Expected %v, got %v", expected, environ)
+	}
+
+	c.Missing = nil
+	environ, err = Environ(c)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, "MISSING=") {
+			t.Errorf("Expected a nil pointer to be left out, got %s", kv)
+		}
+	}
+
+	if _, err := c.Password.Reveal(); err != nil {
+		t.Errorf("Expected Environ not to use up a one-time secret, got %v", err)
+	}
+}
+
+func TestEnviron_RoundTrip(t *testing.T) {
+	type Config struct {
+		Hosts   PathList          `env:"HOSTS"`
+		Tuning  FlagsMap          `env:"TUNING"`
+		Size    Size              `env:"SIZE"`
+		Weights map[string]string `env:"WEIGHTS"`
+	}
+
+	src := mapSource{"HOSTS": "/a" + string(os.PathListSeparator) + "/b", "TUNING": "gc=50,trace=1", "SIZE": "10MiB", "WEIGHTS": ""}
+	var c Config
+	if err := Parse(&c, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	environ, err := Environ(c)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	back := mapSource{}
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		back[k] = v
+	}
+	var parsed Config
+	if err := Parse(&parsed, WithNoOSEnv(), WithSource(back)); err != nil {
+		t.Fatalf("Failed to parse marshalled environment %v: %v", environ, err)
+	}
+	if !reflect.DeepEqual(parsed, c) {
+		t.Errorf("Expected %+v, got %+v", c, parsed)
+	}
+}
+
+func TestEnviron_CredentialPointer(t *testing.T) {
+	type Config struct {
+		Endpoint URL         `env:"U"`
+		Token    *Credential `env:"TOK"`
+	}
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := Config{Token: &Credential{Value: "v", ExpiresAt: expires}}
+
+	environ, err := Environ(&c)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if expected := []string{"U=", "TOK=v", "TOK_EXPIRES_AT=2030-01-02T03:04:05Z"}; !reflect.DeepEqual(environ, expected) {
+		t.Errorf("Expected %v, got %v", expected, environ)
+	}
+
+	back := mapSource{}
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		back[k] = v
+	}
+	var parsed Config
+	if err := Parse(&parsed, WithNoOSEnv(), WithSource(back)); err != nil {
+		t.Fatalf("Failed to parse marshalled environment %v: %v", environ, err)
+	}
+	if parsed.Endpoint != c.Endpoint || parsed.Token == nil || parsed.Token.Value != "v" || !parsed.Token.ExpiresAt.Equal(expires) {
+		t.Errorf("Expected %+v, got %+v", c, parsed)
+	}
+}
+
+func TestEnviron_Unrepresentable(t *testing.T) {
+	type Config struct {
+		Labels map[string]string `env:"LABELS"`
+	}
+
+	_, err := Environ(Config{Labels: map[string]string{"a": "1,2"}})
+	expected := `cannot represent environment variable: LABELS: cannot represent map entry "a=1,2"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	type Levels struct {
+		Priority int `env:"PRIORITY" envValues:"low=1,high=10"`
+	}
+	if _, err := Environ(Levels{Priority: 5}); err == nil {
+		t.Error("Expected an error for a value without a name")
+	}
+}
+
+func TestEnviron_Transform(t *testing.T) {
+	type Config struct {
+		Key   string `env:"SIGNING_KEY" transform:"base64"`
+		Name  string `env:"NAME" transform:"trim,lower"`
+		Token string `env:"TOKEN" transform:"trim,base64"`
+	}
+
+	src := mapSource{"SIGNING_KEY": "c2VjcmV0", "NAME": " App ", "TOKEN": " dDBrM24= "}
+	var c Config
+	if err := Parse(&c, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	environ, err := Environ(c)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if expected := []string{"SIGNING_KEY=c2VjcmV0", "NAME=app", "TOKEN=dDBrM24="}; !reflect.DeepEqual(environ, expected) {
+		t.Errorf("Expected %v, got %v", expected, environ)
+	}
+
+	back := mapSource{}
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		back[k] = v
+	}
+	var parsed Config
+	if err := Parse(&parsed, WithNoOSEnv(), WithSource(back)); err != nil {
+		t.Fatalf("Failed to parse marshalled environment %v: %v", environ, err)
+	}
+	if parsed != c {
+		t.Errorf("Expected %+v, got %+v", c, parsed)
+	}
+
+	_, err = Environ(Config{Name: "Mixed Case"})
+	expected := `cannot represent environment variable: NAME: "Mixed Case" would be changed by transform "trim,lower"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	type Expanded struct {
+		URL string `env:"URL" transform:"expand"`
+	}
+	if _, err := Environ(Expanded{URL: "http://localhost"}); err == nil {
+		t.Error("Expected an error for a transformer that cannot be undone")
+	}
+}
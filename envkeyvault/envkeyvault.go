@@ -0,0 +1,245 @@
+// Package envkeyvault provides an env.Source backed by the secrets of an
+// Azure Key Vault, using only the standard library.
+//
+// The enabled secrets of the vault are read when the source is created and
+// served under names derived from theirs, with dashes, which Key Vault
+// allows, in place of underscores, which it does not:
+//
+//	src, err := envkeyvault.New(ctx, "https://myapp.vault.azure.net")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = env.Parse(&config, env.WithSource(src))
+//
+// The secret db-password is served as DB_PASSWORD.
+package envkeyvault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+const apiVersion = "7.4"
+
+// These are variables so that tests can replace them.
+var (
+	loginURL = "https://login.microsoftonline.com"
+	imdsURL  = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// TokenFunc returns an access token for Key Vault.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// ClientSecret returns a TokenFunc authenticating as the service principal
+// clientID of tenantID with secret.
+func ClientSecret(tenantID, clientID, secret string) TokenFunc {
+	return func(ctx context.Context) (string, error) {
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {secret},
+			"scope":         {"https://vault.azure.net/.default"},
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			loginURL+"/"+url.PathEscape(tenantID)+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return requestToken(req)
+	}
+}
+
+// ManagedIdentity returns a TokenFunc authenticating as the managed identity
+// of the Azure resource the program runs on.
+func ManagedIdentity() TokenFunc {
+	return func(ctx context.Context) (string, error) {
+		query := url.Values{"api-version": {"2018-02-01"}, "resource": {"https://vault.azure.net"}}
+		if id := os.Getenv("AZURE_CLIENT_ID"); id != "" {
+			query.Set("client_id", id)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+		return requestToken(req)
+	}
+}
+
+func requestToken(req *http.Request) (string, error) {
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := do(http.DefaultClient, req, &token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithToken makes the source authenticate with the tokens returned by fn.
+// By default, it uses ClientSecret with AZURE_TENANT_ID, AZURE_CLIENT_ID and
+// AZURE_CLIENT_SECRET if AZURE_CLIENT_SECRET is set, and ManagedIdentity
+// otherwise.
+func WithToken(fn TokenFunc) Option {
+	return func(s *Source) {
+		s.token = fn
+	}
+}
+
+// WithClient makes the source use client for its requests to the vault
+// instead of http.DefaultClient.
+func WithClient(client *http.Client) Option {
+	return func(s *Source) {
+		s.client = client
+	}
+}
+
+// Source is an env.Source serving the secrets of a Key Vault.
+type Source struct {
+	vault  string
+	token  TokenFunc
+	client *http.Client
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// New reads the enabled secrets of the vault at vaultURL, such as
+// https://myapp.vault.azure.net, and returns a Source serving them. A
+// secret's name is upper-cased, with dashes replaced by underscores.
+func New(ctx context.Context, vaultURL string, opts ...Option) (*Source, error) {
+	s := &Source{vault: strings.TrimSuffix(vaultURL, "/"), client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.token == nil {
+		if secret := os.Getenv("AZURE_CLIENT_SECRET"); secret != "" {
+			s.token = ClientSecret(os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), secret)
+		} else {
+			s.token = ManagedIdentity()
+		}
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup implements env.Source.
+func (s *Source) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// String implements fmt.Stringer.
+func (s *Source) String() string {
+	return "keyvault(" + s.vault + ")"
+}
+
+// Refresh reads the secrets again. If that fails, the current values are
+// kept.
+func (s *Source) Refresh(ctx context.Context) error {
+	token, err := s.token(ctx)
+	if err != nil {
+		return errors.New("envkeyvault: cannot get token: " + err.Error())
+	}
+
+	values := make(map[string]string)
+
+	next := s.vault + "/secrets?api-version=" + apiVersion
+	for next != "" {
+		var page struct {
+			Value []struct {
+				ID         string `json:"id"`
+				Attributes struct {
+					Enabled bool `json:"enabled"`
+				} `json:"attributes"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := s.get(ctx, token, next, &page); err != nil {
+			return err
+		}
+
+		for _, item := range page.Value {
+			if !item.Attributes.Enabled {
+				continue
+			}
+
+			var secret struct {
+				Value string `json:"value"`
+			}
+			if err := s.get(ctx, token, item.ID+"?api-version="+apiVersion, &secret); err != nil {
+				return err
+			}
+			values[keyOf(item.ID[strings.LastIndex(item.ID, "/")+1:])] = secret.Value
+		}
+		next = page.NextLink
+	}
+
+	s.mu.Lock()
+	s.values = values
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Source) get(ctx context.Context, token, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return do(s.client, req, v)
+}
+
+// do sends req and decodes its JSON response into v.
+func do(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.New("envkeyvault: " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.New("envkeyvault: " + err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &e) != nil || e.Error.Code == "" {
+			return errors.New("envkeyvault: unexpected status " + resp.Status)
+		}
+		return errors.New("envkeyvault: " + e.Error.Code + ": " + e.Error.Message)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("envkeyvault: " + err.Error())
+	}
+	return nil
+}
+
+// keyOf returns the variable name the secret name is served under.
+func keyOf(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
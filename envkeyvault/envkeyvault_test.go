@@ -0,0 +1,155 @@
+package envkeyvault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caleflat/env"
+)
+
+// vault serves secrets, two per page, to requests carrying token.
+func vault(t *testing.T, token string, secrets map[string]string, disabled ...string) *httptest.Server {
+	names := []string{}
+	for name := range secrets {
+		names = append(names, name)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"Unauthorized","message":"bad token"}}`))
+			return
+		}
+		if r.URL.Query().Get("api-version") != apiVersion {
+			t.Errorf("Expected api-version %s, got %q", apiVersion, r.URL.Query().Get("api-version"))
+		}
+
+		if name := strings.TrimPrefix(r.URL.Path, "/secrets/"); name != r.URL.Path {
+			json.NewEncoder(w).Encode(map[string]string{"value": secrets[name]})
+			return
+		}
+
+		start := 0
+		if r.URL.Query().Get("page") == "2" {
+			start = 2
+		}
+		type item struct {
+			ID         string          `json:"id"`
+			Attributes map[string]bool `json:"attributes"`
+		}
+		var page struct {
+			Value    []item `json:"value"`
+			NextLink string `json:"nextLink,omitempty"`
+		}
+		for i := start; i < len(names) && i < start+2; i++ {
+			enabled := true
+			for _, d := range disabled {
+				enabled = enabled && d != names[i]
+			}
+			page.Value = append(page.Value, item{srv.URL + "/secrets/" + names[i], map[string]bool{"enabled": enabled}})
+		}
+		if start == 0 && len(names) > 2 {
+			page.NextLink = srv.URL + "/secrets?api-version=" + apiVersion + "&page=2"
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func static(token string) TokenFunc {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+func TestNew(t *testing.T) {
+	srv := vault(t, "t0ken", map[string]string{
+		"db-password": "hunter2",
+		"api-url":     "https://api.example.com",
+		"PORT":        "8080",
+		"old-secret":  "stale",
+	}, "old-secret")
+
+	s, err := New(context.Background(), srv.URL+"/", WithToken(static("t0ken")))
+	if err != nil {
+		t.Fatalf("Failed to read secrets: %v", err)
+	}
+
+	var c struct {
+		Password string `env:"DB_PASSWORD"`
+		APIURL   string `env:"API_URL"`
+		Port     int    `env:"PORT"`
+	}
+	if err := env.Parse(&c, env.WithNoOSEnv(), env.WithSource(s)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if c.Password != "hunter2" || c.APIURL != "https://api.example.com" || c.Port != 8080 {
+		t.Errorf("Unexpected config: %+v", c)
+	}
+
+	if _, ok := s.Lookup("OLD_SECRET"); ok {
+		t.Error("Expected disabled secrets to be skipped")
+	}
+	if s.String() != "keyvault("+srv.URL+")" {
+		t.Errorf("Unexpected name %s", s)
+	}
+}
+
+func TestNew_Error(t *testing.T) {
+	srv := vault(t, "t0ken", nil)
+
+	_, err := New(context.Background(), srv.URL, WithToken(static("wrong")))
+	expected := "envkeyvault: Unauthorized: bad token"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestClientSecret(t *testing.T) {
+	login := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		r.ParseForm()
+		if r.Form.Get("client_id") != "app" || r.Form.Get("client_secret") != "s3cret" {
+			t.Errorf("Unexpected credentials %v", r.Form)
+		}
+		w.Write([]byte(`{"access_token":"t0ken","expires_in":3599}`))
+	}))
+	defer login.Close()
+
+	defer func(u string) { loginURL = u }(loginURL)
+	loginURL = login.URL
+
+	token, err := ClientSecret("tenant", "app", "s3cret")(context.Background())
+	if err != nil || token != "t0ken" {
+		t.Errorf("Expected t0ken, got %q, %v", token, err)
+	}
+}
+
+func TestManagedIdentity(t *testing.T) {
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			t.Error("Expected the Metadata header")
+		}
+		if r.URL.Query().Get("resource") != "https://vault.azure.net" {
+			t.Errorf("Unexpected resource %q", r.URL.Query().Get("resource"))
+		}
+		w.Write([]byte(`{"access_token":"t0ken","expires_in":"3599"}`))
+	}))
+	defer imds.Close()
+
+	defer func(u string) { imdsURL = u }(imdsURL)
+	imdsURL = imds.URL
+
+	token, err := ManagedIdentity()(context.Background())
+	if err != nil || token != "t0ken" {
+		t.Errorf("Expected t0ken, got %q, %v", token, err)
+	}
+}
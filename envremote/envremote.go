@@ -0,0 +1,221 @@
+// Package envremote provides env.Sources that fetch configuration from
+// remote servers using only the standard library.
+package envremote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caleflat/env/internal/dotenv"
+)
+
+// Option configures an HTTP source.
+type Option func(*Source)
+
+// WithClient makes the source use client for its requests instead of a
+// client with a timeout of DefaultTimeout.
+func WithClient(client *http.Client) Option {
+	return func(s *Source) {
+		s.client = client
+	}
+}
+
+// WithHeader adds a header to the source's requests, such as an
+// Authorization header.
+func WithHeader(key, value string) Option {
+	return func(s *Source) {
+		s.header.Add(key, value)
+	}
+}
+
+// WithInterval makes Watch fetch the document again every d. Without it,
+// Watch only waits for its context to be done.
+func WithInterval(d time.Duration) Option {
+	return func(s *Source) {
+		s.interval = d
+	}
+}
+
+// DefaultTimeout is the timeout of the requests of sources not given a
+// client with WithClient.
+const DefaultTimeout = 30 * time.Second
+
+// maxDocumentSize is the size in bytes of the largest document a source
+// reads, so that a misbehaving server cannot exhaust memory.
+var maxDocumentSize int64 = 10 << 20
+
+// Source is an env.Source serving the variables of a document fetched over
+// HTTP. It implements env.Watcher, polling the document at the interval set
+// with WithInterval.
+type Source struct {
+	url      string
+	client   *http.Client
+	header   http.Header
+	interval time.Duration
+
+	mu     sync.RWMutex
+	values map[string]string
+	etag   string
+}
+
+// HTTP fetches the document at url and returns a Source serving its
+// variables. The document is either a JSON object, if served with a JSON
+// content type, or a .env file of KEY=VALUE lines. JSON values that are not
+// strings are served as their JSON text, so {"PORT": 8080} reads as "8080".
+//
+// The document is cached. Refresh and Watch fetch it again, using its ETag,
+// if the server sent one, to skip unchanged documents. Documents larger
+// than 10 MiB are rejected.
+func HTTP(url string, opts ...Option) (*Source, error) {
+	s := &Source{url: url, client: &http.Client{Timeout: DefaultTimeout}, header: make(http.Header)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if _, err := s.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup implements env.Source.
+func (s *Source) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// String implements fmt.Stringer.
+func (s *Source) String() string {
+	return s.url
+}
+
+// Refresh fetches the document again and reports whether its contents
+// changed. If fetching fails, the cached variables are kept.
+func (s *Source) Refresh(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header = s.header.Clone()
+
+	s.mu.RLock()
+	etag := s.etag
+	s.mu.RUnlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return false, nil
+	default:
+		return false, errors.New(s.url + ": unexpected status " + resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err != nil {
+		return false, err
+	}
+	if int64(len(body)) > maxDocumentSize {
+		return false, errors.New(s.url + ": document larger than " + strconv.FormatInt(maxDocumentSize, 10) + " bytes")
+	}
+
+	values, err := decode(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return false, errors.New(s.url + ": " + err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := !equal(s.values, values)
+	s.values, s.etag = values, resp.Header.Get("ETag")
+	return changed, nil
+}
+
+// Watch implements env.Watcher. It fetches the document at the interval set
+// with WithInterval and calls changed when its contents change, until ctx
+// is done. Failed fetches are retried at the next interval.
+func (s *Source) Watch(ctx context.Context, changed func()) error {
+	if s.interval <= 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if ok, err := s.Refresh(ctx); err == nil && ok {
+			changed()
+		}
+	}
+}
+
+// decode parses a document served with contentType.
+func decode(contentType string, body []byte) (map[string]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		entries, err := dotenv.Parse(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		return dotenv.Map(entries), nil
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(doc))
+	for key, raw := range doc {
+		// Unmarshaling null into a string succeeds and leaves it empty.
+		if string(raw) == "null" {
+			return nil, errors.New("null value for " + strconv.Quote(key))
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			values[key] = s
+			continue
+		}
+		values[key] = string(raw)
+	}
+	return values, nil
+}
+
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
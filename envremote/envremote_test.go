@@ -0,0 +1,262 @@
+package envremote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caleflat/env"
+	"github.com/caleflat/env/sourcetest"
+)
+
+func TestHTTP_Conformance(t *testing.T) {
+	sourcetest.Run(t, func(t *testing.T, vars map[string]string) env.Source {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(vars)
+		}))
+		t.Cleanup(srv.Close)
+
+		s, err := HTTP(srv.URL)
+		if err != nil {
+			t.Fatalf("Failed to fetch variables: %v", err)
+		}
+		return s
+	})
+}
+
+func TestHTTP_Dotenv(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("# comment\nHOST=localhost\nPORT=8080\n"))
+	}))
+	defer srv.Close()
+
+	s, err := HTTP(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to fetch variables: %v", err)
+	}
+
+	var c struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	if err := env.Parse(&c, env.WithNoOSEnv(), env.WithSource(s)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if c.Host != "localhost" || c.Port != 8080 {
+		t.Errorf("Expected localhost:8080, got %s:%d", c.Host, c.Port)
+	}
+}
+
+func TestHTTP_JSONValues(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"PORT": 8080, "DEBUG": true, "NAME": "api"}`))
+	}))
+	defer srv.Close()
+
+	s, err := HTTP(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to fetch variables: %v", err)
+	}
+
+	for key, expected := range map[string]string{"PORT": "8080", "DEBUG": "true", "NAME": "api"} {
+		if value, _ := s.Lookup(key); value != expected {
+			t.Errorf("Expected %s to be %q, got %q", key, expected, value)
+		}
+	}
+}
+
+func TestHTTP_Header(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("HOST=localhost\n"))
+	}))
+	defer srv.Close()
+
+	if _, err := HTTP(srv.URL); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an unauthorized error, got %v", err)
+	}
+
+	if _, err := HTTP(srv.URL, WithHeader("Authorization", "Bearer secret")); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestHTTP_Invalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`["HOST"]`))
+	}))
+	defer srv.Close()
+
+	if _, err := HTTP(srv.URL); err == nil || !strings.HasPrefix(err.Error(), srv.URL+": ") {
+		t.Errorf("Expected an error naming %s, got %v", srv.URL, err)
+	}
+}
+
+func TestHTTP_Null(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"HOST":"db","DB_PASSWORD":null}`))
+	}))
+	defer srv.Close()
+
+	_, err := HTTP(srv.URL)
+	if err == nil || !strings.HasSuffix(err.Error(), `null value for "DB_PASSWORD"`) {
+		t.Errorf("Expected an error for the null value, got %v", err)
+	}
+}
+
+func TestHTTP_TooLarge(t *testing.T) {
+	defer func(n int64) { maxDocumentSize = n }(maxDocumentSize)
+	maxDocumentSize = 16
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("HOST=db\nPASSWORD=hunter2\n"))
+	}))
+	defer srv.Close()
+
+	_, err := HTTP(srv.URL)
+	if expected := srv.URL + ": document larger than 16 bytes"; err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestHTTP_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("HOST=db\n"))
+	}))
+	defer srv.Close()
+
+	s, err := HTTP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.client.Timeout != DefaultTimeout {
+		t.Errorf("Expected a timeout of %v, got %v", DefaultTimeout, s.client.Timeout)
+	}
+}
+
+func TestSource_Refresh(t *testing.T) {
+	var mu sync.Mutex
+	body, version, conditional := "HOST=a\n", 1, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		etag := `"v` + strconv.Itoa(version) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s, err := HTTP(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to fetch variables: %v", err)
+	}
+
+	if changed, err := s.Refresh(context.Background()); err != nil || changed {
+		t.Errorf("Expected no change, got %v, %v", changed, err)
+	}
+	if conditional != 1 {
+		t.Errorf("Expected 1 conditional request, got %d", conditional)
+	}
+
+	mu.Lock()
+	body, version = "HOST=b\n", 2
+	mu.Unlock()
+
+	if changed, err := s.Refresh(context.Background()); err != nil || !changed {
+		t.Errorf("Expected a change, got %v, %v", changed, err)
+	}
+	if value, _ := s.Lookup("HOST"); value != "b" {
+		t.Errorf("Expected HOST to be %q, got %q", "b", value)
+	}
+}
+
+func TestSource_RefreshKeepsValues(t *testing.T) {
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("HOST=localhost\n"))
+	}))
+	defer srv.Close()
+
+	s, err := HTTP(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to fetch variables: %v", err)
+	}
+
+	fail.Store(true)
+	if _, err := s.Refresh(context.Background()); err == nil {
+		t.Error("Expected an error, got nil")
+	}
+	if value, _ := s.Lookup("HOST"); value != "localhost" {
+		t.Errorf("Expected HOST to be kept, got %q", value)
+	}
+}
+
+func TestSource_Watch(t *testing.T) {
+	var mu sync.Mutex
+	body := "HOST=a\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s, err := HTTP(srv.URL, WithInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to fetch variables: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	mu.Lock()
+	body = "HOST=b\n"
+	mu.Unlock()
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a change to be reported")
+	}
+	if value, _ := s.Lookup("HOST"); value != "b" {
+		t.Errorf("Expected HOST to be %q, got %q", "b", value)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,252 @@
+// Package envssm provides an env.Source backed by AWS Systems Manager
+// Parameter Store, using only the standard library.
+//
+// The parameters under a path are read in pages when the source is created,
+// with SecureString parameters decrypted, and served under names derived
+// from their paths:
+//
+//	src, err := envssm.New(ctx, "/myapp/prod/")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = env.Parse(&config, env.WithSource(src))
+//
+// The parameter /myapp/prod/db/password is served as DB_PASSWORD.
+package envssm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials are the AWS credentials requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithRegion sets the AWS region, instead of the one in AWS_REGION or
+// AWS_DEFAULT_REGION.
+func WithRegion(region string) Option {
+	return func(s *Source) {
+		s.region = region
+	}
+}
+
+// WithCredentials sets the credentials requests are signed with, instead of
+// those in AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+func WithCredentials(creds Credentials) Option {
+	return func(s *Source) {
+		s.creds = creds
+	}
+}
+
+// WithEndpoint sends requests to endpoint instead of the regional SSM
+// endpoint, for VPC endpoints or local emulators.
+func WithEndpoint(endpoint string) Option {
+	return func(s *Source) {
+		s.endpoint = endpoint
+	}
+}
+
+// WithClient makes the source use client for its requests instead of
+// http.DefaultClient.
+func WithClient(client *http.Client) Option {
+	return func(s *Source) {
+		s.client = client
+	}
+}
+
+// Source is an env.Source serving the parameters under a path in Parameter
+// Store.
+type Source struct {
+	path     string
+	region   string
+	creds    Credentials
+	endpoint string
+	client   *http.Client
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// New reads the parameters under path, and the paths below it, and returns
+// a Source serving them. A parameter's name is the rest of its path after
+// path, upper-cased, with slashes, dashes and dots replaced by underscores.
+// A path of "/" or "" reads every parameter.
+func New(ctx context.Context, path string, opts ...Option) (*Source, error) {
+	s := &Source{
+		path:   "/",
+		region: firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		creds: Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		client: http.DefaultClient,
+	}
+	if path = strings.Trim(path, "/"); path != "" {
+		s.path += path + "/"
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.region == "" {
+		return nil, errors.New("envssm: no region set")
+	}
+	if s.creds.AccessKeyID == "" || s.creds.SecretAccessKey == "" {
+		return nil, errors.New("envssm: no credentials set")
+	}
+	if s.endpoint == "" {
+		s.endpoint = "https://ssm." + s.region + ".amazonaws.com"
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup implements env.Source.
+func (s *Source) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// LookupBatch implements env.BatchSource.
+func (s *Source) LookupBatch(keys []string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := s.values[key]; ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// String implements fmt.Stringer.
+func (s *Source) String() string {
+	return "ssm(" + s.path + ")"
+}
+
+// Refresh reads the parameters again. If that fails, the current values are
+// kept.
+func (s *Source) Refresh(ctx context.Context) error {
+	values := make(map[string]string)
+
+	var token string
+	for {
+		page, err := s.getParametersByPath(ctx, token)
+		if err != nil {
+			return err
+		}
+		for _, p := range page.Parameters {
+			values[keyOf(strings.TrimPrefix(p.Name, s.path))] = p.Value
+		}
+		if token = page.NextToken; token == "" {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	s.values = values
+	s.mu.Unlock()
+	return nil
+}
+
+type getParametersByPathInput struct {
+	Path           string
+	Recursive      bool
+	WithDecryption bool
+	NextToken      string `json:",omitempty"`
+}
+
+type getParametersByPathOutput struct {
+	Parameters []struct {
+		Name  string
+		Value string
+	}
+	NextToken string
+}
+
+func (s *Source) getParametersByPath(ctx context.Context, token string) (*getParametersByPathOutput, error) {
+	body, err := json.Marshal(getParametersByPathInput{
+		Path:           s.path,
+		Recursive:      true,
+		WithDecryption: true,
+		NextToken:      token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonSSM.GetParametersByPath")
+	sign(req, body, s.creds, s.region, "ssm", time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.New("envssm: " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.New("envssm: " + err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &e)
+		if e.Type == "" {
+			return nil, errors.New("envssm: unexpected status " + resp.Status)
+		}
+		return nil, errors.New("envssm: " + e.Type + ": " + e.Message)
+	}
+
+	var out getParametersByPathOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, errors.New("envssm: " + err.Error())
+	}
+	return &out, nil
+}
+
+// keyOf returns the variable name a parameter at the relative path name is
+// served under.
+func keyOf(name string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(name))
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
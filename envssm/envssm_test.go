@@ -0,0 +1,159 @@
+package envssm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/caleflat/env"
+)
+
+type parameter struct {
+	Name  string
+	Value string
+}
+
+// server serves params from a fake GetParametersByPath, pageSize at a time.
+func server(t *testing.T, params []parameter, pageSize int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "AmazonSSM.GetParametersByPath" {
+			t.Errorf("Expected GetParametersByPath, got %q", target)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("Expected a signed request, got %q", r.Header.Get("Authorization"))
+		}
+
+		var in getParametersByPathInput
+		json.NewDecoder(r.Body).Decode(&in)
+		if !in.Recursive || !in.WithDecryption {
+			t.Errorf("Expected a recursive, decrypted read, got %+v", in)
+		}
+
+		start, _ := strconv.Atoi(in.NextToken)
+		var out struct {
+			Parameters []parameter
+			NextToken  string `json:",omitempty"`
+		}
+		for _, p := range params[start:] {
+			if len(out.Parameters) == pageSize {
+				out.NextToken = strconv.Itoa(start + pageSize)
+				break
+			}
+			if strings.HasPrefix(p.Name, in.Path) {
+				out.Parameters = append(out.Parameters, p)
+			}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newSource(t *testing.T, srv *httptest.Server, path string) *Source {
+	s, err := New(context.Background(), path,
+		WithRegion("us-east-1"),
+		WithCredentials(Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}),
+		WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatalf("Failed to read parameters: %v", err)
+	}
+	return s
+}
+
+func TestNew(t *testing.T) {
+	srv := server(t, []parameter{
+		{"/myapp/prod/db/password", "hunter2"},
+		{"/myapp/prod/log-level", "debug"},
+		{"/myapp/prod/api.url", "https://api.example.com"},
+		{"/myapp/prod/PORT", "8080"},
+		{"/myapp/staging/PORT", "9090"},
+	}, 2)
+	s := newSource(t, srv, "myapp/prod")
+
+	var c struct {
+		Password string `env:"DB_PASSWORD"`
+		LogLevel string `env:"LOG_LEVEL"`
+		APIURL   string `env:"API_URL"`
+		Port     int    `env:"PORT"`
+	}
+	if err := env.Parse(&c, env.WithNoOSEnv(), env.WithSource(s)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if c.Password != "hunter2" || c.LogLevel != "debug" || c.APIURL != "https://api.example.com" || c.Port != 8080 {
+		t.Errorf("Unexpected config: %+v", c)
+	}
+}
+
+func TestNew_Root(t *testing.T) {
+	srv := server(t, []parameter{{"/myapp/PORT", "8080"}, {"/LOG_LEVEL", "debug"}}, 10)
+
+	for _, path := range []string{"/", ""} {
+		s := newSource(t, srv, path)
+		if s.String() != "ssm(/)" {
+			t.Errorf("Expected ssm(/) for %q, got %s", path, s)
+		}
+		if value, _ := s.Lookup("MYAPP_PORT"); value != "8080" {
+			t.Errorf("Expected MYAPP_PORT to be 8080 for %q, got %q", path, value)
+		}
+		if value, _ := s.Lookup("LOG_LEVEL"); value != "debug" {
+			t.Errorf("Expected LOG_LEVEL to be debug for %q, got %q", path, value)
+		}
+	}
+}
+
+func TestSource_LookupBatch(t *testing.T) {
+	srv := server(t, []parameter{{"/app/HOST", "localhost"}, {"/app/PORT", "8080"}}, 10)
+	s := newSource(t, srv, "/app/")
+
+	got := s.LookupBatch([]string{"HOST", "DEBUG"})
+	if expected := map[string]string{"HOST": "localhost"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestNew_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"AccessDeniedException","message":"not allowed"}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(context.Background(), "/app",
+		WithRegion("us-east-1"),
+		WithCredentials(Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}),
+		WithEndpoint(srv.URL))
+
+	expected := "envssm: AccessDeniedException: not allowed"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestNew_Config(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	if _, err := New(context.Background(), "/app"); err == nil || err.Error() != "envssm: no region set" {
+		t.Errorf("Expected a missing region error, got %v", err)
+	}
+
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+	if _, err := New(context.Background(), "/app"); err == nil || err.Error() != "envssm: no credentials set" {
+		t.Errorf("Expected a missing credentials error, got %v", err)
+	}
+}
+
+func TestSource_String(t *testing.T) {
+	srv := server(t, nil, 10)
+	if s := newSource(t, srv, "myapp/prod/"); s.String() != "ssm(/myapp/prod/)" {
+		t.Errorf("Expected ssm(/myapp/prod/), got %s", s)
+	}
+}
@@ -0,0 +1,103 @@
+// Package envtest provides helpers for testing code configured with the env
+// package.
+package envtest
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/caleflat/env"
+	"github.com/caleflat/env/internal/dotenv"
+	"github.com/caleflat/env/spec"
+)
+
+// CheckExample fails t if the example env file at path and the variables
+// read by config disagree: every variable config reads must appear in the
+// file, and every variable in the file must be read by config. The expiry
+// variables of env.Credential fields may be left out, since they are
+// optional.
+//
+//	func TestEnvExample(t *testing.T) {
+//		envtest.CheckExample(t, &Config{}, "../../.env.example")
+//	}
+func CheckExample(t testing.TB, config interface{}, path string) {
+	t.Helper()
+
+	keys, err := env.Keys(config)
+	if err != nil {
+		t.Fatalf("envtest: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("envtest: %v", err)
+	}
+	defer f.Close()
+
+	entries, err := dotenv.Parse(f)
+	if err != nil {
+		t.Fatalf("envtest: %s: %v", path, err)
+	}
+
+	declared := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		declared[key] = true
+	}
+
+	documented := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		documented[e.Key] = true
+	}
+
+	vars, _ := spec.For(config) // Keys has checked config
+	for _, v := range vars {
+		if t := v.StructField.Type; t == reflect.TypeOf(env.Credential{}) || t == reflect.TypeOf(&env.Credential{}) {
+			documented[v.Name+env.ExpirySuffix] = true
+		}
+	}
+
+	if missing := difference(declared, documented); len(missing) > 0 {
+		t.Errorf("envtest: %s is missing variables read by %T: %s", path, config, strings.Join(missing, ", "))
+	}
+
+	if extra := difference(documented, declared); len(extra) > 0 {
+		t.Errorf("envtest: %s has variables not read by %T: %s", path, config, strings.Join(extra, ", "))
+	}
+}
+
+// difference returns the sorted keys of a that are not in b.
+func difference(a, b map[string]bool) []string {
+	var keys []string
+	for key := range a {
+		if !b[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// With sets the variables in vars for the duration of the test, with the
+// semantics of t.Setenv: the previous values are restored when the test and
+// its subtests complete, and the test must not be parallel.
+//
+//	envtest.With(t, map[string]string{
+//		"PORT": "8080",
+//		"HOST": "localhost",
+//	})
+func With(t testing.TB, vars map[string]string) {
+	t.Helper()
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		t.Setenv(key, vars[key])
+	}
+}
@@ -0,0 +1,96 @@
+package envtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caleflat/env"
+)
+
+// recorder is a testing.TB that records failures instead of failing.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+type config struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT"`
+}
+
+func writeExample(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), ".env.example")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckExample(t *testing.T) {
+	CheckExample(t, &config{}, writeExample(t, "# Host to bind.\nHOST=localhost\nPORT=8080\n"))
+}
+
+func TestCheckExample_Mismatch(t *testing.T) {
+	r := &recorder{TB: t}
+	CheckExample(r, &config{}, writeExample(t, "HOST=localhost\nDEBUG=true\n"))
+
+	if len(r.errors) != 2 {
+		t.Fatalf("Expected two failures, got %q", r.errors)
+	}
+
+	if !strings.Contains(r.errors[0], "missing variables read by *envtest.config: PORT") {
+		t.Errorf("Unexpected failure %q", r.errors[0])
+	}
+
+	if !strings.Contains(r.errors[1], "variables not read by *envtest.config: DEBUG") {
+		t.Errorf("Unexpected failure %q", r.errors[1])
+	}
+}
+
+func TestCheckExample_Credential(t *testing.T) {
+	type config struct {
+		Token env.Credential `env:"TOKEN"`
+	}
+
+	CheckExample(t, &config{}, writeExample(t, "TOKEN=t\n"))
+	CheckExample(t, &config{}, writeExample(t, "TOKEN=t\nTOKEN_EXPIRES_AT=2030-01-01T00:00:00Z\n"))
+}
+
+func TestWith(t *testing.T) {
+	t.Setenv("ENVTEST_PORT", "1")
+	t.Setenv("ENVTEST_HOST", "") // restored when the test ends
+	os.Unsetenv("ENVTEST_HOST")
+
+	t.Run("scoped", func(t *testing.T) {
+		With(t, map[string]string{"ENVTEST_PORT": "8080", "ENVTEST_HOST": "localhost"})
+
+		if v := os.Getenv("ENVTEST_PORT"); v != "8080" {
+			t.Errorf("Expected 8080, got %q", v)
+		}
+		if v := os.Getenv("ENVTEST_HOST"); v != "localhost" {
+			t.Errorf("Expected localhost, got %q", v)
+		}
+	})
+
+	if v := os.Getenv("ENVTEST_PORT"); v != "1" {
+		t.Errorf("Expected the previous value to be restored, got %q", v)
+	}
+	if _, ok := os.LookupEnv("ENVTEST_HOST"); ok {
+		t.Error("Expected the variable to be unset again")
+	}
+}
@@ -0,0 +1,112 @@
+package envtest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Source is an env.Source backed by a map that records which keys are
+// looked up, so that tests can check a component reads exactly the
+// configuration it claims to:
+//
+//	src := envtest.NewSource(map[string]string{"PORT": "8080"})
+//	err := env.Parse(&config, env.WithNoOSEnv(), env.WithSource(src))
+//	src.AssertRead(t, "PORT", "HOST")
+//
+// A Source is safe for concurrent use. It returns the same values for the
+// same lookups, whatever order they are made in.
+type Source struct {
+	mu     sync.Mutex
+	values map[string]string
+	reads  map[string]bool
+}
+
+// NewSource returns a Source holding a copy of values.
+func NewSource(values map[string]string) *Source {
+	s := &Source{values: make(map[string]string, len(values)), reads: make(map[string]bool)}
+	for key, value := range values {
+		s.values[key] = value
+	}
+	return s
+}
+
+// Lookup implements env.Source.
+func (s *Source) Lookup(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reads[key] = true
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set sets the value of key.
+func (s *Source) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// String implements fmt.Stringer.
+func (s *Source) String() string {
+	return "envtest"
+}
+
+// Read returns the sorted keys that have been looked up, whether they were
+// present or not.
+func (s *Source) Read() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedKeys(s.reads)
+}
+
+// Unread returns the sorted keys holding a value that has never been
+// looked up.
+func (s *Source) Unread() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key := range s.values {
+		if !s.reads[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AssertRead fails t unless exactly keys have been looked up.
+func (s *Source) AssertRead(t testing.TB, keys ...string) {
+	t.Helper()
+
+	expected := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		expected[key] = true
+	}
+
+	s.mu.Lock()
+	read := make(map[string]bool, len(s.reads))
+	for key := range s.reads {
+		read[key] = true
+	}
+	s.mu.Unlock()
+
+	if missing := difference(expected, read); len(missing) > 0 {
+		t.Errorf("envtest: keys not read: %s", strings.Join(missing, ", "))
+	}
+	if extra := difference(read, expected); len(extra) > 0 {
+		t.Errorf("envtest: unexpected keys read: %s", strings.Join(extra, ", "))
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package envtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/caleflat/env"
+	"github.com/caleflat/env/sourcetest"
+)
+
+func TestSource_Conformance(t *testing.T) {
+	sourcetest.Run(t, func(t *testing.T, vars map[string]string) env.Source {
+		return NewSource(vars)
+	})
+}
+
+func TestSource(t *testing.T) {
+	src := NewSource(map[string]string{"HOST": "localhost", "PORT": "8080", "UNUSED": "x"})
+
+	var c config
+	if err := env.Parse(&c, env.WithNoOSEnv(), env.WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if expected := []string{"HOST", "PORT"}; !reflect.DeepEqual(src.Read(), expected) {
+		t.Errorf("Expected %v to be read, got %v", expected, src.Read())
+	}
+	if expected := []string{"UNUSED"}; !reflect.DeepEqual(src.Unread(), expected) {
+		t.Errorf("Expected %v to be unread, got %v", expected, src.Unread())
+	}
+
+	src.AssertRead(t, "HOST", "PORT")
+
+	r := &recorder{TB: t}
+	src.AssertRead(r, "HOST", "DEBUG")
+	expected := []string{"envtest: keys not read: DEBUG", "envtest: unexpected keys read: PORT"}
+	if !reflect.DeepEqual(r.errors, expected) {
+		t.Errorf("Expected %q, got %q", expected, r.errors)
+	}
+}
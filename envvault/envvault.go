@@ -0,0 +1,347 @@
+// Package envvault provides an env.Source backed by a secret in the KV
+// version 2 secrets engine of HashiCorp Vault, using only the standard
+// library.
+//
+// Each key of the secret is served as a variable:
+//
+//	src, err := envvault.New(ctx, "secret", "myapp/prod",
+//		envvault.WithAppRole(roleID, secretID))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = env.Parse(&config, env.WithSource(src))
+//
+// The source is an env.Leaser: if Vault leases the secret, or WithTTL is
+// given, it reports how long its values remain valid, and reads the secret
+// again in the background once two thirds of that time have passed, so that
+// env.Reloadable.RefreshLeases keeps a config current. Lookups never wait
+// for Vault.
+package envvault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithAddress sets the address of the Vault server, instead of the one in
+// VAULT_ADDR.
+func WithAddress(addr string) Option {
+	return func(s *Source) {
+		s.addr = addr
+	}
+}
+
+// WithToken authenticates with token, instead of the one in VAULT_TOKEN.
+func WithToken(token string) Option {
+	return func(s *Source) {
+		s.token = token
+	}
+}
+
+// WithAppRole authenticates with the AppRole auth method, logging in again
+// whenever the token it got has expired.
+func WithAppRole(roleID, secretID string) Option {
+	return func(s *Source) {
+		s.roleID, s.secretID = roleID, secretID
+	}
+}
+
+// WithTTL makes the source read the secret again after two thirds of ttl,
+// for secrets that are rotated but not leased by Vault.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Source) {
+		s.ttl = ttl
+	}
+}
+
+// WithTimeout bounds the background reads of a leased secret, 30 seconds by
+// default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Source) {
+		s.timeout = timeout
+	}
+}
+
+// WithErrorHandler makes the source call fn with the error of every
+// background read that fails.
+func WithErrorHandler(fn func(error)) Option {
+	return func(s *Source) {
+		s.onError = fn
+	}
+}
+
+// WithClient makes the source use client for its requests instead of
+// http.DefaultClient.
+func WithClient(client *http.Client) Option {
+	return func(s *Source) {
+		s.client = client
+	}
+}
+
+// Source is an env.Source serving the keys of a Vault secret.
+type Source struct {
+	addr    string
+	mount   string
+	path    string
+	client  *http.Client
+	ttl     time.Duration
+	timeout time.Duration
+	onError func(error)
+
+	roleID, secretID string
+
+	// reading serializes reads and guards the token.
+	reading     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+
+	mu         sync.Mutex
+	values     map[string]string
+	expiry     time.Time
+	refreshAt  time.Time
+	refreshing bool
+	failures   int
+	retryAt    time.Time
+	err        error
+}
+
+// New reads the secret at path in the KV version 2 engine mounted at mount,
+// and returns a Source serving its keys. Values that are not strings are
+// served as their JSON text.
+func New(ctx context.Context, mount, path string, opts ...Option) (*Source, error) {
+	s := &Source{
+		addr:    os.Getenv("VAULT_ADDR"),
+		token:   os.Getenv("VAULT_TOKEN"),
+		mount:   strings.Trim(mount, "/"),
+		path:    strings.Trim(path, "/"),
+		client:  http.DefaultClient,
+		timeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.addr = strings.TrimSuffix(s.addr, "/")
+
+	if s.addr == "" {
+		return nil, errors.New("envvault: no address set")
+	}
+	if s.token == "" && s.roleID == "" {
+		return nil, errors.New("envvault: no token or AppRole set")
+	}
+
+	if err := s.read(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup implements env.Source.
+func (s *Source) Lookup(key string) (string, bool) {
+	value, _, ok := s.LookupLease(key)
+	return value, ok
+}
+
+// LookupLease implements env.Leaser. Once two thirds of the lease have
+// passed, it starts reading the secret again in the background and serves
+// the current values until that succeeds. Failed reads are retried after a
+// second, doubling up to a minute; once the lease has ended, values are
+// served with the time until the next attempt as their ttl.
+func (s *Source) LookupLease(key string) (string, time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !s.refreshAt.IsZero() && !now.Before(s.refreshAt) && !s.refreshing && !now.Before(s.retryAt) {
+		s.refreshing = true
+		go s.refresh()
+	}
+
+	value, ok := s.values[key]
+	if !ok || s.expiry.IsZero() {
+		return value, 0, ok
+	}
+
+	ttl := s.expiry.Sub(now)
+	if ttl <= 0 {
+		ttl = s.retryAt.Sub(now)
+		if ttl <= 0 {
+			ttl = retryWait(s.failures)
+		}
+	}
+	return value, ttl, true
+}
+
+// Err returns the error of the last background read, or nil if it
+// succeeded.
+func (s *Source) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// String implements fmt.Stringer.
+func (s *Source) String() string {
+	return "vault(" + s.mount + "/" + s.path + ")"
+}
+
+// Refresh reads the secret again. If that fails, the current values are
+// kept.
+func (s *Source) Refresh(ctx context.Context) error {
+	return s.read(ctx)
+}
+
+// refresh reads the secret in the background, scheduling a retry if that
+// fails.
+func (s *Source) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	err := s.read(ctx)
+
+	s.mu.Lock()
+	s.refreshing = false
+	if err != nil {
+		s.failures++
+		s.retryAt = time.Now().Add(retryWait(s.failures))
+		s.err = err
+	}
+	s.mu.Unlock()
+
+	if err != nil && s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// retryWait returns how long to wait before reading the secret again after
+// failures failed reads.
+func retryWait(failures int) time.Duration {
+	wait := time.Second
+	for i := 1; i < failures && wait < time.Minute; i++ {
+		wait *= 2
+	}
+	if wait > time.Minute {
+		wait = time.Minute
+	}
+	return wait
+}
+
+// read reads the secret, logging in first if needed.
+func (s *Source) read(ctx context.Context) error {
+	s.reading.Lock()
+	defer s.reading.Unlock()
+
+	if s.roleID != "" && (s.token == "" || !s.tokenExpiry.IsZero() && !time.Now().Before(s.tokenExpiry)) {
+		if err := s.login(ctx); err != nil {
+			return err
+		}
+	}
+
+	var secret struct {
+		LeaseDuration int `json:"lease_duration"`
+		Data          struct {
+			Data map[string]json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/v1/"+s.mount+"/data/"+s.path, nil, &secret); err != nil {
+		return err
+	}
+
+	values := make(map[string]string, len(secret.Data.Data))
+	for key, raw := range secret.Data.Data {
+		var v string
+		if json.Unmarshal(raw, &v) != nil {
+			v = string(raw)
+		}
+		values[key] = v
+	}
+
+	now := time.Now()
+	ttl := time.Duration(secret.LeaseDuration) * time.Second
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = values
+	s.expiry, s.refreshAt = time.Time{}, time.Time{}
+	if ttl > 0 {
+		s.expiry, s.refreshAt = now.Add(ttl), now.Add(ttl*2/3)
+	}
+	s.failures, s.retryAt, s.err = 0, time.Time{}, nil
+	return nil
+}
+
+// login gets a token with the AppRole auth method. s.reading must be held.
+func (s *Source) login(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{"role_id": s.roleID, "secret_id": s.secretID})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	s.token = ""
+	if err := s.do(ctx, http.MethodPost, "/v1/auth/approle/login", body, &resp); err != nil {
+		return err
+	}
+
+	s.token, s.tokenExpiry = resp.Auth.ClientToken, time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		s.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	}
+	return nil
+}
+
+func (s *Source) do(ctx context.Context, method, path string, body []byte, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, s.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if s.token != "" {
+		req.Header.Set("X-Vault-Token", s.token)
+	}
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.New("envvault: " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.New("envvault: " + err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &e) != nil || len(e.Errors) == 0 {
+			return errors.New("envvault: " + path + ": unexpected status " + resp.Status)
+		}
+		return errors.New("envvault: " + path + ": " + strings.Join(e.Errors, "; "))
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("envvault: " + err.Error())
+	}
+	return nil
+}
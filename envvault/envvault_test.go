@@ -0,0 +1,292 @@
+package envvault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caleflat/env"
+)
+
+// server is a fake Vault serving the KV version 2 secret secret/myapp.
+type server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	data   map[string]interface{}
+	lease  int
+	logins int
+	reads  int
+	fail   bool
+	// hang, if not nil, holds requests until it is closed.
+	hang chan struct{}
+}
+
+func newServer(t *testing.T, data map[string]interface{}) *server {
+	s := &server{data: data}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		hang := s.hang
+		s.mu.Unlock()
+		if hang != nil {
+			<-hang
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"errors":["Vault is sealed"]}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+			var creds map[string]string
+			json.NewDecoder(r.Body).Decode(&creds)
+			if creds["role_id"] != "role" || creds["secret_id"] != "s3cret" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+				return
+			}
+			s.logins++
+			w.Write([]byte(`{"auth":{"client_token":"t0ken","lease_duration":3600}}`))
+		case "/v1/secret/data/myapp":
+			if r.Header.Get("X-Vault-Token") != "t0ken" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+			s.reads++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_duration": s.lease,
+				"data":           map[string]interface{}{"data": s.data},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestNew(t *testing.T) {
+	srv := newServer(t, map[string]interface{}{"DB_PASSWORD": "hunter2", "PORT": 8080})
+
+	s, err := New(context.Background(), "secret", "/myapp/", WithAddress(srv.URL), WithToken("t0ken"))
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+
+	var c struct {
+		Password string `env:"DB_PASSWORD"`
+		Port     int    `env:"PORT"`
+	}
+	if err := env.Parse(&c, env.WithNoOSEnv(), env.WithSource(s)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if c.Password != "hunter2" || c.Port != 8080 {
+		t.Errorf("Unexpected config: %+v", c)
+	}
+
+	if _, ttl, _ := s.LookupLease("PORT"); ttl != 0 {
+		t.Errorf("Expected no lease, got %v", ttl)
+	}
+	if s.String() != "vault(secret/myapp)" {
+		t.Errorf("Unexpected name %s", s)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	srv := newServer(t, nil)
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{"no address", nil, "envvault: no address set"},
+		{"no auth", []Option{WithAddress(srv.URL)}, "envvault: no token or AppRole set"},
+		{"bad token", []Option{WithAddress(srv.URL), WithToken("wrong")},
+			"envvault: /v1/secret/data/myapp: permission denied"},
+		{"bad AppRole", []Option{WithAddress(srv.URL), WithAppRole("role", "wrong")},
+			"envvault: /v1/auth/approle/login: invalid role or secret ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(context.Background(), "secret", "myapp", tt.opts...)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestWithAppRole(t *testing.T) {
+	srv := newServer(t, map[string]interface{}{"DB_PASSWORD": "hunter2"})
+	t.Setenv("VAULT_TOKEN", "")
+
+	s, err := New(context.Background(), "secret", "myapp", WithAddress(srv.URL), WithAppRole("role", "s3cret"))
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+	if value, _ := s.Lookup("DB_PASSWORD"); value != "hunter2" {
+		t.Errorf("Expected hunter2, got %q", value)
+	}
+
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if srv.logins != 1 {
+		t.Errorf("Expected the token to be reused, got %d logins", srv.logins)
+	}
+}
+
+func TestSource_Lease(t *testing.T) {
+	srv := newServer(t, map[string]interface{}{"DB_PASSWORD": "v1"})
+	srv.lease = 3600
+
+	s, err := New(context.Background(), "secret", "myapp", WithAddress(srv.URL), WithToken("t0ken"))
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+
+	value, ttl, ok := s.LookupLease("DB_PASSWORD")
+	if !ok || value != "v1" || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Expected v1 leased for an hour, got %q for %v", value, ttl)
+	}
+
+	// Pretend two thirds of the lease have passed.
+	srv.mu.Lock()
+	srv.data["DB_PASSWORD"] = "v2"
+	srv.mu.Unlock()
+	s.mu.Lock()
+	s.refreshAt = time.Now().Add(-time.Second)
+	s.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, _ := s.Lookup("DB_PASSWORD")
+		if value == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the secret to be read again, got %q", value)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.reads != 2 {
+		t.Errorf("Expected 2 reads, got %d", srv.reads)
+	}
+}
+
+func TestSource_LeaseExpired(t *testing.T) {
+	srv := newServer(t, map[string]interface{}{"DB_PASSWORD": "v1"})
+	srv.lease = 3600
+
+	errs := make(chan error, 1)
+	s, err := New(context.Background(), "secret", "myapp", WithAddress(srv.URL), WithToken("t0ken"),
+		WithErrorHandler(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+
+	// Pretend the lease has ended while Vault is unavailable.
+	srv.mu.Lock()
+	srv.fail = true
+	srv.mu.Unlock()
+	s.mu.Lock()
+	s.refreshAt, s.expiry = time.Now().Add(-time.Minute), time.Now().Add(-time.Second)
+	s.mu.Unlock()
+
+	if value, _ := s.Lookup("DB_PASSWORD"); value != "v1" {
+		t.Errorf("Expected the current value while reading again, got %q", value)
+	}
+
+	select {
+	case err := <-errs:
+		if expected := "envvault: /v1/secret/data/myapp: Vault is sealed"; err.Error() != expected {
+			t.Errorf("Expected %q, got %q", expected, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the failed read to be reported")
+	}
+	if s.Err() == nil {
+		t.Error("Expected Err to return the failed read")
+	}
+
+	value, ttl, ok := s.LookupLease("DB_PASSWORD")
+	if !ok || value != "v1" || ttl < 500*time.Millisecond || ttl > time.Second {
+		t.Errorf("Expected v1 until the retry in a second, got %q for %v", value, ttl)
+	}
+}
+
+func TestSource_LookupDoesNotWait(t *testing.T) {
+	srv := newServer(t, map[string]interface{}{"DB_PASSWORD": "v1"})
+	srv.lease = 3600
+
+	errs := make(chan error, 1)
+	s, err := New(context.Background(), "secret", "myapp", WithAddress(srv.URL), WithToken("t0ken"),
+		WithTimeout(50*time.Millisecond), WithErrorHandler(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+
+	hang := make(chan struct{})
+	defer close(hang)
+	srv.mu.Lock()
+	srv.hang = hang
+	srv.mu.Unlock()
+	s.mu.Lock()
+	s.refreshAt = time.Now().Add(-time.Second)
+	s.mu.Unlock()
+
+	done := make(chan string)
+	go func() {
+		value, _ := s.Lookup("DB_PASSWORD")
+		done <- value
+	}()
+	select {
+	case value := <-done:
+		if value != "v1" {
+			t.Errorf("Expected v1, got %q", value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lookup waited for Vault")
+	}
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "context deadline exceeded") {
+			t.Errorf("Expected a timeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the read to time out")
+	}
+}
+
+func TestWithTTL(t *testing.T) {
+	srv := newServer(t, map[string]interface{}{"DB_PASSWORD": "v1"})
+
+	s, err := New(context.Background(), "secret", "myapp", WithAddress(srv.URL), WithToken("t0ken"), WithTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+
+	if _, ttl, _ := s.LookupLease("DB_PASSWORD"); ttl <= 59*time.Second || ttl > time.Minute {
+		t.Errorf("Expected a lease of a minute, got %v", ttl)
+	}
+}
@@ -0,0 +1,139 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// The kinds of Event.
+const (
+	// EventLookup is a query of one source for one variable.
+	EventLookup = "lookup"
+	// EventBatch is a BatchSource answering all variables at once.
+	EventBatch = "batch"
+	// EventCacheHit is a variable answered from values fetched earlier by
+	// a batch or a concurrent lookup.
+	EventCacheHit = "cache_hit"
+	// EventDefault is a field keeping its default because its variable is
+	// not set.
+	EventDefault = "default"
+	// EventConvert is the conversion of a value to the type of its field.
+	EventConvert = "convert"
+	// EventValidate is the validation of a value by its field's tags, or,
+	// without a Key, of the whole config by Validate methods and validators.
+	EventValidate = "validate"
+	// EventFile is a file read by a source: a .env file loaded by
+	// DotenvFS, reported when Parse starts, or the file a DirSource or a
+	// WithSecretsDir directory read a variable from.
+	EventFile = "file"
+)
+
+// Event is one step of Parse, as written by WithEventSink. Values are never
+// included, so that traces can be shared without leaking secrets; errors
+// about the values of secret or decrypted fields, whose messages may quote
+// them, are replaced by a redacted one.
+type Event struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	Key  string    `json:"key,omitempty"`
+	// Source names the source queried, by its String method if it has one.
+	Source string `json:"source,omitempty"`
+	// Found reports whether a lookup or cache hit found the variable.
+	Found bool `json:"found,omitempty"`
+	// Type is the field type of a conversion.
+	Type string `json:"type,omitempty"`
+	// File is the path of the file of a file event.
+	File     string        `json:"file,omitempty"`
+	Duration time.Duration `json:"duration_ns,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// WithEventSink makes Parse write an Event to w, as a line of JSON, for
+// every step it takes: file loads, source lookups and batches, cache hits,
+// defaults, conversions and validations. It lets tooling see how a config was loaded
+// without instrumenting the program:
+//
+//	{"time":"2024-03-01T12:00:00Z","kind":"lookup","key":"PORT","source":"os","found":true,"duration_ns":1200}
+//
+// Writes to w are serialized; errors writing to w are ignored.
+func WithEventSink(w io.Writer) Option {
+	return func(o *options) {
+		o.events = &eventSink{enc: json.NewEncoder(w)}
+	}
+}
+
+type eventSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// emit writes e to the event sink, if there is one.
+func (o *options) emit(e Event) {
+	if o.events == nil {
+		return
+	}
+
+	e.Time = time.Now()
+	o.events.mu.Lock()
+	defer o.events.mu.Unlock()
+	_ = o.events.enc.Encode(e)
+}
+
+// errorString returns the message of err, or "" if err is nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// redactError returns err, or, if the value of env is secret, an error
+// that names env without quoting the value.
+func redactError(err error, env string, secret bool) error {
+	if err == nil || !secret {
+		return err
+	}
+	return errors.New("invalid value for environment variable: " + env + ": [REDACTED]")
+}
+
+// fileLoader is implemented by sources that loaded files when they were
+// created, to report them in events.
+type fileLoader interface {
+	loadedFiles() []loadedFile
+}
+
+// loadedFile is a file loaded by a source, and how long loading it took.
+type loadedFile struct {
+	path string
+	d    time.Duration
+}
+
+// fileReader is implemented by sources that read a file for every lookup,
+// to report it in events.
+type fileReader interface {
+	// file returns the path of the file holding key.
+	file(key string) string
+}
+
+// emitFiles reports the files loaded by the sources.
+func (o *options) emitFiles() {
+	for _, s := range o.sources {
+		if l, ok := s.(fileLoader); ok {
+			for _, f := range l.loadedFiles() {
+				o.emit(Event{Kind: EventFile, Source: sourceName(s), File: f.path, Duration: f.d})
+			}
+		}
+	}
+}
+
+// sourceName names s in events.
+func sourceName(s Source) string {
+	if str, ok := s.(fmt.Stringer); ok {
+		return str.String()
+	}
+	return fmt.Sprintf("%T", s)
+}
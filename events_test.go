@@ -0,0 +1,139 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithEventSink(t *testing.T) {
+	type Config struct {
+		Port     int    `env:"PORT"`
+		Host     string `env:"HOST" default:"localhost"`
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	var buf bytes.Buffer
+	src := mapSource{"PORT": "8080", "DB_PASSWORD": "s3cret"}
+	if err := Parse(&Config{}, WithNoOSEnv(), WithSource(src), WithEventSink(&buf)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Error("Expected events not to contain values")
+	}
+
+	var kinds []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", scanner.Text(), err)
+		}
+		if e.Time.IsZero() {
+			t.Errorf("Expected event %q to have a time", scanner.Text())
+		}
+		kinds = append(kinds, e.Kind+" "+e.Key)
+	}
+
+	expected := []string{
+		"lookup PORT", "validate PORT", "convert PORT",
+		"lookup HOST", "default HOST", "validate HOST", "convert HOST",
+		"lookup DB_PASSWORD", "validate DB_PASSWORD", "convert DB_PASSWORD",
+		"validate ",
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected events %q, got %q", expected, kinds)
+	}
+}
+
+func TestWithEventSink_Batch(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+	}
+
+	var buf bytes.Buffer
+	src := &batchSource{mapSource: mapSource{"HOST": "db"}}
+	if err := Parse(&Config{}, WithNoOSEnv(), WithSource(src), WithEventSink(&buf)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 || !strings.Contains(lines[0], `"kind":"batch"`) || !strings.Contains(lines[1], `"kind":"cache_hit","key":"HOST","source":"*env.batchSource","found":true`) {
+		t.Errorf("Expected a batch followed by a cache hit, got %q", lines)
+	}
+}
+
+func TestWithEventSink_SecretError(t *testing.T) {
+	type Config struct {
+		Password string `env:"DB_PASSWORD" secret:"true" match:"^[a-z]+$"`
+		Token    string `env:"API_TOKEN" oneof:"a,b"`
+	}
+
+	for _, src := range []mapSource{
+		{"DB_PASSWORD": "Hunter2", "API_TOKEN": "a"},
+		{"DB_PASSWORD": "hunter", "API_TOKEN": "enc:Hunter2"},
+	} {
+		var buf bytes.Buffer
+		err := Parse(&Config{}, WithNoOSEnv(), WithSource(src), WithEventSink(&buf),
+			WithDecryptor("enc:", func(ciphertext string) (string, error) { return ciphertext, nil }))
+		if err == nil {
+			t.Fatal("Expected a validation error")
+		}
+
+		if strings.Contains(buf.String(), "Hunter2") {
+			t.Errorf("Expected events not to contain the secret, got %s", buf.String())
+		}
+		if !strings.Contains(buf.String(), `"error":"invalid value for environment variable: `) {
+			t.Errorf("Expected the failed validation to be reported, got %s", buf.String())
+		}
+	}
+}
+
+func TestWithEventSink_File(t *testing.T) {
+	type Config struct {
+		Host     string `env:"HOST"`
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	fsys := fstest.MapFS{"app.env": {Data: []byte("HOST=db\n")}}
+	dotenv, err := DotenvFS(fsys, "app.env", "missing.env")
+	if err != nil {
+		t.Fatalf("Failed to load .env file: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db_password"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Parse(&Config{}, WithNoOSEnv(), WithSource(dotenv), WithSecretsDir(dir), WithEventSink(&buf)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	var files []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", scanner.Text(), err)
+		}
+		if e.Kind == EventFile {
+			files = append(files, e.Key+" "+e.Source+" "+e.File)
+		}
+	}
+
+	expected := []string{
+		" dotenv(app.env) app.env",
+		"DB_PASSWORD dir(" + dir + ") " + filepath.Join(dir, "db_password"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected file events %q, got %q", expected, files)
+	}
+}
@@ -0,0 +1,86 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Example returns the contents of a .env.example file for config, a struct
+// or a pointer to one. Every variable gets a KEY= line preceded by comments
+// describing it, and the variables of nested structs are grouped under the
+// name of their field:
+//
+//	# Database host
+//	# DB_HOST=localhost
+//
+//	# DB
+//
+//	# Required.
+//	DB_PASSWORD=
+//
+// Variables with defaults are commented out, so that copying the file as is
+// leaves the defaults in effect; required variables are left for the reader
+// to fill in. Secret defaults are not shown. Example panics if config is not
+// a struct or a pointer to one.
+func Example(config interface{}) string {
+	docs, err := describe(config)
+	if err != nil {
+		panic("env: " + err.Error())
+	}
+
+	t := reflect.TypeOf(config)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var b strings.Builder
+	group := ""
+	for i, d := range docs {
+		path := d.Field[:strings.LastIndex(d.Field, ".")]
+		if g := strings.TrimPrefix(strings.TrimPrefix(path, t.String()), "."); g != group {
+			group = g
+			if group != "" {
+				if i > 0 {
+					b.WriteString("\n")
+				}
+				b.WriteString("# " + group + "\n")
+			}
+		}
+		if i > 0 || group != "" {
+			b.WriteString("\n")
+		}
+
+		for _, line := range strings.Split(d.Description, "\n") {
+			if line != "" {
+				b.WriteString("# " + line + "\n")
+			}
+		}
+		if len(d.allowed) > 0 {
+			b.WriteString("# One of " + strings.Join(d.allowed, ", ") + ".\n")
+		}
+		if len(d.Fallbacks) > 0 {
+			b.WriteString("# Also read from " + strings.Join(d.Fallbacks, ", ") + ".\n")
+		}
+		if d.Required {
+			b.WriteString("# Required.\n")
+		}
+
+		switch {
+		case d.Required:
+			b.WriteString(d.Name + "=\n")
+		case d.Default == nil || d.Secret:
+			b.WriteString("# " + d.Name + "=\n")
+		default:
+			b.WriteString("# " + d.Name + "=" + quoteDotenv(*d.Default) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// quoteDotenv quotes value if a .env file would not read it back as is.
+func quoteDotenv(value string) string {
+	if strings.ContainsAny(value, " \t\r\n\"'#\\") {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(value) + `"`
+	}
+	return value
+}
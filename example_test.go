@@ -0,0 +1,56 @@
+package env
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caleflat/env/internal/dotenv"
+)
+
+func TestExample(t *testing.T) {
+	type Config struct {
+		Name string `env:"APP_NAME" default:"my app" desc:"Name shown in logs"`
+		DB   struct {
+			Host     string `env:"HOST" default:"localhost"`
+			Password Secret `env:"PASSWORD"`
+		} `env:"DB"`
+		Level string     `env:"LOG_LEVEL" oneof:"debug,info"`
+		Token Credential `env:"TOKEN"`
+	}
+
+	expected := `# Name shown in logs
+# APP_NAME="my app"
+
+# DB
+
+# DB_HOST=localhost
+
+# Required.
+DB_PASSWORD=
+
+# One of debug, info.
+# Required.
+LOG_LEVEL=
+
+# Required.
+TOKEN=
+
+# Expiry of TOKEN, as an RFC 3339 time or in seconds since the Unix epoch.
+# TOKEN_EXPIRES_AT=
+`
+	if example := Example(&Config{}); example != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, example)
+	}
+}
+
+func TestQuoteDotenv(t *testing.T) {
+	for _, value := range []string{"plain", "two words", "a#b", `"quoted"`, "it's", `back\slash`, "line\nbreak\ttab"} {
+		entries, err := dotenv.Parse(strings.NewReader("KEY=" + quoteDotenv(value)))
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", quoteDotenv(value), err)
+		}
+		if got := entries[0].Value; got != value {
+			t.Errorf("Expected %q, got %q", value, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Resolution is the story of how Parse resolves one variable of a config,
+// as returned by Explain.
+type Resolution struct {
+	Key string
+	// Field names the field that reads Key, e.g. "main.Config.DB.Host".
+	Field string
+	// Lookups lists every source queried, in order, for Key and then for
+	// each of its `fallback` names, until one of them had a value.
+	Lookups []SourceLookup
+	// Default is the value of the field's `default` tag, if it has one,
+	// and UsedDefault reports whether it was used.
+	Default     *string
+	UsedDefault bool
+	// Secret reports whether the value is secret, because the field is or
+	// the value was decrypted. Its values, default included, are redacted.
+	Secret bool
+	// KeptPreset reports whether the field kept a value set by SetDefaults
+	// because no variable was set.
+	KeptPreset bool
+	// Transforms lists the transformers applied to the raw value, with the
+	// value each one produced.
+	Transforms []Transform
+	// Value is the raw value the field is converted from.
+	Value string
+	// Err is the error resolving the field, if any.
+	Err error
+}
+
+// SourceLookup is a query of one source for one key.
+type SourceLookup struct {
+	Key    string
+	Source string
+	Found  bool
+}
+
+// Transform is a transformer applied to a raw value, and its result.
+type Transform struct {
+	Name  string
+	Value string
+}
+
+// Explain tells where the value of the variable key of config comes from:
+// which sources were consulted and which of them had it, whether a default
+// was used and which transformers were applied, as Parse with opts would
+// resolve it. config is not modified. The values of secret fields are
+// redacted. It is meant for interactive debugging:
+//
+//	r, err := env.Explain(&config, "DB_HOST")
+//	fmt.Println(r)
+//
+// If no field of config reads key, an error is returned.
+func Explain(config interface{}, key string, opts ...Option) (Resolution, error) {
+	if err := checkTarget(config); err != nil {
+		return Resolution{}, err
+	}
+
+	t := reflect.TypeOf(config).Elem()
+	for _, f := range fieldsOf(t) {
+		if f.key == key {
+			o := newOptions(opts)
+			o.events = nil // Explain tells, rather than parses
+			return explain(t, f, o), nil
+		}
+	}
+	return Resolution{}, errors.New("no field reads environment variable: " + key)
+}
+
+func explain(t reflect.Type, f fieldInfo, o *options) Resolution {
+	r := Resolution{Key: f.key, Field: f.path}
+
+	names := []string{f.key}
+	if fallback := f.field.Tag.Get("fallback"); fallback != "" {
+		names = append(names, splitList(fallback, ",")...)
+	}
+
+	raw, found := "", false
+	for _, name := range names {
+		for _, s := range o.sources {
+			var ok bool
+			raw, _, ok = lookupSource(s, name)
+			r.Lookups = append(r.Lookups, SourceLookup{Key: name, Source: sourceName(s), Found: ok})
+			if ok {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	def, hasDefault := f.field.Tag.Lookup("default")
+	if hasDefault {
+		redacted := redact(def, f.secret)
+		r.Default = &redacted
+	}
+	r.Secret = f.secret
+
+	if err := checkTags(f.field, f.key); err != nil {
+		r.Err = err
+		return r
+	}
+
+	v := reflect.New(t)
+	o.preset = setDefaults(v, []fieldInfo{f})
+	value := v.Elem().FieldByIndex(f.index)
+
+	if !found {
+		switch {
+		case o.keepPreset(f):
+			r.KeptPreset = true
+			return r
+		case !hasDefault:
+			r.Err = errors.New("environment variable not found: " + f.key)
+			return r
+		}
+		raw, r.UsedDefault = def, true
+	}
+
+	raw, secret, err := o.prepare(f, raw, func(name, value string, secret bool) {
+		r.Transforms = append(r.Transforms, Transform{Name: name, Value: redact(value, secret)})
+	})
+	r.Secret = r.Secret || secret
+	if secret && r.Default != nil {
+		redacted := redact(def, true)
+		r.Default = &redacted
+	}
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Value = redact(raw, secret)
+	r.Err = o.store(f, value, raw, secret)
+	return r
+}
+
+// redact returns value, or a placeholder if it is secret.
+func redact(value string, secret bool) string {
+	if secret {
+		return "[REDACTED]"
+	}
+	return value
+}
+
+// String tells the story of r, one step per line.
+func (r Resolution) String() string {
+	var b strings.Builder
+	b.WriteString(r.Key + " (" + r.Field + ")\n")
+
+	for _, l := range r.Lookups {
+		status := "not set"
+		if l.Found {
+			status = "set"
+		}
+		b.WriteString("  lookup " + l.Key + " in " + l.Source + ": " + status + "\n")
+	}
+
+	switch {
+	case r.KeptPreset:
+		b.WriteString("  kept the value set by SetDefaults\n")
+	case r.UsedDefault && r.Secret:
+		b.WriteString("  used default (redacted)\n")
+	case r.UsedDefault:
+		b.WriteString("  used default " + strconv.Quote(*r.Default) + "\n")
+	}
+
+	for _, t := range r.Transforms {
+		b.WriteString("  transform " + t.Name + ": " + strconv.Quote(t.Value) + "\n")
+	}
+
+	if r.Err != nil {
+		b.WriteString("  error: " + r.Err.Error() + "\n")
+	} else if !r.KeptPreset {
+		b.WriteString("  value: " + strconv.Quote(r.Value) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	type DB struct {
+		Host     string `env:"HOST" fallback:"DATABASE_HOST" transform:"trim,lower"`
+		Password string `env:"PASSWORD" secret:"true" transform:"trim"`
+		Port     int    `env:"PORT" default:"5432"`
+	}
+	type Config struct {
+		DB DB `env:"DB"`
+	}
+
+	files := mapSource{"DATABASE_HOST": " DB.Internal ", "DB_PASSWORD": " s3cret "}
+	opts := []Option{WithNoOSEnv(), WithSource(mapSource{}), WithSource(files)}
+
+	r, err := Explain(&Config{}, "DB_HOST", opts...)
+	if err != nil {
+		t.Fatalf("Failed to explain: %v", err)
+	}
+
+	expected := `DB_HOST (env.Config.DB.Host)
+  lookup DB_HOST in env.mapSource: not set
+  lookup DB_HOST in env.mapSource: not set
+  lookup DATABASE_HOST in env.mapSource: not set
+  lookup DATABASE_HOST in env.mapSource: set
+  transform trim: "DB.Internal"
+  transform lower: "db.internal"
+  value: "db.internal"
+`
+	if s := r.String(); s != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, s)
+	}
+
+	r, err = Explain(&Config{}, "DB_PASSWORD", opts...)
+	if err != nil {
+		t.Fatalf("Failed to explain: %v", err)
+	}
+	if strings.Contains(r.String(), "s3cret") {
+		t.Errorf("Expected secret values to be redacted, got:\n%s", r)
+	}
+
+	r, err = Explain(&Config{}, "DB_PORT", opts...)
+	if err != nil {
+		t.Fatalf("Failed to explain: %v", err)
+	}
+	if !r.UsedDefault || r.Value != "5432" || r.Err != nil {
+		t.Errorf("Expected the default to be used, got:\n%s", r)
+	}
+
+	files["DB_PORT"] = "x"
+	r, _ = Explain(&Config{}, "DB_PORT", opts...)
+	if r.Err == nil || r.Err.Error() != "invalid value for environment variable: DB_PORT" {
+		t.Errorf("Expected a conversion error, got:\n%s", r)
+	}
+
+	if _, err := Explain(&Config{}, "NOPE", opts...); err == nil || err.Error() != "no field reads environment variable: NOPE" {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+}
+
+func TestExplain_Coercion(t *testing.T) {
+	type Config struct {
+		Debug bool `env:"DEBUG"`
+	}
+
+	opts := []Option{WithNoOSEnv(), WithSource(mapSource{"DEBUG": "on"}), WithCoercion()}
+	if err := Parse(&Config{}, opts...); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	r, err := Explain(&Config{}, "DEBUG", opts...)
+	if err != nil {
+		t.Fatalf("Failed to explain: %v", err)
+	}
+	if r.Err != nil || r.Value != "true" {
+		t.Errorf("Expected DEBUG to resolve to true as Parse does, got %q, %v", r.Value, r.Err)
+	}
+}
+
+func TestExplain_SecretLeaks(t *testing.T) {
+	type Config struct {
+		Token  string `env:"TOKEN" secret:"true" default:"default-s3cret"`
+		Pass   string `env:"PASS" secret:"true" match:"^[a-z]+$"`
+		Cipher string `env:"CIPHER" oneof:"a,b"`
+	}
+
+	opts := []Option{WithNoOSEnv(), WithSource(mapSource{"PASS": "Sup3rS3cret!", "CIPHER": "enc:zyx-nialp"}),
+		WithDecryptor("enc:", reverse)}
+	for _, key := range []string{"TOKEN", "PASS", "CIPHER"} {
+		r, err := Explain(&Config{}, key, opts...)
+		if err != nil {
+			t.Fatalf("Failed to explain: %v", err)
+		}
+		if !r.Secret {
+			t.Errorf("Expected %s to be secret", key)
+		}
+		for _, leak := range []string{"default-s3cret", "Sup3rS3cret", "plain-xyz"} {
+			if s := r.String(); strings.Contains(s, leak) || r.Default != nil && *r.Default == leak {
+				t.Errorf("Expected %s to be redacted, got:\n%s", key, s)
+			}
+		}
+	}
+
+	r, _ := Explain(&Config{}, "TOKEN", opts...)
+	if !strings.Contains(r.String(), "used default (redacted)\n") {
+		t.Errorf("Expected the default to be redacted, got:\n%s", r)
+	}
+}
@@ -0,0 +1,132 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/caleflat/env/spec"
+)
+
+// fieldInfo describes a struct field that reads an environment variable.
+type fieldInfo struct {
+	// key is the variable the field reads, including prefixes.
+	key string
+	// path names the field for messages, e.g. "main.Config.DB.DSN".
+	path  string
+	field reflect.StructField
+	// secret is set by a `secret:"true"` or `secret:"once"` tag, or for
+	// Secret fields.
+	secret bool
+	// index is the index sequence of the field for reflect.Value.FieldByIndex.
+	index []int
+}
+
+// fieldsOf returns the fields of the struct type t, including those of
+// nested structs, that read a variable, in declaration order, as described
+// by the spec package. Misplaced env tags are left for Parse to report.
+func fieldsOf(t reflect.Type) []fieldInfo {
+	fields, _ := specFields(t)
+	return fields
+}
+
+// specFields is fieldsOf, also returning the error of spec.Of for env tags
+// on unexported fields.
+func specFields(t reflect.Type) ([]fieldInfo, error) {
+	vars, err := spec.Of(t)
+	fields := make([]fieldInfo, len(vars))
+	for i, v := range vars {
+		fields[i] = fieldInfo{
+			key:    v.Name,
+			path:   v.Field,
+			field:  v.StructField,
+			secret: v.Secret,
+			index:  v.Index,
+		}
+	}
+	return fields, err
+}
+
+// lookupKeys returns every name fields may read: their variables, their
+// `fallback` names and the expiry variables of Credential fields.
+func lookupKeys(fields []fieldInfo) []string {
+	var keys []string
+	for _, f := range fields {
+		keys = append(keys, f.key)
+		if fallback := f.field.Tag.Get("fallback"); fallback != "" {
+			for _, old := range strings.Split(fallback, ",") {
+				keys = append(keys, strings.TrimSpace(old))
+			}
+		}
+		if isCredential(f.field.Type) {
+			keys = append(keys, f.key+ExpirySuffix)
+		}
+	}
+	return keys
+}
+
+// checkDuplicates reports an error if two of fields read the same variable.
+func checkDuplicates(fields []fieldInfo) error {
+	seen := make(map[string]string)
+	for _, f := range fields {
+		if other, ok := seen[f.key]; ok {
+			return errors.New("environment variable " + f.key + " is read by both " + other + " and " + f.path)
+		}
+		seen[f.key] = f.path
+	}
+	return nil
+}
+
+// checkNames reports the first variable name read by fields, including
+// `fallback` names, that is not a valid name as checked by validName.
+func checkNames(fields []fieldInfo) error {
+	for _, f := range fields {
+		names := []string{f.key}
+		if fallback := f.field.Tag.Get("fallback"); fallback != "" {
+			names = append(names, splitList(fallback, ",")...)
+		}
+		for _, name := range names {
+			if !validName(name) {
+				return errors.New("invalid environment variable name " + strconv.Quote(name) + " for " + f.path +
+					": names must consist of A-Z, 0-9 and _ and not start with a digit")
+			}
+		}
+	}
+	return nil
+}
+
+// validName reports whether name consists only of the ASCII upper case
+// letters, digits and underscores, and does not start with a digit. The
+// check is done byte by byte so that it does not depend on the locale.
+func validName(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys returns the names of the variables the fields of config read, in the
+// order the fields are declared. The expiry variable of a Credential field
+// follows its variable. config must be a pointer to a struct.
+func Keys(config interface{}) ([]string, error) {
+	if err := checkTarget(config); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, f := range fieldsOf(reflect.TypeOf(config).Elem()) {
+		keys = append(keys, f.key)
+		if isCredential(f.field.Type) {
+			keys = append(keys, f.key+ExpirySuffix)
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,107 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	type DB struct {
+		DSN      string `env:"DSN"`
+		Password string `env:"PASSWORD"`
+	}
+
+	type Config struct {
+		Port    int `env:"PORT"`
+		Primary DB  `env:"PRIMARY"`
+		Cache   DB
+		Token   Credential `env:"TOKEN"`
+		Ignored string
+	}
+
+	keys, err := Keys(&Config{})
+	if err != nil {
+		t.Fatalf("Keys returned error: %v", err)
+	}
+
+	expected := []string{"PORT", "PRIMARY_DSN", "PRIMARY_PASSWORD", "DSN", "PASSWORD", "TOKEN", "TOKEN_EXPIRES_AT"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+
+	if _, err := Keys(Config{}); err == nil {
+		t.Error("Expected an error for a non-pointer")
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"PORT":       true,
+		"_PRIVATE":   true,
+		"DB_HOST_2":  true,
+		"":           false,
+		"2FA_SECRET": false,
+		"db_host":    false,
+		"DB-HOST":    false,
+		"DB HOST":    false,
+		"DB.HOST":    false,
+		"İSTANBUL":   false,
+	} {
+		if got := validName(name); got != expected {
+			t.Errorf("Expected validName(%q) to be %v, got %v", name, expected, got)
+		}
+	}
+}
+
+func TestWithStrictNames(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST"`
+	}
+
+	tests := []struct {
+		name     string
+		config   interface{}
+		expected string
+	}{
+		{
+			name: "field",
+			config: &struct {
+				Host string `env:"db-host"`
+			}{},
+			expected: `invalid environment variable name "db-host" for struct { Host string "env:\"db-host\"" }.Host`,
+		},
+		{
+			name: "prefix",
+			config: &struct {
+				DB DB `env:"db"`
+			}{},
+			expected: `invalid environment variable name "db_HOST" for `,
+		},
+		{
+			name: "fallback",
+			config: &struct {
+				Port int `env:"PORT" fallback:"HTTP_PORT, 8080"`
+			}{},
+			expected: `invalid environment variable name "8080" for `,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Parse(tt.config, WithNoOSEnv(), WithStrictNames())
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("Expected an error starting with %q, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	var c struct {
+		DB   DB  `env:"DB"`
+		Port int `env:"PORT" fallback:"HTTP_PORT"`
+	}
+	err := Parse(&c, WithNoOSEnv(), WithSource(mapSource{"DB_HOST": "db", "PORT": "80"}), WithStrictNames())
+	if err != nil {
+		t.Errorf("Expected valid names to be accepted, got %v", err)
+	}
+}
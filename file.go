@@ -0,0 +1,30 @@
+package env
+
+import (
+	"errors"
+	"os"
+)
+
+// GetFileContents treats the value of the environment variable named by the
+// key as the path of a file and returns the file's contents, as is, for
+// secrets mounted as files such as DB_PASSWORD_FILE=/run/secrets/db. A
+// leading ~ in the path is expanded like in Path values. If the variable is
+// not present, def is returned. If the file cannot be read, an error is
+// returned.
+func GetFileContents(key, def string) (string, error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+
+	path, err := expandPath(value)
+	if err != nil {
+		return "", errors.New("invalid value for environment variable: " + key + ": " + err.Error())
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.New("cannot read file named by environment variable: " + key + ": " + err.Error())
+	}
+	return string(b), nil
+}
@@ -0,0 +1,37 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetFileContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	clearenv(t)
+	t.Setenv("DB_PASSWORD_FILE", path)
+
+	if s, err := GetFileContents("DB_PASSWORD_FILE", ""); err != nil || s != "s3cret\n" {
+		t.Errorf("Expected the file contents, got %q, %v", s, err)
+	}
+
+	if s, err := GetFileContents("MISSING", "default"); err != nil || s != "default" {
+		t.Errorf("Expected the default, got %q, %v", s, err)
+	}
+
+	t.Setenv("DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	_, err := GetFileContents("DB_PASSWORD_FILE", "default")
+	if err == nil || !strings.HasPrefix(err.Error(), "cannot read file named by environment variable: DB_PASSWORD_FILE: ") {
+		t.Errorf("Expected a read error, got %v", err)
+	}
+
+	t.Setenv("DB_PASSWORD_FILE", "")
+	if _, err := GetFileContents("DB_PASSWORD_FILE", "default"); err == nil {
+		t.Error("Expected an error for an empty path")
+	}
+}
@@ -0,0 +1,42 @@
+package env
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// Fingerprint returns a stable hash of the values of the fields of config
+// that read a variable, leaving out fields tagged `secret:"true"` and
+// Credential fields. Two configs with the same values have the same
+// fingerprint, whatever the process, so fingerprints can be logged, used as
+// cache keys or compared between replicas to detect configuration drift.
+//
+// config must be a struct or a pointer to one; Fingerprint panics
+// otherwise.
+func Fingerprint(config interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(config))
+	if v.Kind() != reflect.Struct {
+		panic("env: Fingerprint of non-struct " + fmt.Sprintf("%T", config))
+	}
+
+	h := sha256.New()
+	for _, f := range fieldsOf(v.Type()) {
+		if f.secret || isCredential(f.field.Type) {
+			continue
+		}
+
+		value := v.FieldByIndex(f.index)
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		s := "<nil>"
+		if value.Kind() != reflect.Ptr {
+			s = fmt.Sprint(value.Interface())
+		}
+		fmt.Fprintf(h, "%s=%q\n", f.key, s)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
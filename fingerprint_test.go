@@ -0,0 +1,38 @@
+package env
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	type Config struct {
+		Host     string            `env:"HOST"`
+		Port     *int              `env:"PORT"`
+		Labels   map[string]string `env:"LABELS"`
+		Password string            `env:"PASSWORD" secret:"true"`
+		Token    Credential        `env:"TOKEN"`
+		Ignored  string
+	}
+
+	port := 8080
+	a := Config{Host: "db", Port: &port, Labels: map[string]string{"a": "1", "b": "2", "c": "3"}, Password: "one"}
+	other := 8080
+	b := Config{Host: "db", Port: &other, Labels: map[string]string{"c": "3", "b": "2", "a": "1"}, Password: "two", Ignored: "x"}
+	b.Token.Value = "t"
+
+	fa, fb := Fingerprint(&a), Fingerprint(b)
+	if fa != fb {
+		t.Errorf("Expected equal fingerprints ignoring secrets and untagged fields, got %s and %s", fa, fb)
+	}
+	if len(fa) != 64 {
+		t.Errorf("Expected a hex SHA-256, got %q", fa)
+	}
+
+	b.Port = nil
+	if Fingerprint(b) == fa {
+		t.Error("Expected a different fingerprint for a different port")
+	}
+
+	b.Port, b.Host = &other, "db2"
+	if Fingerprint(b) == fa {
+		t.Error("Expected a different fingerprint for a different host")
+	}
+}
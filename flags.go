@@ -0,0 +1,134 @@
+package env
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlagsMap is a composite variable of comma-separated key=value settings, in
+// the style of GODEBUG:
+//
+//	type Config struct {
+//	  Tuning env.FlagsMap `env:"MYAPP_TUNING"` // e.g. "gcpercent=50,trace=1"
+//	}
+//
+// Applications read settings with the typed accessors and call Check to
+// reject keys they do not know.
+type FlagsMap map[string]string
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *FlagsMap) UnmarshalText(text []byte) error {
+	flags := FlagsMap{}
+	for _, setting := range strings.Split(string(text), ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(setting, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return errors.New("invalid setting " + strconv.Quote(setting) + ": expected key=value")
+		}
+		if _, dup := flags[key]; dup {
+			return errors.New("duplicate setting: " + key)
+		}
+
+		flags[key] = strings.TrimSpace(value)
+	}
+
+	*m = flags
+	return nil
+}
+
+// String returns m in its parsed form, with keys sorted.
+func (m FlagsMap) String() string {
+	settings := make([]string, 0, len(m))
+	for _, key := range m.keys() {
+		settings = append(settings, key+"="+m[key])
+	}
+	return strings.Join(settings, ",")
+}
+
+// Check returns an error listing the keys of m that are not among known.
+func (m FlagsMap) Check(known ...string) error {
+	isKnown := make(map[string]bool, len(known))
+	for _, key := range known {
+		isKnown[key] = true
+	}
+
+	var unknown []string
+	for _, key := range m.keys() {
+		if !isKnown[key] {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return errors.New("unknown settings: " + strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Get returns the value of key, or def if it is not set.
+func (m FlagsMap) Get(key, def string) string {
+	if value, ok := m[key]; ok {
+		return value
+	}
+	return def
+}
+
+// Int returns the value of key as an int, or def if it is not set.
+func (m FlagsMap) Int(key string, def int) (int, error) {
+	value, ok := m[key]
+	if !ok {
+		return def, nil
+	}
+
+	i, err := parseInt(value)
+	if err != nil {
+		return def, errors.New("invalid value for setting " + key + ": " + strconv.Quote(value))
+	}
+	return int(i), nil
+}
+
+// Bool returns the value of key as a bool, or def if it is not set.
+func (m FlagsMap) Bool(key string, def bool) (bool, error) {
+	value, ok := m[key]
+	if !ok {
+		return def, nil
+	}
+
+	b, err := parseBool(value)
+	if err != nil {
+		return def, errors.New("invalid value for setting " + key + ": " + strconv.Quote(value))
+	}
+	return b, nil
+}
+
+// Duration returns the value of key as a time.Duration, or def if it is not
+// set.
+func (m FlagsMap) Duration(key string, def time.Duration) (time.Duration, error) {
+	value, ok := m[key]
+	if !ok {
+		return def, nil
+	}
+
+	d, err := parseDuration(value)
+	if err != nil {
+		return def, errors.New("invalid value for setting " + key + ": " + strconv.Quote(value))
+	}
+	return d, nil
+}
+
+func (m FlagsMap) keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package env
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_FlagsMap(t *testing.T) {
+	type Config struct {
+		Tuning FlagsMap `env:"TUNING"`
+	}
+
+	t.Setenv("TUNING", "workers=4, trace=true,flush=250ms,,mode=")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if got := config.Tuning.String(); got != "flush=250ms,mode=,trace=true,workers=4" {
+		t.Errorf("Unexpected settings %q", got)
+	}
+
+	if workers, err := config.Tuning.Int("workers", 1); err != nil || workers != 4 {
+		t.Errorf("Int(workers) = %d, %v", workers, err)
+	}
+
+	if trace, err := config.Tuning.Bool("trace", false); err != nil || !trace {
+		t.Errorf("Bool(trace) = %v, %v", trace, err)
+	}
+
+	if flush, err := config.Tuning.Duration("flush", time.Second); err != nil || flush != 250*time.Millisecond {
+		t.Errorf("Duration(flush) = %v, %v", flush, err)
+	}
+
+	if _, err := config.Tuning.Int("mode", 1); err == nil {
+		t.Error("Expected an error for a non-numeric setting")
+	}
+
+	if got := config.Tuning.Get("missing", "default"); got != "default" {
+		t.Errorf("Get(missing) = %q", got)
+	}
+
+	if err := config.Tuning.Check("workers", "trace", "flush", "mode"); err != nil {
+		t.Errorf("Unexpected error for known settings: %v", err)
+	}
+
+	if err := config.Tuning.Check("workers"); err == nil || err.Error() != "unknown settings: flush, mode, trace" {
+		t.Errorf("Expected unknown settings error, got %v", err)
+	}
+}
+
+func TestParse_FlagsMapInvalid(t *testing.T) {
+	type Config struct {
+		Tuning FlagsMap `env:"TUNING"`
+	}
+
+	for _, value := range []string{"workers", "=4", "a=1,a=2"} {
+		t.Setenv("TUNING", value)
+
+		var config Config
+		if err := Parse(&config); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
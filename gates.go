@@ -0,0 +1,130 @@
+package env
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FeatureGates is a set of named features that can be switched on and off
+// through a single variable, for rolling out experimental behaviour:
+//
+//	var gates = env.Gates("MYAPP_FEATURES").
+//		Define("newParser", false).
+//		Define("fastPath", true)
+//
+//	func main() {
+//		if err := gates.Load(); err != nil {
+//			log.Fatal(err)
+//		}
+//		if gates.Enabled("newParser") {
+//			// ...
+//		}
+//	}
+//
+// The variable holds comma-separated name=bool settings, for example
+// MYAPP_FEATURES=newParser=true,fastPath=false. Features not mentioned keep
+// the default they were defined with.
+//
+// A FeatureGates is safe for concurrent use.
+type FeatureGates struct {
+	key string
+
+	mu       sync.RWMutex
+	defaults map[string]bool
+	enabled  map[string]bool
+}
+
+// Gates returns an empty set of feature gates read from the variable key.
+func Gates(key string) *FeatureGates {
+	return &FeatureGates{
+		key:      key,
+		defaults: make(map[string]bool),
+		enabled:  make(map[string]bool),
+	}
+}
+
+// Define declares the feature name with its default state and returns g.
+func (g *FeatureGates) Define(name string, enabled bool) *FeatureGates {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.defaults[name] = enabled
+	g.enabled[name] = enabled
+	return g
+}
+
+// Load reads the variable and applies its settings on top of the defaults.
+// It returns an error, and leaves the gates unchanged, if the variable is
+// malformed or names a feature that was not defined.
+func (g *FeatureGates) Load() error {
+	value, _ := GetString(g.key)
+
+	var settings FlagsMap
+	if err := settings.UnmarshalText([]byte(value)); err != nil {
+		return errors.New("invalid value for environment variable: " + g.key + ": " + err.Error())
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	enabled := make(map[string]bool, len(g.defaults))
+	for name, state := range g.defaults {
+		enabled[name] = state
+	}
+
+	for _, name := range settings.keys() {
+		if _, ok := g.defaults[name]; !ok {
+			return errors.New("invalid value for environment variable: " + g.key + ": unknown feature " + name)
+		}
+
+		state, err := parseBool(settings[name])
+		if err != nil {
+			return errors.New("invalid value for environment variable: " + g.key +
+				": feature " + name + " set to " + strconv.Quote(settings[name]))
+		}
+		enabled[name] = state
+	}
+
+	g.enabled = enabled
+	return nil
+}
+
+// Enabled reports whether the feature name is enabled. Features that were
+// never defined are reported as disabled.
+func (g *FeatureGates) Enabled(name string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.enabled[name]
+}
+
+// Active returns the names of the enabled features, sorted.
+func (g *FeatureGates) Active() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var active []string
+	for name, enabled := range g.enabled {
+		if enabled {
+			active = append(active, name)
+		}
+	}
+	sort.Strings(active)
+	return active
+}
+
+// String reports the state of every feature in the format of the variable,
+// with names sorted.
+func (g *FeatureGates) String() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	settings := make([]string, 0, len(g.enabled))
+	for name, enabled := range g.enabled {
+		settings = append(settings, name+"="+strconv.FormatBool(enabled))
+	}
+	sort.Strings(settings)
+	return strings.Join(settings, ",")
+}
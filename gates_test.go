@@ -0,0 +1,55 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeatureGates(t *testing.T) {
+	clearenv(t)
+
+	gates := Gates("FEATURES").
+		Define("newParser", false).
+		Define("fastPath", true).
+		Define("tracing", false)
+
+	if err := gates.Load(); err != nil {
+		t.Fatalf("Failed to load unset gates: %v", err)
+	}
+
+	if gates.Enabled("newParser") || !gates.Enabled("fastPath") {
+		t.Errorf("Expected defaults, got %s", gates)
+	}
+
+	t.Setenv("FEATURES", "newParser=true, fastPath=false")
+	if err := gates.Load(); err != nil {
+		t.Fatalf("Failed to load gates: %v", err)
+	}
+
+	if got := gates.Active(); !reflect.DeepEqual(got, []string{"newParser"}) {
+		t.Errorf("Expected only newParser to be active, got %v", got)
+	}
+
+	if got := gates.String(); got != "fastPath=false,newParser=true,tracing=false" {
+		t.Errorf("Unexpected report %q", got)
+	}
+
+	if gates.Enabled("undefined") {
+		t.Error("Expected undefined features to be disabled")
+	}
+}
+
+func TestFeatureGates_Invalid(t *testing.T) {
+	gates := Gates("FEATURES").Define("newParser", false)
+
+	for _, value := range []string{"unknown=true", "newParser=maybe", "newParser"} {
+		t.Setenv("FEATURES", value)
+		if err := gates.Load(); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+
+	if gates.Enabled("newParser") {
+		t.Error("Expected a failed Load to leave the gates unchanged")
+	}
+}
@@ -0,0 +1,172 @@
+// Package ghactions reads and writes variables the way GitHub Actions passes
+// them between workflow steps, so that actions written in Go can use the env
+// package for both their inputs and their outputs.
+//
+// Inputs arrive as INPUT_<NAME> variables; Inputs exposes them under their
+// declared names:
+//
+//	type Inputs struct {
+//		Greeting string `env:"greeting"`
+//	}
+//
+//	var in Inputs
+//	err := env.Parse(&in, env.WithNoOSEnv(), env.WithSource(ghactions.Inputs()))
+//
+// Outputs and variables for later steps are written with SetOutput and
+// SetEnv, which append to the files named by GITHUB_OUTPUT and GITHUB_ENV.
+package ghactions
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/caleflat/env"
+)
+
+// Inputs returns a Source serving the inputs of the running action. A key is
+// looked up as INPUT_ followed by the key in upper case with spaces replaced
+// by underscores, as the runner does.
+func Inputs() env.Source {
+	return inputSource{}
+}
+
+type inputSource struct{}
+
+func (inputSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv("INPUT_" + strings.ToUpper(strings.ReplaceAll(key, " ", "_")))
+}
+
+func (inputSource) String() string {
+	return "github actions inputs"
+}
+
+// SetOutput sets the output name of the current step by appending to the file
+// named by GITHUB_OUTPUT.
+func SetOutput(name, value string) error {
+	return appendFile("GITHUB_OUTPUT", name, value)
+}
+
+// SetEnv sets the variable name for the following steps of the job by
+// appending to the file named by GITHUB_ENV.
+func SetEnv(name, value string) error {
+	return appendFile("GITHUB_ENV", name, value)
+}
+
+func appendFile(key, name, value string) error {
+	path, ok := os.LookupEnv(key)
+	if !ok || path == "" {
+		return errors.New("environment variable not found: " + key)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, name, value); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Write writes the assignment of value to name to w in the format of the
+// GITHUB_ENV and GITHUB_OUTPUT files. Values spanning several lines are
+// written with a random heredoc delimiter.
+func Write(w io.Writer, name, value string) error {
+	if name == "" || strings.ContainsAny(name, "=\n\r") || strings.Contains(name, "<<") {
+		return errors.New("invalid variable name: " + strconv.Quote(name))
+	}
+
+	if !strings.ContainsAny(value, "\n\r") {
+		_, err := io.WriteString(w, name+"="+value+"\n")
+		return err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	delimiter := "ghadelimiter_" + hex.EncodeToString(b)
+
+	_, err := io.WriteString(w, name+"<<"+delimiter+"\n"+value+"\n"+delimiter+"\n")
+	return err
+}
+
+// Parse reads assignments in the format of the GITHUB_ENV and GITHUB_OUTPUT
+// files from r. Later assignments override earlier ones.
+func Parse(r io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+
+		if name, delimiter, ok := strings.Cut(text, "<<"); ok && !strings.Contains(name, "=") {
+			start := line
+			var lines []string
+			closed := false
+			for scanner.Scan() {
+				line++
+				if scanner.Text() == delimiter {
+					closed = true
+					break
+				}
+				lines = append(lines, scanner.Text())
+			}
+			if !closed {
+				return nil, errors.New("line " + strconv.Itoa(start) + ": missing delimiter " + delimiter)
+			}
+			vars[name] = strings.Join(lines, "\n")
+			continue
+		}
+
+		name, value, ok := strings.Cut(text, "=")
+		if !ok || name == "" {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": expected NAME=value or NAME<<DELIMITER")
+		}
+		vars[name] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// FileSource returns a Source serving the variables assigned in the file at
+// path, which is in the format of the GITHUB_ENV and GITHUB_OUTPUT files.
+func FileSource(path string) (env.Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars, err := Parse(f)
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+
+	return fileSource(vars), nil
+}
+
+type fileSource map[string]string
+
+func (s fileSource) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
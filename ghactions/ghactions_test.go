@@ -0,0 +1,101 @@
+package ghactions
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/caleflat/env"
+	"github.com/caleflat/env/sourcetest"
+)
+
+func TestInputs(t *testing.T) {
+	t.Setenv("INPUT_WHO-TO-GREET", "Mona")
+	t.Setenv("INPUT_DRY_RUN", "true")
+
+	type inputs struct {
+		Who    string `env:"who-to-greet"`
+		DryRun bool   `env:"dry run"`
+	}
+
+	var in inputs
+	if err := env.Parse(&in, env.WithNoOSEnv(), env.WithSource(Inputs())); err != nil {
+		t.Fatalf("Failed to parse inputs: %v", err)
+	}
+
+	if in != (inputs{Who: "Mona", DryRun: true}) {
+		t.Errorf("Unexpected inputs %+v", in)
+	}
+}
+
+func TestSetOutputAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(dir, "output"))
+	t.Setenv("GITHUB_ENV", filepath.Join(dir, "env"))
+
+	if err := SetOutput("version", "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOutput("notes", "line one\nline two"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetEnv("DEPLOY_ENV", "staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := FileSource(filepath.Join(dir, "output"))
+	if err != nil {
+		t.Fatalf("Failed to read outputs: %v", err)
+	}
+
+	for key, want := range map[string]string{"version": "1.2.3", "notes": "line one\nline two"} {
+		if got, ok := s.Lookup(key); !ok || got != want {
+			t.Errorf("Lookup(%q) = %q, %v, expected %q", key, got, ok, want)
+		}
+	}
+
+	b, _ := os.ReadFile(filepath.Join(dir, "env"))
+	if string(b) != "DEPLOY_ENV=staging\n" {
+		t.Errorf("Unexpected GITHUB_ENV content %q", b)
+	}
+}
+
+func TestParse(t *testing.T) {
+	vars, err := Parse(strings.NewReader("A=1\nB<<EOF\nx=y\n\nz\nEOF\nC=a=b\nA=2\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	expected := map[string]string{"A": "2", "B": "x=y\n\nz", "C": "a=b"}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("Expected %v, got %v", expected, vars)
+	}
+
+	for _, input := range []string{"NOVALUE", "A<<EOF\nunterminated"} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	sourcetest.Run(t, func(t *testing.T, vars map[string]string) env.Source {
+		path := filepath.Join(t.TempDir(), "env")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range vars {
+			Write(f, key, value)
+		}
+		f.Close()
+
+		s, err := FileSource(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
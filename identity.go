@@ -0,0 +1,111 @@
+package env
+
+import (
+	"errors"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// Identity is a user and group, such as a service drops its privileges to.
+//
+// It is parsed from "user" or "user:group", where user and group are names
+// or numeric IDs, like the --user flag of docker. Names are resolved with
+// os/user; numeric IDs are accepted even if no such user or group exists,
+// which is common in containers.
+type Identity struct {
+	UID int
+	// GID is the given group, or the primary group of the user. It is -1
+	// if no group was given and the user is unknown.
+	GID int
+	// Username and Group are the names of the user and group, if known.
+	Username string
+	Group    string
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *Identity) UnmarshalText(text []byte) error {
+	userPart, groupPart, hasGroup := strings.Cut(string(text), ":")
+	if userPart == "" || (hasGroup && groupPart == "") {
+		return errors.New("invalid identity: " + strconv.Quote(string(text)))
+	}
+
+	parsed := Identity{GID: -1}
+
+	u, err := lookupUser(userPart)
+	if err != nil {
+		return err
+	}
+	if u != nil {
+		parsed.Username = u.Username
+		parsed.UID, err = strconv.Atoi(u.Uid)
+		if err != nil {
+			return errors.New("user " + u.Username + " has no numeric ID")
+		}
+		if !hasGroup {
+			groupPart = u.Gid
+		}
+	} else {
+		parsed.UID, _ = strconv.Atoi(userPart)
+	}
+
+	if groupPart != "" {
+		g, err := lookupGroup(groupPart)
+		if err != nil {
+			return err
+		}
+		if g != nil {
+			parsed.Group = g.Name
+			parsed.GID, err = strconv.Atoi(g.Gid)
+			if err != nil {
+				return errors.New("group " + g.Name + " has no numeric ID")
+			}
+		} else {
+			parsed.GID, _ = strconv.Atoi(groupPart)
+		}
+	}
+
+	*id = parsed
+	return nil
+}
+
+// String returns id in the form "uid:gid".
+func (id Identity) String() string {
+	return strconv.Itoa(id.UID) + ":" + strconv.Itoa(id.GID)
+}
+
+// lookupUser resolves a user name or ID. It returns nil without an error for
+// a numeric ID that does not belong to a known user.
+func lookupUser(s string) (*user.User, error) {
+	if _, err := strconv.Atoi(s); err == nil {
+		u, err := user.LookupId(s)
+		if err != nil {
+			return nil, nil
+		}
+		return u, nil
+	}
+
+	u, err := user.Lookup(s)
+	if err != nil {
+		return nil, errors.New("unknown user: " + s)
+	}
+	return u, nil
+}
+
+// lookupGroup resolves a group name or ID. It returns nil without an error
+// for a numeric ID that does not belong to a known group.
+func lookupGroup(s string) (*user.Group, error) {
+	if _, err := strconv.Atoi(s); err == nil {
+		g, err := user.LookupGroupId(s)
+		if err != nil {
+			return nil, nil
+		}
+		return g, nil
+	}
+
+	g, err := user.LookupGroup(s)
+	if err != nil {
+		return nil, errors.New("unknown group: " + s)
+	}
+	return g, nil
+}
@@ -0,0 +1,51 @@
+package env
+
+import (
+	"os/user"
+	"testing"
+)
+
+func TestParse_Identity(t *testing.T) {
+	if _, err := user.LookupGroup("root"); err != nil {
+		t.Skip("no root user and group on this system")
+	}
+
+	type Config struct {
+		RunAs Identity `env:"RUN_AS"`
+	}
+
+	tests := []struct {
+		value   string
+		want    Identity
+		wantErr bool
+	}{
+		{value: "root", want: Identity{UID: 0, GID: 0, Username: "root", Group: "root"}},
+		{value: "0:0", want: Identity{UID: 0, GID: 0, Username: "root", Group: "root"}},
+		{value: "54321", want: Identity{UID: 54321, GID: -1}},
+		{value: "54321:54322", want: Identity{UID: 54321, GID: 54322}},
+		{value: "no-such-user-here", wantErr: true},
+		{value: "0:", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("RUN_AS", tt.value)
+
+		var config Config
+		err := Parse(&config)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected an error for %q", tt.value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", tt.value, err)
+			continue
+		}
+
+		if config.RunAs != tt.want {
+			t.Errorf("Parsing %q: expected %+v, got %+v", tt.value, tt.want, config.RunAs)
+		}
+	}
+}
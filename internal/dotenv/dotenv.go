@@ -0,0 +1,137 @@
+// Package dotenv reads files in the .env format shared by the env package
+// and its tools.
+//
+// A file consists of KEY=VALUE lines, optionally prefixed with "export".
+// Blank lines are ignored and lines starting with # are comments. Values may
+// be double quoted, with \n, \t, \" and \\ escapes, or single quoted, taken
+// literally. An unquoted value ends at a # preceded by white space.
+package dotenv
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Entry is a variable assignment read from a file.
+type Entry struct {
+	Key   string
+	Value string
+	// Comments are the comment lines directly above the assignment,
+	// without the leading # and surrounding white space.
+	Comments []string
+	// Line is the line number of the assignment, starting at 1.
+	Line int
+}
+
+// Parse reads the entries of a .env file from r in the order they appear.
+func Parse(r io.Reader) ([]Entry, error) {
+	var (
+		entries  []Entry
+		comments []string
+		line     int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case text == "":
+			comments = nil
+			continue
+		case strings.HasPrefix(text, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimPrefix(text, "#")))
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": expected KEY=VALUE")
+		}
+
+		value, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+
+		entries = append(entries, Entry{Key: key, Value: value, Comments: comments, Line: line})
+		comments = nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Map returns the entries as a map from key to value. Later entries override
+// earlier ones.
+func Map(entries []Entry) map[string]string {
+	m := make(map[string]string, len(entries))
+	for _, e := range entries {
+		m[e.Key] = e.Value
+	}
+	return m
+}
+
+func parseValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return value[1 : end+1], checkTrailing(value[end+2:])
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			switch {
+			case c == '"':
+				return b.String(), checkTrailing(value[i+1:])
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double-quoted value")
+	}
+
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i]), nil
+		}
+	}
+
+	return value, nil
+}
+
+// checkTrailing reports an error if anything but a comment follows a quoted
+// value.
+func checkTrailing(rest string) error {
+	if trimmed := strings.TrimSpace(rest); trimmed != "" && (trimmed == rest || trimmed[0] != '#') {
+		return errors.New("unexpected text after quoted value")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package dotenv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `
+# Database settings.
+# Used by the worker too.
+DB_HOST=localhost
+export DB_PORT = 5432 # inline comment
+
+DB_PASSWORD="p#ss \"word\"\n"
+DB_NAME='app db' # comment
+EMPTY=
+HASH=a#b
+`
+
+	entries, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	expected := []Entry{
+		{Key: "DB_HOST", Value: "localhost", Comments: []string{"Database settings.", "Used by the worker too."}, Line: 4},
+		{Key: "DB_PORT", Value: "5432", Line: 5},
+		{Key: "DB_PASSWORD", Value: "p#ss \"word\"\n", Line: 7},
+		{Key: "DB_NAME", Value: "app db", Line: 8},
+		{Key: "EMPTY", Value: "", Line: 9},
+		{Key: "HASH", Value: "a#b", Line: 10},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Entries do not match.\nExpected: %+v\nGot: %+v", expected, entries)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, input := range []string{"NO_EQUALS", "=value", "A B=c", `QUOTED="open`, "SINGLE='open", "TRAILING='a'b"} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
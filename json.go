@@ -0,0 +1,31 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// GetJSON unmarshals the value of the environment variable named by the key
+// into v with json.Unmarshal, for structured values passed through a single
+// variable:
+//
+//	var features struct {
+//	  Beta  bool     `json:"beta"`
+//	  Teams []string `json:"teams"`
+//	}
+//	err := env.GetJSON("FEATURES_JSON", &features)
+//
+// If the variable is not present, v is left unchanged and an error wrapping
+// ErrNotFound is returned.
+func GetJSON(key string, v interface{}) error {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return errors.New("invalid value for environment variable: " + key + ": " + err.Error())
+	}
+	return nil
+}
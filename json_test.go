@@ -0,0 +1,39 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetJSON(t *testing.T) {
+	type Features struct {
+		Beta  bool     `json:"beta"`
+		Teams []string `json:"teams"`
+	}
+
+	clearenv(t)
+	t.Setenv("FEATURES_JSON", `{"beta": true, "teams": ["core", "web"]}`)
+
+	var features Features
+	if err := GetJSON("FEATURES_JSON", &features); err != nil {
+		t.Fatalf("Failed to get JSON: %v", err)
+	}
+
+	expected := Features{Beta: true, Teams: []string{"core", "web"}}
+	if !reflect.DeepEqual(features, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, features)
+	}
+
+	if err := GetJSON("MISSING", &features); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if !reflect.DeepEqual(features, expected) {
+		t.Errorf("Expected a missing variable to leave the target unchanged, got %+v", features)
+	}
+
+	t.Setenv("FEATURES_JSON", `{"beta": "yes"}`)
+	if err := GetJSON("FEATURES_JSON", &features); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected an unmarshal error, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package launchd loads the EnvironmentVariables dictionary of launchd
+// property lists, so macOS agents and daemons managed by launchd can share
+// their configuration with the env structs:
+//
+//	src, err := launchd.FileSource("/Library/LaunchDaemons/com.example.agent.plist")
+//	if err != nil {
+//		return err
+//	}
+//	err = env.Parse(&config, env.WithSource(src))
+//
+// Only XML property lists are supported; convert binary ones with
+// "plutil -convert xml1".
+package launchd
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/caleflat/env"
+)
+
+// Parse reads the XML property list from r and returns its
+// EnvironmentVariables dictionary. A property list without one yields an
+// empty map.
+func Parse(r io.Reader) (map[string]string, error) {
+	d := xml.NewDecoder(r)
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("property list has no top-level dict")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local == "plist" {
+			continue
+		}
+		if start.Name.Local != "dict" {
+			return nil, errors.New("property list has no top-level dict")
+		}
+
+		top, err := decodeDict(d)
+		if err != nil {
+			return nil, err
+		}
+
+		vars := make(map[string]string)
+		raw, ok := top["EnvironmentVariables"]
+		if !ok {
+			return vars, nil
+		}
+
+		dict, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("EnvironmentVariables is not a dict")
+		}
+		for key, value := range dict {
+			s, ok := value.(string)
+			if !ok {
+				return nil, errors.New("EnvironmentVariables: value of " + key + " is not a string")
+			}
+			vars[key] = s
+		}
+
+		return vars, nil
+	}
+}
+
+// Load is like Parse but reads the property list from the file at path.
+func Load(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars, err := Parse(f)
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	return vars, nil
+}
+
+// FileSource returns a Source serving the EnvironmentVariables of the
+// property list at path.
+func FileSource(path string) (env.Source, error) {
+	vars, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return source(vars), nil
+}
+
+type source map[string]string
+
+func (s source) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+// decodeDict decodes the contents of a dict element whose start tag has
+// been read.
+func decodeDict(d *xml.Decoder) (map[string]interface{}, error) {
+	dict := make(map[string]interface{})
+	var key *string
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			if key != nil {
+				return nil, errors.New("dict key " + *key + " has no value")
+			}
+			return dict, nil
+		case xml.StartElement:
+			if key == nil {
+				if tok.Name.Local != "key" {
+					return nil, errors.New("expected key in dict, got " + tok.Name.Local)
+				}
+				var k string
+				if err := d.DecodeElement(&k, &tok); err != nil {
+					return nil, err
+				}
+				key = &k
+				continue
+			}
+
+			value, err := decodeValue(d, tok)
+			if err != nil {
+				return nil, err
+			}
+			dict[*key] = value
+			key = nil
+		}
+	}
+}
+
+// decodeValue decodes the element started by start. Strings and dicts are
+// decoded; other values are skipped and returned as nil.
+func decodeValue(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		return decodeDict(d)
+	case "string":
+		var s string
+		err := d.DecodeElement(&s, &start)
+		return s, err
+	}
+
+	return nil, d.Skip()
+}
@@ -0,0 +1,88 @@
+package launchd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/caleflat/env"
+)
+
+const agent = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.example.agent</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/usr/local/bin/agent</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>PORT</key>
+		<string>8080</string>
+		<key>HOST</key>
+		<string>localhost</string>
+	</dict>
+</dict>
+</plist>
+`
+
+func TestParse(t *testing.T) {
+	vars, err := Parse(strings.NewReader(agent))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	expected := map[string]string{"PORT": "8080", "HOST": "localhost"}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("Expected %v, got %v", expected, vars)
+	}
+}
+
+func TestParse_NoEnvironment(t *testing.T) {
+	vars, err := Parse(strings.NewReader(`<plist><dict><key>Label</key><string>x</string></dict></plist>`))
+	if err != nil || len(vars) != 0 {
+		t.Errorf("Expected an empty map, got %v, %v", vars, err)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, input := range []string{
+		`<plist><array></array></plist>`,
+		`<plist><dict><key>EnvironmentVariables</key><string>x</string></dict></plist>`,
+		`<plist><dict><key>EnvironmentVariables</key><dict><key>PORT</key><integer>1</integer></dict></dict></plist>`,
+		`bplist00`,
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.plist")
+	os.WriteFile(path, []byte(agent), 0o644)
+
+	src, err := FileSource(path)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	var config struct {
+		Port int    `env:"PORT"`
+		Host string `env:"HOST"`
+	}
+	if err := env.Parse(&config, env.WithNoOSEnv(), env.WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	if config.Port != 8080 || config.Host != "localhost" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+}
@@ -0,0 +1,135 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The Lookup functions return the value of the environment variable named by
+// the key and whether it is present, so that callers can tell an unset
+// variable from one set to the zero value. If the variable is present but
+// cannot be parsed, the zero value, true and an error are returned.
+
+// LookupString returns the value of the environment variable named by the key
+// and whether it is present.
+func LookupString(key string) (string, bool) {
+	return lookupEnv(key)
+}
+
+// LookupInt is like LookupString but parses the value as an int.
+func LookupInt(key string) (int, bool, error) {
+	i, ok, err := LookupInt64(key)
+	return int(i), ok, err
+}
+
+// LookupInt64 is like LookupString but parses the value as an int64.
+func LookupInt64(key string) (int64, bool, error) {
+	return lookupAs(key, parseInt)
+}
+
+// LookupUint is like LookupString but parses the value as a uint.
+func LookupUint(key string) (uint, bool, error) {
+	u, ok, err := LookupUint64(key)
+	return uint(u), ok, err
+}
+
+// LookupUint64 is like LookupString but parses the value as a uint64.
+func LookupUint64(key string) (uint64, bool, error) {
+	return lookupAs(key, parseUint)
+}
+
+// LookupBool is like LookupString but parses the value as a bool.
+func LookupBool(key string) (bool, bool, error) {
+	return lookupAs(key, parseBool)
+}
+
+// LookupFloat64 is like LookupString but parses the value as a float64.
+func LookupFloat64(key string) (float64, bool, error) {
+	return lookupAs(key, parseFloat)
+}
+
+// LookupDuration is like LookupString but parses the value as a
+// time.Duration.
+func LookupDuration(key string) (time.Duration, bool, error) {
+	return lookupAs(key, parseDuration)
+}
+
+func lookupAs[T any](key string, parse func(string) (T, error)) (T, bool, error) {
+	var zero T
+
+	value, ok := lookupEnv(key)
+	if !ok {
+		return zero, false, nil
+	}
+
+	v, err := parse(value)
+	if err != nil {
+		return zero, true, errors.New("invalid value for environment variable: " + key + ": " + err.Error())
+	}
+
+	return v, true, nil
+}
+
+// ErrNotFound is returned, wrapped, by the E variants of the getters when
+// the variable is not present.
+var ErrNotFound = errors.New("environment variable not found")
+
+// The E variants of the getters return an error wrapping ErrNotFound if the
+// variable is not present, and an error describing the problem if its value
+// cannot be parsed.
+
+// GetStringE returns the value of the environment variable named by the key.
+func GetStringE(key string) (string, error) {
+	return getE(key, func(key string) (string, bool, error) {
+		value, ok := LookupString(key)
+		return value, ok, nil
+	})
+}
+
+// GetIntE returns the value of the environment variable named by the key as
+// an int.
+func GetIntE(key string) (int, error) {
+	return getE(key, LookupInt)
+}
+
+// GetInt64E returns the value of the environment variable named by the key as
+// an int64.
+func GetInt64E(key string) (int64, error) {
+	return getE(key, LookupInt64)
+}
+
+// GetUintE returns the value of the environment variable named by the key as
+// a uint.
+func GetUintE(key string) (uint, error) {
+	return getE(key, LookupUint)
+}
+
+// GetBoolE returns the value of the environment variable named by the key as
+// a bool.
+func GetBoolE(key string) (bool, error) {
+	return getE(key, LookupBool)
+}
+
+// GetFloat64E returns the value of the environment variable named by the key
+// as a float64.
+func GetFloat64E(key string) (float64, error) {
+	return getE(key, LookupFloat64)
+}
+
+// GetDurationE returns the value of the environment variable named by the key
+// as a time.Duration.
+func GetDurationE(key string) (time.Duration, error) {
+	return getE(key, LookupDuration)
+}
+
+func getE[T any](key string, lookup func(string) (T, bool, error)) (T, error) {
+	v, ok, err := lookup(key)
+	if err != nil {
+		return v, err
+	}
+	if !ok {
+		return v, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return v, nil
+}
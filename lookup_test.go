@@ -0,0 +1,74 @@
+package env
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	clearenv(t)
+	t.Setenv("ZERO", "0")
+	t.Setenv("EMPTY", "")
+	t.Setenv("TIMEOUT", "1m")
+	t.Setenv("BAD", "x")
+
+	if value, ok := LookupString("EMPTY"); !ok || value != "" {
+		t.Errorf("LookupString(EMPTY) = %q, %v", value, ok)
+	}
+
+	if i, ok, err := LookupInt("ZERO"); i != 0 || !ok || err != nil {
+		t.Errorf("LookupInt(ZERO) = %d, %v, %v", i, ok, err)
+	}
+
+	if i, ok, err := LookupInt("MISSING"); i != 0 || ok || err != nil {
+		t.Errorf("LookupInt(MISSING) = %d, %v, %v", i, ok, err)
+	}
+
+	if _, ok, err := LookupInt("BAD"); !ok || err == nil {
+		t.Errorf("LookupInt(BAD) = %v, %v, expected present with an error", ok, err)
+	}
+
+	if u, ok, err := LookupUint("ZERO"); u != 0 || !ok || err != nil {
+		t.Errorf("LookupUint(ZERO) = %d, %v, %v", u, ok, err)
+	}
+
+	if _, ok, err := LookupBool("BAD"); !ok || err == nil {
+		t.Errorf("LookupBool(BAD) = %v, %v, expected present with an error", ok, err)
+	}
+
+	if f, ok, err := LookupFloat64("ZERO"); f != 0 || !ok || err != nil {
+		t.Errorf("LookupFloat64(ZERO) = %v, %v, %v", f, ok, err)
+	}
+
+	if d, ok, err := LookupDuration("TIMEOUT"); d != time.Minute || !ok || err != nil {
+		t.Errorf("LookupDuration(TIMEOUT) = %v, %v, %v", d, ok, err)
+	}
+}
+
+func TestGetE(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PORT", "8080")
+	t.Setenv("DEBUG", "yes")
+	t.Setenv("TIMEOUT", "5s")
+
+	if port, err := GetIntE("PORT"); port != 8080 || err != nil {
+		t.Errorf("GetIntE(PORT) = %d, %v", port, err)
+	}
+
+	if _, err := GetIntE("MISSING"); !errors.Is(err, ErrNotFound) || err.Error() != "environment variable not found: MISSING" {
+		t.Errorf("GetIntE(MISSING) returned %v, expected ErrNotFound", err)
+	}
+
+	if _, err := GetBoolE("DEBUG"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("GetBoolE(DEBUG) returned %v, expected a parse error", err)
+	}
+
+	if d, err := GetDurationE("TIMEOUT"); d != 5*time.Second || err != nil {
+		t.Errorf("GetDurationE(TIMEOUT) = %v, %v", d, err)
+	}
+
+	if _, err := GetStringE("MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetStringE(MISSING) returned %v, expected ErrNotFound", err)
+	}
+}
@@ -0,0 +1,51 @@
+package env
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// GetMap returns the value of the environment variable named by the key
+// parsed as comma-separated key=value pairs, such as "team=core,tier=1".
+// Map fields in structs passed to Parse use the same format. If the variable
+// is not present, def is returned.
+func GetMap(key string, def map[string]string) map[string]string {
+	return GetMapSep(key, ",", "=", def)
+}
+
+// GetMapSep is like GetMap but splits pairs on sep and keys from values on
+// kvSep. White space around keys and values is trimmed, empty pairs are
+// dropped and later pairs override earlier ones. Pairs without kvSep or with
+// an empty key are skipped.
+func GetMapSep(key string, sep, kvSep string, def map[string]string) map[string]string {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+
+	// With a skip function, parseMap never fails.
+	m, _ := parseMap(value, sep, kvSep, func(err error) { malformed(key, err) })
+	return m
+}
+
+// parseMap parses value as pairs separated by sep, with keys separated from
+// values by kvSep. Malformed pairs are passed to skip, or are an error if
+// skip is nil.
+func parseMap(value, sep, kvSep string, skip func(error)) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, pair := range splitList(value, sep) {
+		k, v, ok := strings.Cut(pair, kvSep)
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			err := errors.New("malformed pair " + strconv.Quote(pair))
+			if skip == nil {
+				return nil, err
+			}
+			skip(err)
+			continue
+		}
+		m[k] = strings.TrimSpace(v)
+	}
+	return m, nil
+}
@@ -0,0 +1,57 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetMap(t *testing.T) {
+	clearenv(t)
+	t.Setenv("LABELS", " team = core ,tier=1,,url=a=b ")
+	t.Setenv("HEADERS", "Accept: text/plain; X-Id: 7")
+	t.Setenv("BROKEN", "team=core,oops,=x")
+
+	expected := map[string]string{"team": "core", "tier": "1", "url": "a=b"}
+	if m := GetMap("LABELS", nil); !reflect.DeepEqual(m, expected) {
+		t.Errorf("Expected %v, got %v", expected, m)
+	}
+
+	expected = map[string]string{"Accept": "text/plain", "X-Id": "7"}
+	if m := GetMapSep("HEADERS", ";", ":", nil); !reflect.DeepEqual(m, expected) {
+		t.Errorf("Expected %v, got %v", expected, m)
+	}
+
+	expected = map[string]string{"team": "core"}
+	if m := GetMap("BROKEN", nil); !reflect.DeepEqual(m, expected) {
+		t.Errorf("Expected malformed pairs to be skipped, got %v", m)
+	}
+
+	def := map[string]string{"a": "b"}
+	if m := GetMap("MISSING", def); !reflect.DeepEqual(m, def) {
+		t.Errorf("Expected the default, got %v", m)
+	}
+}
+
+func TestParse_Map(t *testing.T) {
+	type Config struct {
+		Labels map[string]string `env:"LABELS"`
+	}
+
+	clearenv(t)
+	t.Setenv("LABELS", "team=core,tier=1")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := map[string]string{"team": "core", "tier": "1"}
+	if !reflect.DeepEqual(config.Labels, expected) {
+		t.Errorf("Expected %v, got %v", expected, config.Labels)
+	}
+
+	t.Setenv("LABELS", "team")
+	if err := Parse(&config); err == nil {
+		t.Error("Expected an error for a malformed pair")
+	}
+}
@@ -0,0 +1,84 @@
+package env
+
+import (
+	"io"
+	"strings"
+)
+
+// Doc writes a Markdown table of the variables read by config, a struct or
+// a pointer to one, for READMEs generated from the code rather than kept in
+// sync by hand:
+//
+//	| Name | Type | Default | Required | Description |
+//	| --- | --- | --- | --- | --- |
+//	| `DB_HOST` | `string` | `localhost` | no | Database host |
+//
+// Descriptions and defaults are those shown by Usage.
+func Doc(config interface{}, w io.Writer) error {
+	docs, err := describe(config)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("| Name | Type | Default | Required | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, d := range docs {
+		def := ""
+		if d.Default != nil && !d.Secret {
+			def = code(*d.Default)
+		}
+		required := "no"
+		if d.Required {
+			required = "yes"
+		}
+
+		var details []string
+		if d.Description != "" {
+			details = append(details, d.Description)
+		}
+		if len(d.allowed) > 0 {
+			allowed := make([]string, len(d.allowed))
+			for i, a := range d.allowed {
+				allowed[i] = code(a)
+			}
+			details = append(details, "One of "+strings.Join(allowed, ", ")+".")
+		}
+		if len(d.Fallbacks) > 0 {
+			fallbacks := make([]string, len(d.Fallbacks))
+			for i, f := range d.Fallbacks {
+				fallbacks[i] = code(f)
+			}
+			details = append(details, "Also read from "+strings.Join(fallbacks, ", ")+".")
+		}
+		if d.Secret {
+			details = append(details, "Secret.")
+		}
+
+		b.WriteString("| " + code(d.Name) + " | " + code(d.Type) + " | " + def + " | " + required + " | " + cell(strings.Join(details, " ")) + " |\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// code formats s as a Markdown code span that fits in a table cell.
+func code(s string) string {
+	if s == "" {
+		return `""`
+	}
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + cell(s) + fence
+}
+
+// cell escapes s for a Markdown table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
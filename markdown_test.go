@@ -0,0 +1,43 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDoc(t *testing.T) {
+	var b strings.Builder
+	if err := Doc(&usageConfig{}, &b); err != nil {
+		t.Fatalf("Failed to write documentation: %v", err)
+	}
+
+	expected := "| Name | Type | Default | Required | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `DB_HOST` | `string` | `localhost` | no | Database host |\n" +
+		"| `DB_PASSWORD` | `env.Secret` |  | no | Secret. |\n" +
+		"| `DB_PORT` | `int` |  | yes | Also read from `DATABASE_PORT`. |\n" +
+		"| `LOG_LEVEL` | `string` | `info` | no | One of `debug`, `info`. |\n" +
+		"| `PRIORITY` | `int` | `high` | no | One of `low`, `high`. |\n" +
+		"| `TIMEOUT` | `time.Duration` | `5s` | no |  |\n" +
+		"| `TOKEN` | `env.Credential` |  | yes |  |\n" +
+		"| `TOKEN_EXPIRES_AT` | `time.Time` |  | no | Expiry of TOKEN, as an RFC 3339 time or in seconds since the Unix epoch. |\n"
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestDoc_Escaping(t *testing.T) {
+	type Config struct {
+		Sep string `env:"SEP" default:"|" desc:"Separator, such as | or ;"`
+	}
+
+	var b strings.Builder
+	if err := Doc(&Config{}, &b); err != nil {
+		t.Fatalf("Failed to write documentation: %v", err)
+	}
+
+	expected := "| `SEP` | `string` | `\\|` | no | Separator, such as \\| or ; |\n"
+	if !strings.HasSuffix(b.String(), expected) {
+		t.Errorf("Expected row %q, got:\n%s", expected, b.String())
+	}
+}
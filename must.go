@@ -0,0 +1,45 @@
+package env
+
+import (
+	"errors"
+	"time"
+)
+
+// MustParse is like Parse but panics if Parse returns an error. It is meant
+// for programs that want to fail fast during initialization.
+func MustParse(config interface{}, opts ...Option) {
+	if err := Parse(config, opts...); err != nil {
+		panic(errors.New("env: " + err.Error()))
+	}
+}
+
+// MustGetString returns the value of the environment variable named by the
+// key. It panics if the variable is not present.
+func MustGetString(key string) string {
+	value, ok := lookupEnv(key)
+	if !ok {
+		panic(errors.New("env: environment variable not found: " + key))
+	}
+	return value
+}
+
+// MustGetInt returns the value of the environment variable named by the key
+// as an int. It panics if the variable is not present or is not an integer.
+func MustGetInt(key string) int {
+	i, err := parseInt(MustGetString(key))
+	if err != nil {
+		panic(errors.New("env: invalid value for environment variable: " + key + ": " + err.Error()))
+	}
+	return int(i)
+}
+
+// MustGetDuration returns the value of the environment variable named by the
+// key as a time.Duration. It panics if the variable is not present or is not
+// a duration.
+func MustGetDuration(key string) time.Duration {
+	d, err := parseDuration(MustGetString(key))
+	if err != nil {
+		panic(errors.New("env: invalid value for environment variable: " + key + ": " + err.Error()))
+	}
+	return d
+}
@@ -0,0 +1,62 @@
+package env
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func expectPanic(t *testing.T, want string, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		r := recover()
+		if r == nil {
+			t.Errorf("Expected a panic containing %q", want)
+			return
+		}
+		if err, ok := r.(error); !ok || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected a panic containing %q, got %v", want, r)
+		}
+	}()
+	fn()
+}
+
+func TestMustGet(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "8080")
+	t.Setenv("TIMEOUT", "5s")
+	t.Setenv("BAD", "five")
+
+	if got := MustGetString("HOST"); got != "localhost" {
+		t.Errorf("MustGetString returned %q", got)
+	}
+
+	if got := MustGetInt("PORT"); got != 8080 {
+		t.Errorf("MustGetInt returned %d", got)
+	}
+
+	if got := MustGetDuration("TIMEOUT"); got != 5*time.Second {
+		t.Errorf("MustGetDuration returned %v", got)
+	}
+
+	expectPanic(t, "not found: MISSING", func() { MustGetString("MISSING") })
+	expectPanic(t, "invalid value for environment variable: BAD", func() { MustGetInt("BAD") })
+	expectPanic(t, "invalid value for environment variable: BAD", func() { MustGetDuration("BAD") })
+}
+
+func TestMustParse(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PORT", "8080")
+
+	var config Config
+	expectPanic(t, "not found: HOST", func() { MustParse(&config) })
+
+	t.Setenv("HOST", "localhost")
+	MustParse(&config)
+
+	if config.Host != "localhost" {
+		t.Errorf("Expected config to be parsed, got %+v", config)
+	}
+}
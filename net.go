@@ -0,0 +1,54 @@
+package env
+
+import (
+	"errors"
+	"net"
+	"strconv"
+)
+
+// GetHostPort returns the value of the environment variable named by the
+// key, or def if it is not present, split into a host and a numeric port,
+// such as "db.internal:5432" or ":8080". IPv6 hosts must be bracketed, as in
+// "[::1]:8080". If the value is not a valid host:port, an error is returned.
+func GetHostPort(key, def string) (host string, port int, err error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		value = def
+	}
+
+	host, port, err = parseHostPort(value)
+	if err != nil {
+		return "", 0, errors.New("invalid value for environment variable: " + key + ": " + err.Error())
+	}
+	return host, port, nil
+}
+
+func parseHostPort(value string) (string, int, error) {
+	host, p, err := net.SplitHostPort(value)
+	if err != nil {
+		return "", 0, err
+	}
+
+	port, err := strconv.ParseUint(p, 10, 16)
+	if err != nil {
+		return "", 0, errors.New("invalid port " + strconv.Quote(p))
+	}
+	return host, int(port), nil
+}
+
+// GetPort returns the value of the environment variable named by the key as
+// a TCP or UDP port number. If the variable is not present, def is returned.
+// If its value is not a number from 1 to 65535, an error is returned.
+func GetPort(key string, def int) (int, error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return def, errors.New("invalid value for environment variable: " + key +
+			": " + strconv.Quote(value) + " is not a port between 1 and 65535")
+	}
+	return port, nil
+}
@@ -0,0 +1,64 @@
+package env
+
+import "testing"
+
+func TestGetHostPort(t *testing.T) {
+	clearenv(t)
+
+	tests := []struct {
+		value string
+		host  string
+		port  int
+	}{
+		{"db.internal:5432", "db.internal", 5432},
+		{":8080", "", 8080},
+		{"[::1]:443", "::1", 443},
+		{"localhost:0", "localhost", 0},
+	}
+
+	for _, test := range tests {
+		t.Setenv("ADDR", test.value)
+		host, port, err := GetHostPort("ADDR", "")
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", test.value, err)
+		} else if host != test.host || port != test.port {
+			t.Errorf("Expected %q and %d for %q, got %q and %d", test.host, test.port, test.value, host, port)
+		}
+	}
+
+	for _, value := range []string{"db.internal", "db:http", "db:65536", "db:-1", "::1:80"} {
+		t.Setenv("ADDR", value)
+		if _, _, err := GetHostPort("ADDR", ""); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+
+	if host, port, err := GetHostPort("MISSING", "localhost:8080"); err != nil || host != "localhost" || port != 8080 {
+		t.Errorf("Expected the default, got %q, %d, %v", host, port, err)
+	}
+}
+
+func TestGetPort(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PORT", "9090")
+
+	if port, err := GetPort("PORT", 8080); err != nil || port != 9090 {
+		t.Errorf("Expected 9090, got %d, %v", port, err)
+	}
+
+	if port, err := GetPort("MISSING", 8080); err != nil || port != 8080 {
+		t.Errorf("Expected the default, got %d, %v", port, err)
+	}
+
+	for _, value := range []string{"0", "65536", "-80", "80x0", "", "http"} {
+		t.Setenv("PORT", value)
+		if _, err := GetPort("PORT", 8080); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+
+	t.Setenv("PORT", "70000")
+	if _, err := GetPort("PORT", 8080); err == nil || err.Error() != `invalid value for environment variable: PORT: "70000" is not a port between 1 and 65535` {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
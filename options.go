@@ -0,0 +1,279 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Option configures the behaviour of Parse.
+type Option func(*options)
+
+type options struct {
+	sources             []Source
+	secretsDirs         []string
+	noOSEnv             bool
+	requiredIfNoDefault bool
+	onDeprecated        func(oldKey, newKey string)
+	validators          []func(interface{}) error
+	onSet               func(key string, value interface{}, isDefault, secret bool)
+	onLease             func(key string, ttl time.Duration)
+
+	// preset holds the keys of the fields whose values were set by
+	// SetDefaults.
+	preset map[string]bool
+
+	// batched holds the values prefetched from each BatchSource, by the
+	// source's index, and prefetched the keys they were fetched for.
+	batched    map[int]map[string]cachedLookup
+	prefetched map[string]bool
+
+	// ctx bounds the lookups made by prefetch. concurrency is set by
+	// WithConcurrency, and cached holds the results of the lookups it
+	// made ahead of time.
+	ctx         context.Context
+	concurrency int
+	cached      map[string]cachedLookup
+
+	// deadline is set by WithStartupDeadline, start is when Parse started
+	// resolving variables, and timings records how long each variable took
+	// to resolve.
+	deadline time.Duration
+	start    time.Time
+	timings  []fieldTiming
+
+	// events is set by WithEventSink.
+	events *eventSink
+
+	// decryptors are registered with WithDecryptor.
+	decryptors []decryptor
+
+	// transformers are the names given to WithTransformers.
+	transformers []string
+
+	// coerce is set by WithCoercion.
+	coerce bool
+
+	// names holds the names configs were registered under, to prefix
+	// their errors with.
+	names map[interface{}]string
+
+	// strictNames is set by WithStrictNames.
+	strictNames bool
+
+	// readiness is set by WithReadiness.
+	readiness *Readiness
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{ctx: context.Background(), preset: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if !o.noOSEnv {
+		o.sources = append([]Source{OS()}, o.sources...)
+	}
+	for _, dir := range o.secretsDirs {
+		o.sources = append(o.sources, secretsDir{dirSource(dir)})
+	}
+	return o
+}
+
+// lookup returns the value of key from the first source that has it.
+func (o *options) lookup(key string) (string, bool) {
+	if c, ok := o.cached[key]; ok {
+		o.emit(Event{Kind: EventCacheHit, Key: key, Found: c.ok})
+		o.lease(key, c.ttl)
+		return c.value, c.ok
+	}
+
+	value, ttl, ok := o.lookupSources(key)
+	o.lease(key, ttl)
+	return value, ok
+}
+
+// lookupSources queries the sources for key in order. ttl is the lease of
+// the value if it came from a Leaser.
+func (o *options) lookupSources(key string) (value string, ttl time.Duration, ok bool) {
+	for i, s := range o.sources {
+		if values, batched := o.batched[i]; batched && o.prefetched[key] {
+			c := values[key]
+			value, ttl, ok = c.value, c.ttl, c.ok
+			o.emit(Event{Kind: EventCacheHit, Key: key, Source: sourceName(s), Found: ok})
+		} else {
+			start := time.Now()
+			value, ttl, ok = lookupSource(s, key)
+			o.emit(Event{Kind: EventLookup, Key: key, Source: sourceName(s), Found: ok, Duration: time.Since(start)})
+			if r, isFile := s.(fileReader); isFile && ok {
+				o.emit(Event{Kind: EventFile, Key: key, Source: sourceName(s), File: r.file(key)})
+			}
+		}
+		if ok {
+			return value, ttl, true
+		}
+	}
+	return "", 0, false
+}
+
+// lookupSource looks up key in s, with its lease if s is a Leaser.
+func lookupSource(s Source, key string) (string, time.Duration, bool) {
+	if l, ok := s.(Leaser); ok {
+		return l.LookupLease(key)
+	}
+	value, ok := s.Lookup(key)
+	return value, 0, ok
+}
+
+// lease reports the lease of the value of key to the lease handler.
+func (o *options) lease(key string, ttl time.Duration) {
+	if o.onLease != nil && ttl > 0 {
+		o.onLease(key, ttl)
+	}
+}
+
+// prefetch looks up keys in every BatchSource at once, and then, with
+// WithConcurrency, in the remaining sources concurrently, so that lookup can
+// answer them without querying the sources again.
+func (o *options) prefetch(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for i, s := range o.sources {
+		start := time.Now()
+		values, ok := lookupBatch(s, keys)
+		if !ok {
+			continue
+		}
+		if o.batched == nil {
+			o.batched = make(map[int]map[string]cachedLookup)
+			o.prefetched = make(map[string]bool, len(keys))
+			for _, key := range keys {
+				o.prefetched[key] = true
+			}
+		}
+		o.batched[i] = values
+		o.emit(Event{Kind: EventBatch, Source: sourceName(s), Duration: time.Since(start)})
+	}
+
+	if o.concurrency > 1 {
+		return o.prefetchConcurrently(keys)
+	}
+	return nil
+}
+
+// set reports the final value of the field read from key to the OnSet hook,
+// and whether it is secret.
+func (o *options) set(key string, value reflect.Value, isDefault, secret bool) {
+	if o.onSet != nil {
+		o.onSet(key, value.Interface(), isDefault, secret)
+	}
+}
+
+// WithSource adds s to the sources Parse reads from. The process environment
+// is consulted first, unless WithNoOSEnv is given, followed by the added
+// sources in the order they were given.
+func WithSource(s Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, s)
+	}
+}
+
+// WithSecretsDir makes Parse look for variables that no source has in the
+// files of dir, named after the variable in lower case, following the Docker
+// Swarm and Kubernetes convention for mounted secrets:
+//
+//	// DB_PASSWORD falls back to the contents of /run/secrets/db_password.
+//	err := env.Parse(&config, env.WithSecretsDir("/run/secrets"))
+//
+// The directories are consulted after all other sources, in the order they
+// were given, and read like DirSource reads them.
+func WithSecretsDir(dir string) Option {
+	return func(o *options) {
+		o.secretsDirs = append(o.secretsDirs, dir)
+	}
+}
+
+// WithNoOSEnv stops Parse from reading the process environment, so only the
+// sources added with WithSource are used. It keeps tests from picking up
+// variables that happen to be set on the machine running them.
+func WithNoOSEnv() Option {
+	return func(o *options) {
+		o.noOSEnv = true
+	}
+}
+
+// WithRequiredIfNoDefault makes every field without a `default` tag require
+// its variable, even if SetDefaults sets the field. It makes a whole config
+// struct strict without annotating each field.
+func WithRequiredIfNoDefault() Option {
+	return func(o *options) {
+		o.requiredIfNoDefault = true
+	}
+}
+
+// WithDeprecationHandler registers fn to be called whenever a field's value is
+// read from one of its `fallback` names instead of its primary name.
+// It is meant for tracking the migration away from legacy variable names,
+// e.g. by logging a warning.
+func WithDeprecationHandler(fn func(oldKey, newKey string)) Option {
+	return func(o *options) {
+		o.onDeprecated = fn
+	}
+}
+
+// WithValidator registers fn to be called with the config once Parse has
+// populated it, so that validation libraries such as go-playground/validator
+// run as part of Parse:
+//
+//	validate := validator.New()
+//	err := env.Parse(&config, env.WithValidator(validate.Struct))
+//
+// Errors returned by fn are joined with any errors Parse found itself.
+// Validators run in the order they were added.
+func WithValidator(fn func(interface{}) error) Option {
+	return func(o *options) {
+		o.validators = append(o.validators, fn)
+	}
+}
+
+// WithOnSet registers fn to be called for every field once its value is
+// resolved, with the name of its variable and the field's value. isDefault is
+// true if the variable was not set and the field kept its default.
+//
+// The values of secret fields, and of values that were decrypted, are
+// passed as the string "[REDACTED]", so that fn can log what it is given.
+func WithOnSet(fn func(key string, value interface{}, isDefault bool)) Option {
+	return func(o *options) {
+		o.onSet = func(key string, value interface{}, isDefault, secret bool) {
+			if secret {
+				value = "[REDACTED]"
+			}
+			fn(key, value, isDefault)
+		}
+	}
+}
+
+// WithConcurrency lets Parse look up to n variables at the same time, for
+// sources backed by slow remote stores. The values are still assigned to the
+// fields one at a time, in declaration order, once all lookups are done.
+// With ParseContext, Parse stops waiting for lookups when its context is
+// done.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithStrictNames makes Parse reject configs reading variables whose names,
+// including the prefixes of nested structs and `fallback` names, are not
+// made of the ASCII upper case letters A-Z, digits and underscores, or start
+// with a digit. Such names are never set in most environments, so a typo
+// such as `env:"db-host"` would otherwise only show up as a missing
+// variable. Sources that serve other names, such as the inputs of a GitHub
+// Action, cannot be used with it.
+func WithStrictNames() Option {
+	return func(o *options) {
+		o.strictNames = true
+	}
+}
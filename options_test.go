@@ -0,0 +1,225 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_Fallback(t *testing.T) {
+	clearenv(t)
+	t.Setenv("LEGACY_PORT", "7070")
+
+	type Config struct {
+		Port int `env:"PORT" fallback:"HTTP_PORT, LEGACY_PORT"`
+	}
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	if config.Port != 7070 {
+		t.Errorf("Expected port 7070 from fallback, got %d", config.Port)
+	}
+}
+
+func TestWithDeprecationHandler(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HTTP_PORT", "7070")
+
+	type Config struct {
+		Port int `env:"PORT" fallback:"HTTP_PORT"`
+	}
+
+	var oldKey, newKey string
+	handler := WithDeprecationHandler(func(o, n string) {
+		oldKey, newKey = o, n
+	})
+
+	var config Config
+	if err := Parse(&config, handler); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	if oldKey != "HTTP_PORT" || newKey != "PORT" {
+		t.Errorf("Expected handler to be called with HTTP_PORT and PORT, got %q and %q", oldKey, newKey)
+	}
+
+	t.Setenv("PORT", "8080")
+	oldKey, newKey = "", ""
+	if err := Parse(&config, handler); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	if oldKey != "" || newKey != "" {
+		t.Errorf("Expected handler not to be called when the primary key is set, got %q and %q", oldKey, newKey)
+	}
+}
+
+func TestWithNoOSEnv(t *testing.T) {
+	t.Setenv("PORT", "8080")
+	t.Setenv("HOST", "from-os")
+
+	var config Config
+	err := Parse(&config, WithNoOSEnv(), WithSource(mapSource{"PORT": "9090"}))
+	if err == nil {
+		t.Error("Expected an error for HOST, which is only set in the OS environment")
+	}
+
+	config = Config{}
+	err = Parse(&config, WithNoOSEnv(), WithSource(mapSource{"PORT": "9090", "HOST": "from-source"}))
+	if err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	expectedConfig := Config{Port: 9090, Host: "from-source"}
+	if config != expectedConfig {
+		t.Errorf("Parsed config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, config)
+	}
+}
+
+func TestWithValidator(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PORT", "8080")
+
+	errPrivileged := errors.New("port must not be privileged")
+	errHost := errors.New("host is required")
+
+	validator := WithValidator(func(v interface{}) error {
+		if v.(*Config).Port < 1024 {
+			return errPrivileged
+		}
+		return nil
+	})
+
+	var config Config
+	err := Parse(&config, WithSource(mapSource{"HOST": "localhost"}), validator)
+	if err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	t.Setenv("PORT", "80")
+
+	config = Config{}
+	err = Parse(&config, validator, WithValidator(func(v interface{}) error {
+		if v.(*Config).Host == "" {
+			return errHost
+		}
+		return nil
+	}))
+	if !errors.Is(err, errPrivileged) || !errors.Is(err, errHost) {
+		t.Errorf("Expected validator errors to be merged into the parse error, got %v", err)
+	}
+}
+
+type tlsConfig struct {
+	Cert string `env:"TLS_CERT"`
+	Key  string `env:"TLS_KEY"`
+}
+
+func (c *tlsConfig) Validate() error {
+	if (c.Cert == "") != (c.Key == "") {
+		return errors.New("TLS_CERT and TLS_KEY must be set together")
+	}
+	return nil
+}
+
+type serverConfig struct {
+	Port int `env:"PORT"`
+	TLS  tlsConfig
+}
+
+func (c serverConfig) Validate() error {
+	if c.TLS.Cert != "" && c.Port == 80 {
+		return errors.New("TLS cannot be served on port 80")
+	}
+	return nil
+}
+
+func TestParse_Validate(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PORT", "80")
+	t.Setenv("TLS_CERT", "cert.pem")
+	t.Setenv("TLS_KEY", "")
+
+	var config serverConfig
+	err := Parse(&config)
+	if err == nil {
+		t.Fatal("Expected Validate errors")
+	}
+
+	for _, want := range []string{"must be set together", "port 80"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %q", want, err)
+		}
+	}
+
+	t.Setenv("PORT", "443")
+	t.Setenv("TLS_KEY", "key.pem")
+
+	config = serverConfig{}
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+}
+
+func TestWithOnSet(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOST", "localhost")
+
+	type set struct {
+		value     interface{}
+		isDefault bool
+	}
+	got := map[string]set{}
+
+	var config presetConfig
+	err := Parse(&config, WithOnSet(func(key string, value interface{}, isDefault bool) {
+		got[key] = set{value, isDefault}
+	}))
+	if err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := map[string]set{
+		"PORT": {8080, true},
+		"HOST": {"localhost", false},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("OnSet calls do not match.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+}
+
+func TestWithOnSet_Secret(t *testing.T) {
+	type Config struct {
+		APIKey   string `env:"API_KEY" secret:"true"`
+		Password string `env:"DB_PASSWORD"`
+		Host     string `env:"HOST"`
+	}
+
+	got := map[string]interface{}{}
+	src := mapSource{"API_KEY": "hunter2", "DB_PASSWORD": "enc:2retnuh", "HOST": "localhost"}
+
+	var config Config
+	err := Parse(&config, WithNoOSEnv(), WithSource(src), WithDecryptor("enc:", reverse),
+		WithOnSet(func(key string, value interface{}, isDefault bool) {
+			got[key] = value
+		}))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"API_KEY":     "[REDACTED]",
+		"DB_PASSWORD": "[REDACTED]",
+		"HOST":        "localhost",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("OnSet calls do not match.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+	if config.APIKey != "hunter2" || config.Password != "hunter2" {
+		t.Errorf("Expected the fields to hold the values in the clear, got %+v", config)
+	}
+}
@@ -0,0 +1,132 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Path is a file system path. When parsed, a leading ~ or ~user is expanded
+// to the home directory of the current or named user, and the result is
+// cleaned with filepath.Clean.
+type Path string
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Path) UnmarshalText(text []byte) error {
+	path, err := expandPath(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = Path(path)
+	return nil
+}
+
+// String returns p as a string.
+func (p Path) String() string {
+	return string(p)
+}
+
+// AbsPath is a Path that is additionally made absolute when parsed, relative
+// to the working directory at that time.
+type AbsPath string
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *AbsPath) UnmarshalText(text []byte) error {
+	path, err := expandPath(string(text))
+	if err != nil {
+		return err
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	*p = AbsPath(path)
+	return nil
+}
+
+// String returns p as a string.
+func (p AbsPath) String() string {
+	return string(p)
+}
+
+// expandPath expands a leading ~ or ~user in path and cleans the result.
+func expandPath(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("empty path")
+	}
+
+	if !strings.HasPrefix(path, "~") {
+		return filepath.Clean(path), nil
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, `/\`); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+
+	var home string
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		home = dir
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		home = u.HomeDir
+	}
+
+	return filepath.Join(home, rest), nil
+}
+
+// PathList is a list of paths separated by os.PathListSeparator, like PATH.
+// Each entry is expanded and cleaned like a Path; empty entries are dropped
+// rather than meaning the working directory.
+type PathList []Path
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *PathList) UnmarshalText(text []byte) error {
+	var list PathList
+	for _, entry := range filepath.SplitList(string(text)) {
+		if entry == "" {
+			continue
+		}
+
+		path, err := expandPath(entry)
+		if err != nil {
+			return err
+		}
+		list = append(list, Path(path))
+	}
+
+	*l = list
+	return nil
+}
+
+// String joins l with os.PathListSeparator.
+func (l PathList) String() string {
+	s := make([]string, len(l))
+	for i, path := range l {
+		s[i] = string(path)
+	}
+	return strings.Join(s, string(os.PathListSeparator))
+}
+
+// Existing returns the entries of l that exist in the file system.
+func (l PathList) Existing() PathList {
+	var list PathList
+	for _, path := range l {
+		if _, err := os.Stat(string(path)); err == nil {
+			list = append(list, path)
+		}
+	}
+	return list
+}
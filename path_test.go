@@ -0,0 +1,83 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse_Path(t *testing.T) {
+	type Config struct {
+		Data  Path    `env:"DATA_DIR"`
+		Cache *Path   `env:"CACHE_DIR"`
+		Logs  AbsPath `env:"LOG_DIR"`
+	}
+
+	clearenv(t)
+	t.Setenv("HOME", "/home/gopher")
+	t.Setenv("DATA_DIR", "~/data/../share/")
+	t.Setenv("CACHE_DIR", "/var//cache")
+	t.Setenv("LOG_DIR", "logs/./app")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if config.Data != "/home/gopher/share" {
+		t.Errorf("Expected ~ to be expanded and the path cleaned, got %q", config.Data)
+	}
+
+	if config.Cache == nil || *config.Cache != "/var/cache" {
+		t.Errorf("Expected pointer path to be set to /var/cache, got %v", config.Cache)
+	}
+
+	wd, _ := os.Getwd()
+	if want := filepath.Join(wd, "logs", "app"); string(config.Logs) != want {
+		t.Errorf("Expected absolute path %q, got %q", want, config.Logs)
+	}
+}
+
+func TestParse_PathEmpty(t *testing.T) {
+	type Config struct {
+		Data Path `env:"DATA_DIR"`
+	}
+
+	t.Setenv("DATA_DIR", "")
+
+	var config Config
+	if err := Parse(&config); err == nil {
+		t.Error("Expected an error for an empty path")
+	}
+}
+
+func TestParse_PathList(t *testing.T) {
+	type Config struct {
+		Plugins PathList `env:"PLUGIN_PATH"`
+	}
+
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	list := dir + string(os.PathListSeparator) + string(os.PathListSeparator) + missing + "/"
+
+	t.Setenv("PLUGIN_PATH", list)
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := PathList{Path(dir), Path(missing)}
+	if !reflect.DeepEqual(config.Plugins, expected) {
+		t.Errorf("Expected %v, got %v", expected, config.Plugins)
+	}
+
+	if existing := config.Plugins.Existing(); !reflect.DeepEqual(existing, PathList{Path(dir)}) {
+		t.Errorf("Expected only %q to exist, got %v", dir, existing)
+	}
+
+	if got := expected.String(); got != dir+string(os.PathListSeparator)+missing {
+		t.Errorf("String returned %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package env
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Proxy holds the conventional proxy variables HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY.
+type Proxy struct {
+	// HTTPProxy is the proxy used for http requests.
+	HTTPProxy string
+	// HTTPSProxy is the proxy used for https requests.
+	HTTPSProxy string
+	// NoProxy is a comma- or space-separated list of hosts that are
+	// reached directly. See Bypass for the accepted forms.
+	NoProxy string
+}
+
+// GetProxy returns the proxy configuration of the environment. Like net/http,
+// it prefers the upper case variables and falls back to their lower case
+// spelling (http_proxy, https_proxy, no_proxy), which many tools use instead.
+func GetProxy() Proxy {
+	return Proxy{
+		HTTPProxy:  getAny("HTTP_PROXY", "http_proxy"),
+		HTTPSProxy: getAny("HTTPS_PROXY", "https_proxy"),
+		NoProxy:    getAny("NO_PROXY", "no_proxy"),
+	}
+}
+
+func getAny(keys ...string) string {
+	for _, key := range keys {
+		if value, ok := GetString(key); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Bypass reports whether requests to host, optionally with a port, should not
+// go through a proxy. Loopback addresses and localhost are never proxied.
+// Otherwise host is matched against the entries of NoProxy, which may be:
+//
+//   - "*", matching every host
+//   - an IP address, or an IP range in CIDR notation
+//   - a domain name, matching the domain and all of its subdomains; a
+//     leading "." or "*." is accepted and means the same
+//   - an IP address or domain name followed by ":port", matching only
+//     that port
+func (p Proxy) Bypass(host string) bool {
+	hostname, port := splitHostPort(host)
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if hostname == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(hostname)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	entries := strings.FieldsFunc(p.NoProxy, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	for _, entry := range entries {
+		entry = strings.ToLower(entry)
+		if entry == "*" {
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := splitHostPort(entry)
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && ip.Equal(entryIP) {
+				return true
+			}
+			continue
+		}
+
+		domain := strings.TrimPrefix(strings.TrimPrefix(entryHost, "*"), ".")
+		if hostname == domain || strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// URL returns the proxy to use for a request to u, or nil if the request
+// should be made directly. Proxy addresses without a scheme are assumed to be
+// http proxies.
+func (p Proxy) URL(u *url.URL) (*url.URL, error) {
+	var proxy string
+	switch u.Scheme {
+	case "http":
+		proxy = p.HTTPProxy
+	case "https":
+		proxy = p.HTTPSProxy
+	}
+
+	if proxy == "" || p.Bypass(u.Host) {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		if proxyURL, err := url.Parse("http://" + proxy); err == nil {
+			return proxyURL, nil
+		}
+	}
+	if err != nil {
+		return nil, errors.New("invalid proxy address: " + strconv.Quote(proxy))
+	}
+
+	return proxyURL, nil
+}
+
+// Func returns p as a function for the Proxy field of http.Transport.
+func (p Proxy) Func() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		return p.URL(req.URL)
+	}
+}
+
+// splitHostPort splits host into a host name and port, accepting hosts
+// without a port.
+func splitHostPort(host string) (string, string) {
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		return h, port
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), ""
+}
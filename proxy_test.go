@@ -0,0 +1,81 @@
+package env
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestGetProxy(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HTTP_PROXY", "http://proxy:3128")
+	t.Setenv("http_proxy", "http://ignored:3128")
+	t.Setenv("https_proxy", "proxy:3129")
+	t.Setenv("no_proxy", ".internal")
+
+	expected := Proxy{HTTPProxy: "http://proxy:3128", HTTPSProxy: "proxy:3129", NoProxy: ".internal"}
+	if got := GetProxy(); got != expected {
+		t.Errorf("GetProxy does not match.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+}
+
+func TestProxy_Bypass(t *testing.T) {
+	p := Proxy{NoProxy: "example.com, .corp.net *.dev.local,10.0.0.0/8 192.168.1.1 api.test:8443"}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost:8080", true},
+		{"127.0.0.1", true},
+		{"[::1]:443", true},
+		{"example.com", true},
+		{"www.example.com:443", true},
+		{"notexample.com", false},
+		{"corp.net", true},
+		{"git.corp.net", true},
+		{"a.dev.local", true},
+		{"10.1.2.3:80", true},
+		{"11.1.2.3", false},
+		{"192.168.1.1", true},
+		{"api.test:8443", true},
+		{"api.test:443", false},
+		{"golang.org", false},
+	}
+
+	for _, tt := range tests {
+		if got := p.Bypass(tt.host); got != tt.want {
+			t.Errorf("Bypass(%q) = %v, expected %v", tt.host, got, tt.want)
+		}
+	}
+
+	if !(Proxy{NoProxy: "*"}).Bypass("golang.org") {
+		t.Error("Expected * to bypass every host")
+	}
+}
+
+func TestProxy_URL(t *testing.T) {
+	p := Proxy{HTTPProxy: "http://proxy:3128", HTTPSProxy: "secure-proxy:3129", NoProxy: "internal"}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"http://golang.org/", "http://proxy:3128"},
+		{"https://golang.org/", "http://secure-proxy:3129"},
+		{"https://api.internal/", ""},
+		{"ftp://golang.org/", ""},
+	}
+
+	for _, tt := range tests {
+		target, _ := url.Parse(tt.target)
+		got, err := p.URL(target)
+		if err != nil {
+			t.Errorf("URL(%q) returned error: %v", tt.target, err)
+			continue
+		}
+
+		if (got == nil && tt.want != "") || (got != nil && got.String() != tt.want) {
+			t.Errorf("URL(%q) = %v, expected %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package env
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot is where the cgroup file system of the process is mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// CPUs returns the number of CPUs the process can use: GOMAXPROCS, lowered to
+// the CPU quota of its cgroup, rounded up, if that is smaller. It is meant
+// for computing defaults such as worker counts in SetDefaults:
+//
+//	func (c *Config) SetDefaults() {
+//		c.Workers = env.CPUs()
+//	}
+func CPUs() int {
+	n := runtime.GOMAXPROCS(0)
+
+	if quota, ok := cgroupCPUQuota(); ok {
+		if q := int(math.Ceil(quota)); q < n {
+			n = q
+		}
+	}
+
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// MemoryLimit returns the memory limit of the process in bytes: the smaller
+// of the memory limit of its cgroup and the Go runtime's soft limit set with
+// GOMEMLIMIT. It reports false if neither is set. Like CPUs, it is meant for
+// computing defaults, such as cache sizes, in SetDefaults.
+func MemoryLimit() (int64, bool) {
+	limit, ok := cgroupMemoryLimit()
+
+	if soft := debug.SetMemoryLimit(-1); soft != math.MaxInt64 && (!ok || soft < limit) {
+		limit, ok = soft, true
+	}
+
+	return limit, ok
+}
+
+// cgroupCPUQuota returns the CPU quota of the cgroup in CPUs, reading the
+// cgroup v2 interface first and falling back to v1.
+func cgroupCPUQuota() (float64, bool) {
+	if fields := strings.Fields(readCgroup("cpu.max")); len(fields) == 2 {
+		if fields[0] == "max" {
+			return 0, false
+		}
+		return quotaRatio(fields[0], fields[1])
+	}
+
+	quota := readCgroup(filepath.Join("cpu", "cpu.cfs_quota_us"))
+	period := readCgroup(filepath.Join("cpu", "cpu.cfs_period_us"))
+	if quota == "" || period == "" || strings.HasPrefix(quota, "-") {
+		return 0, false
+	}
+	return quotaRatio(quota, period)
+}
+
+func quotaRatio(quota, period string) (float64, bool) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+
+	return q / p, true
+}
+
+// cgroupMemoryLimit returns the memory limit of the cgroup in bytes, reading
+// the cgroup v2 interface first and falling back to v1.
+func cgroupMemoryLimit() (int64, bool) {
+	value := readCgroup("memory.max")
+	if value == "" {
+		value = readCgroup(filepath.Join("memory", "memory.limit_in_bytes"))
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		return 0, false
+	}
+
+	// cgroup v1 reports "no limit" as the largest page-aligned int64.
+	if limit >= math.MaxInt64&^(1<<12-1) {
+		return 0, false
+	}
+
+	return limit, true
+}
+
+func readCgroup(name string) string {
+	b, err := os.ReadFile(filepath.Join(cgroupRoot, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
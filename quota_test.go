@@ -0,0 +1,72 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func withCgroup(t *testing.T, files map[string]string) {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content+"\n"), 0o644)
+	}
+
+	old := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = old })
+}
+
+func TestCPUs(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  int
+	}{
+		{"no cgroup", nil, 8},
+		{"v2 unlimited", map[string]string{"cpu.max": "max 100000"}, 8},
+		{"v2 quota", map[string]string{"cpu.max": "150000 100000"}, 2},
+		{"v2 quota above GOMAXPROCS", map[string]string{"cpu.max": "1600000 100000"}, 8},
+		{"v1 quota", map[string]string{"cpu/cpu.cfs_quota_us": "50000", "cpu/cpu.cfs_period_us": "100000"}, 1},
+		{"v1 unlimited", map[string]string{"cpu/cpu.cfs_quota_us": "-1", "cpu/cpu.cfs_period_us": "100000"}, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCgroup(t, tt.files)
+			if got := CPUs(); got != tt.want {
+				t.Errorf("CPUs() = %d, expected %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		want   int64
+		wantOK bool
+	}{
+		{"no cgroup", nil, 0, false},
+		{"v2 unlimited", map[string]string{"memory.max": "max"}, 0, false},
+		{"v2 limit", map[string]string{"memory.max": "536870912"}, 536870912, true},
+		{"v1 limit", map[string]string{"memory/memory.limit_in_bytes": "268435456"}, 268435456, true},
+		{"v1 unlimited", map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCgroup(t, tt.files)
+			got, ok := MemoryLimit()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("MemoryLimit() = %d, %v, expected %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package env
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Readiness reports whether a config has been parsed successfully, for
+// readiness probes that should keep traffic away until it has:
+//
+//	var ready env.Readiness
+//	http.Handle("/readyz", &ready)
+//	go serve()
+//
+//	err := env.Parse(&config, env.WithSource(remote), env.WithReadiness(&ready))
+//
+// A Readiness becomes ready the first time a Parse given it with
+// WithReadiness succeeds, and stays ready after that, even if later parses,
+// such as reloads, fail. The zero value is a Readiness that is not ready yet.
+//
+// A Readiness is safe for concurrent use.
+type Readiness struct {
+	init  sync.Once
+	ready sync.Once
+	done  chan struct{}
+}
+
+// WithReadiness makes a successful Parse mark r as ready.
+func WithReadiness(r *Readiness) Option {
+	return func(o *options) {
+		o.readiness = r
+	}
+}
+
+// Ready reports whether r is ready.
+func (r *Readiness) Ready() bool {
+	select {
+	case <-r.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Done returns a channel that is closed once r is ready.
+func (r *Readiness) Done() <-chan struct{} {
+	r.init.Do(func() {
+		r.done = make(chan struct{})
+	})
+	return r.done
+}
+
+// ServeHTTP implements http.Handler, responding with status 200 once r is
+// ready and 503 until then.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !r.Ready() {
+		http.Error(w, "config not loaded", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+func (r *Readiness) markReady() {
+	r.Done()
+	r.ready.Do(func() {
+		close(r.done)
+	})
+}
@@ -0,0 +1,62 @@
+package env
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithReadiness(t *testing.T) {
+	var ready Readiness
+	if ready.Ready() {
+		t.Fatal("Expected a new Readiness not to be ready")
+	}
+
+	type config struct {
+		Port int `env:"PORT"`
+	}
+	var c config
+
+	err := Parse(&c, WithNoOSEnv(), WithSource(mapSource{"PORT": "http"}), WithReadiness(&ready))
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	if ready.Ready() {
+		t.Error("Expected a failed Parse not to make it ready")
+	}
+
+	if err := Parse(&c, WithNoOSEnv(), WithSource(mapSource{"PORT": "8080"}), WithReadiness(&ready)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if !ready.Ready() {
+		t.Error("Expected a successful Parse to make it ready")
+	}
+	select {
+	case <-ready.Done():
+	default:
+		t.Error("Expected Done to be closed")
+	}
+
+	Parse(&c, WithNoOSEnv(), WithSource(mapSource{"PORT": "http"}), WithReadiness(&ready))
+	if !ready.Ready() {
+		t.Error("Expected a later failed Parse to keep it ready")
+	}
+}
+
+func TestReadiness_ServeHTTP(t *testing.T) {
+	var ready Readiness
+
+	rec := httptest.NewRecorder()
+	ready.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	ready.markReady()
+
+	rec = httptest.NewRecorder()
+	ready.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,149 @@
+package env
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Recording is a single lookup captured by a Recorder.
+type Recording struct {
+	Key      string `json:"key"`
+	Value    string `json:"value,omitempty"`
+	Present  bool   `json:"present"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// Recorder is a Source that passes lookups through to another source and
+// remembers every key that was asked for, so that the configuration a
+// program actually saw can be saved and replayed elsewhere with Replay.
+//
+// Values of keys for which the redact function returns true are never
+// stored; only the fact that they were present is.
+//
+// To record the process environment, read it only through the Recorder:
+//
+//	rec := env.NewRecorder(env.OS(), isSecret)
+//	err := env.Parse(&config, env.WithNoOSEnv(), env.WithSource(rec))
+type Recorder struct {
+	src    Source
+	redact func(key string) bool
+
+	mu         sync.Mutex
+	recordings map[string]Recording
+}
+
+// NewRecorder returns a Recorder reading from src. If redact is nil, all
+// values are recorded.
+func NewRecorder(src Source, redact func(key string) bool) *Recorder {
+	return &Recorder{
+		src:        src,
+		redact:     redact,
+		recordings: make(map[string]Recording),
+	}
+}
+
+// Lookup looks key up in the underlying source and records the result.
+func (r *Recorder) Lookup(key string) (string, bool) {
+	value, ok := r.src.Lookup(key)
+
+	rec := Recording{Key: key, Present: ok}
+	if ok {
+		if r.redact != nil && r.redact(key) {
+			rec.Redacted = true
+		} else {
+			rec.Value = value
+		}
+	}
+
+	r.mu.Lock()
+	r.recordings[key] = rec
+	r.mu.Unlock()
+
+	return value, ok
+}
+
+// Recordings returns the lookups recorded so far, sorted by key.
+func (r *Recorder) Recordings() []Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recs := make([]Recording, 0, len(r.recordings))
+	for _, rec := range r.recordings {
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].Key < recs[j].Key
+	})
+
+	return recs
+}
+
+// WriteTo writes the recorded lookups to w as JSON.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.MarshalIndent(r.Recordings(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// Save writes the recorded lookups to the file at path.
+func (r *Recorder) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Replay returns a Source serving the lookups recorded by a Recorder and
+// read from rd. Keys that were missing or redacted when recording are
+// reported as missing, so they have to be supplied by another source.
+func Replay(rd io.Reader) (Source, error) {
+	var recs []Recording
+	if err := json.NewDecoder(rd).Decode(&recs); err != nil {
+		return nil, err
+	}
+
+	s := make(replaySource, len(recs))
+	for _, rec := range recs {
+		if rec.Present && !rec.Redacted {
+			s[rec.Key] = rec.Value
+		}
+	}
+
+	return s, nil
+}
+
+// ReplayFile is like Replay but reads the recording from the file at path.
+func ReplayFile(path string) (Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Replay(f)
+}
+
+type replaySource map[string]string
+
+func (s replaySource) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+func (replaySource) String() string {
+	return "replay"
+}
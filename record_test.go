@@ -0,0 +1,59 @@
+package env
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	clearenv(t)
+
+	type Config struct {
+		Host     string `env:"REC_HOST"`
+		Password string `env:"REC_PASSWORD"`
+		Port     int    `env:"REC_PORT" fallback:"REC_LEGACY_PORT"`
+	}
+
+	src := mapSource{"REC_HOST": "db.internal", "REC_PASSWORD": "hunter2", "REC_LEGACY_PORT": "5432"}
+	rec := NewRecorder(src, func(key string) bool {
+		return key == "REC_PASSWORD"
+	})
+
+	var config Config
+	if err := Parse(&config, WithSource(rec)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := []Recording{
+		{Key: "REC_HOST", Value: "db.internal", Present: true},
+		{Key: "REC_LEGACY_PORT", Value: "5432", Present: true},
+		{Key: "REC_PASSWORD", Present: true, Redacted: true},
+		{Key: "REC_PORT"},
+	}
+	if got := rec.Recordings(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Recordings do not match.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Failed to save recording: %v", err)
+	}
+
+	replay, err := ReplayFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load recording: %v", err)
+	}
+
+	t.Setenv("REC_PASSWORD", "local")
+
+	var replayed Config
+	if err := Parse(&replayed, WithSource(replay)); err != nil {
+		t.Fatalf("Failed to parse replayed environment: %v", err)
+	}
+
+	expectedConfig := Config{Host: "db.internal", Password: "local", Port: 5432}
+	if replayed != expectedConfig {
+		t.Errorf("Replayed config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, replayed)
+	}
+}
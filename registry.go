@@ -0,0 +1,117 @@
+package env
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+var registry struct {
+	sync.Mutex
+	modules []Module
+}
+
+// Module is a config registered with Register.
+type Module struct {
+	Name   string
+	Config interface{}
+}
+
+// Register registers config, a pointer to a struct, under name, so that
+// independently developed packages can declare their configuration and have
+// it loaded by a single call to LoadAll in main, and documented together by
+// UsageAll and DocAll:
+//
+//	var Config HTTPConfig
+//
+//	func init() {
+//		env.Register("http", &Config)
+//	}
+//
+// Register panics if name is registered twice or config is not a non-nil
+// pointer to a struct.
+func Register(name string, config interface{}) {
+	if err := checkTarget(config); err != nil {
+		panic(errors.New("env: Register " + name + ": " + err.Error()))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, m := range registry.modules {
+		if m.Name == name {
+			panic(errors.New("env: Register called twice for " + name))
+		}
+	}
+	registry.modules = append(registry.modules, Module{Name: name, Config: config})
+}
+
+// Registered returns the registered configs in the order they were
+// registered, for tools that document or report on them.
+func Registered() []Module {
+	registry.Lock()
+	defer registry.Unlock()
+	return append([]Module(nil), registry.modules...)
+}
+
+// LoadAll parses all registered configs with opts, like ParseAll: variables
+// read by more than one config are reported, sources are queried once, and
+// the errors of all configs are returned together, each prefixed with the
+// name its config was registered under.
+func LoadAll(opts ...Option) error {
+	modules := Registered()
+
+	configs := make([]interface{}, len(modules))
+	names := make(map[interface{}]string, len(modules))
+	for i, m := range modules {
+		configs[i] = m.Config
+		names[m.Config] = m.Name
+	}
+
+	o := newOptions(opts)
+	o.names = names
+	return parseWith(o, configs...)
+}
+
+// UsageAll returns the Usage of every registered config, under the name it
+// was registered with:
+//
+//	http:
+//	  HTTP_PORT int (required)
+//
+//	db:
+//	  DB_DSN string (required)
+//
+// UsageAll panics if a registered config is invalid, as Usage does.
+func UsageAll() string {
+	var b strings.Builder
+	for i, m := range Registered() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(m.Name + ":\n")
+		if err := WriteUsage(&b, m.Config); err != nil {
+			panic("env: " + m.Name + ": " + err.Error())
+		}
+	}
+	return b.String()
+}
+
+// DocAll writes the Doc of every registered config to w, each under a
+// Markdown heading naming it.
+func DocAll(w io.Writer) error {
+	for i, m := range Registered() {
+		heading := "## " + m.Name + "\n\n"
+		if i > 0 {
+			heading = "\n" + heading
+		}
+		if _, err := io.WriteString(w, heading); err != nil {
+			return err
+		}
+		if err := Doc(m.Config, w); err != nil {
+			return errors.New(m.Name + ": " + err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+// resetRegistry empties the registry for the duration of a test.
+func resetRegistry(t *testing.T) {
+	registry.Lock()
+	saved := registry.modules
+	registry.modules = nil
+	registry.Unlock()
+
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.modules = saved
+		registry.Unlock()
+	})
+}
+
+func TestLoadAll(t *testing.T) {
+	resetRegistry(t)
+
+	type HTTPConfig struct {
+		Port int `env:"HTTP_PORT"`
+	}
+	type DBConfig struct {
+		DSN  string `env:"DB_DSN"`
+		Pool int    `env:"DB_POOL" min:"1"`
+	}
+
+	var http HTTPConfig
+	var db DBConfig
+	Register("http", &http)
+	Register("db", &db)
+
+	src := mapSource{"HTTP_PORT": "8080", "DB_DSN": "postgres://db", "DB_POOL": "4"}
+	if err := LoadAll(WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to load configs: %v", err)
+	}
+
+	if http.Port != 8080 || db.DSN != "postgres://db" || db.Pool != 4 {
+		t.Errorf("Unexpected configs %+v, %+v", http, db)
+	}
+
+	modules := Registered()
+	if len(modules) != 2 || modules[0].Name != "http" || modules[1].Name != "db" {
+		t.Errorf("Unexpected modules %+v", modules)
+	}
+
+	src["HTTP_PORT"] = "x"
+	src["DB_POOL"] = "0"
+	err := LoadAll(WithNoOSEnv(), WithSource(src))
+	if err == nil {
+		t.Fatal("Expected errors for both configs")
+	}
+	expected := "http: invalid value for environment variable: HTTP_PORT\n" +
+		"db: invalid value for environment variable: DB_POOL: 0 is less than the minimum 1"
+	if err.Error() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, err)
+	}
+}
+
+func TestUsageAll(t *testing.T) {
+	resetRegistry(t)
+
+	type HTTPConfig struct {
+		Port int `env:"HTTP_PORT" default:"8080"`
+	}
+	type DBConfig struct {
+		DSN string `env:"DB_DSN" desc:"Database to connect to"`
+	}
+	Register("http", &HTTPConfig{})
+	Register("db", &DBConfig{})
+
+	expected := `http:
+  HTTP_PORT int
+    	(default "8080")
+
+db:
+  DB_DSN string (required)
+    	Database to connect to
+`
+	if usage := UsageAll(); usage != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, usage)
+	}
+
+	var b strings.Builder
+	if err := DocAll(&b); err != nil {
+		t.Fatalf("Failed to write docs: %v", err)
+	}
+	expected = "## http\n\n" +
+		"| Name | Type | Default | Required | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `HTTP_PORT` | `int` | `8080` | no |  |\n" +
+		"\n## db\n\n" +
+		"| Name | Type | Default | Required | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `DB_DSN` | `string` |  | yes | Database to connect to |\n"
+	if doc := b.String(); doc != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, doc)
+	}
+}
+
+func TestRegister_Panics(t *testing.T) {
+	resetRegistry(t)
+
+	type Config struct {
+		Port int `env:"PORT"`
+	}
+
+	Register("http", &Config{})
+
+	for name, config := range map[string]interface{}{"http": &Config{}, "other": Config{}} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.HasPrefix(r.(error).Error(), "env: Register") {
+					t.Errorf("Expected Register(%q) to panic, got %v", name, r)
+				}
+			}()
+			Register(name, config)
+		}()
+	}
+}
@@ -0,0 +1,511 @@
+package env
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reloadable holds a config of type T, a struct, that can be parsed again
+// while the program runs, for example to pick up rotated secrets. Readers
+// get consistent copies with Get and are told which variables changed
+// through OnChange.
+//
+// A Reloadable is safe for concurrent use.
+type Reloadable[T any] struct {
+	opts []Option
+
+	// reloading serializes Reload and field refreshes.
+	reloading sync.Mutex
+
+	mu     sync.RWMutex
+	config T
+	values map[string]interface{}
+	// secrets holds the keys of the values that are secret.
+	secrets  map[string]bool
+	ttl      time.Duration
+	onChange []func(config T, changed []string)
+	// reloaded is closed, and cleared, by the next successful reload.
+	reloaded chan struct{}
+}
+
+// NewReloadable parses a T with opts, like Parse, and returns a Reloadable
+// holding it.
+func NewReloadable[T any](opts ...Option) (*Reloadable[T], error) {
+	r := &Reloadable[T]{opts: opts}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get returns the current config.
+func (r *Reloadable[T]) Get() T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config
+}
+
+// OnChange registers fn to be called after a reload that changed the value
+// of any field, with the new config and the sorted names of the variables
+// whose values changed.
+func (r *Reloadable[T]) OnChange(fn func(config T, changed []string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onChange = append(r.onChange, fn)
+}
+
+// Reload parses a new config. If that fails, the current config is kept
+// and the error is returned.
+func (r *Reloadable[T]) Reload() error {
+	_, _, err := r.reload()
+	return err
+}
+
+// valueChange is the old and new value of a variable changed by a reload,
+// as reported by ReloadHandler. A value is nil if the variable had none.
+type valueChange struct {
+	Old *string `json:"old"`
+	New *string `json:"new"`
+}
+
+// reload is Reload, returning the sorted names of the variables whose values
+// changed and their old and new values, with secrets redacted.
+func (r *Reloadable[T]) reload() ([]string, map[string]valueChange, error) {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+
+	var config T
+	values := make(map[string]interface{})
+	secrets := make(map[string]bool)
+	var ttl time.Duration
+
+	o := newOptions(r.opts)
+	onSet := o.onSet
+	o.onSet = func(key string, value interface{}, isDefault, secret bool) {
+		values[key] = value
+		secrets[key] = secret
+		if onSet != nil {
+			onSet(key, value, isDefault, secret)
+		}
+	}
+	o.onLease = func(key string, leased time.Duration) {
+		if ttl == 0 || leased < ttl {
+			ttl = leased
+		}
+	}
+
+	if err := parseWith(o, &config); err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	first := r.values == nil
+	changed := diff(r.values, values)
+	changes := make(map[string]valueChange, len(changed))
+	for _, key := range changed {
+		secret := secrets[key] || r.secrets[key]
+		changes[key] = valueChange{
+			Old: displayValue(r.values, key, secret),
+			New: displayValue(values, key, secret),
+		}
+	}
+	r.config, r.values, r.secrets, r.ttl = config, values, secrets, ttl
+	if r.reloaded != nil {
+		close(r.reloaded)
+		r.reloaded = nil
+	}
+	r.mu.Unlock()
+
+	if !first {
+		r.notify(config, changed)
+	}
+	return changed, changes, nil
+}
+
+// displayValue returns the value of key in values as ReloadHandler shows
+// it, or nil if there is none.
+func displayValue(values map[string]interface{}, key string, secret bool) *string {
+	value, ok := values[key]
+	if !ok {
+		return nil
+	}
+	s := "[REDACTED]"
+	if !secret {
+		s = ""
+		if v := reflect.Indirect(reflect.ValueOf(value)); v.IsValid() {
+			s = fmt.Sprint(v.Interface())
+		}
+	}
+	return &s
+}
+
+// ReloadHandler returns an http.Handler that reloads the config on POST
+// requests carrying token as a bearer token, so that operators can apply
+// changes to the environment or mounted files without sending signals:
+//
+//	http.Handle("/admin/config/reload", r.ReloadHandler(adminToken))
+//
+//	$ curl -X POST -H "Authorization: Bearer $TOKEN" .../admin/config/reload
+//	{"changed":["LOG_LEVEL"],"diff":{"LOG_LEVEL":{"old":"info","new":"debug"}}}
+//
+// A successful reload responds with the names of the variables that
+// changed and their old and new values; the values of secrets are shown as
+// "[REDACTED]". A failed reload responds with status 422 and the error, such
+// as a validation failure, which does not quote secret values, and keeps
+// the current config. Requests without the
+// token get status 401. ReloadHandler panics if token is empty.
+func (r *Reloadable[T]) ReloadHandler(token string) http.Handler {
+	if token == "" {
+		panic("env: ReloadHandler requires a token")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var resp struct {
+			Changed []string               `json:"changed"`
+			Diff    map[string]valueChange `json:"diff,omitempty"`
+			Error   string                 `json:"error,omitempty"`
+		}
+
+		status := http.StatusOK
+		changed, changes, err := r.reload()
+		if err != nil {
+			status, resp.Error = http.StatusUnprocessableEntity, err.Error()
+		}
+		resp.Changed, resp.Diff = append([]string{}, changed...), changes
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// notify calls the OnChange functions if any variables changed.
+func (r *Reloadable[T]) notify(config T, changed []string) {
+	if len(changed) == 0 {
+		return
+	}
+
+	r.mu.RLock()
+	onChange := r.onChange
+	r.mu.RUnlock()
+
+	for _, fn := range onChange {
+		fn(config, changed)
+	}
+}
+
+// minLeaseWait and maxLeaseRetry bound the waits of RefreshLeases, so that
+// leases that are about to end, or have ended, do not make it spin.
+var (
+	minLeaseWait  = time.Second
+	maxLeaseRetry = 5 * time.Minute
+)
+
+// RefreshLeases reloads the config before the shortest lease of the values
+// read from a Leaser ends, after two thirds of its ttl but no sooner than a
+// second, until ctx is done. Failed reloads are reported to onError, if it
+// is not nil, and retried after a tenth of the ttl, doubling with every
+// failure up to five minutes. Reloads made by other means, such as Reload
+// or WatchSources, reschedule the refresh from the leases they read, so
+// that it also starts once a config without leases gains some.
+// RefreshLeases returns ctx.Err().
+func (r *Reloadable[T]) RefreshLeases(ctx context.Context, onError func(error)) error {
+	failures := 0
+	for {
+		r.mu.Lock()
+		ttl := r.ttl
+		if r.reloaded == nil {
+			r.reloaded = make(chan struct{})
+		}
+		reloaded := r.reloaded
+		r.mu.Unlock()
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if ttl > 0 {
+			timer = time.NewTimer(leaseWait(ttl, failures))
+			expired = timer.C
+		}
+
+		due := false
+		select {
+		case <-ctx.Done():
+		case <-reloaded:
+			failures = 0
+		case <-expired:
+			due = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !due {
+			continue
+		}
+
+		if err := r.Reload(); err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			failures++
+			continue
+		}
+		failures = 0
+	}
+}
+
+// leaseWait returns how long RefreshLeases waits for a lease of ttl after
+// failures failed reloads.
+func leaseWait(ttl time.Duration, failures int) time.Duration {
+	wait := ttl * 2 / 3
+	if failures > 0 {
+		wait = ttl / 10
+		for i := 1; i < failures && wait < maxLeaseRetry; i++ {
+			wait *= 2
+		}
+		if wait > maxLeaseRetry {
+			wait = maxLeaseRetry
+		}
+	}
+	if wait < minLeaseWait {
+		wait = minLeaseWait
+	}
+	return wait
+}
+
+// WatchSources reloads the config whenever one of the sources it is parsed
+// from that implements Watcher reports a change, until ctx is done. The
+// sources held by a Chain or Resolver are watched too; those added to a
+// Resolver after WatchSources is called are not. Errors
+// returned by Watch and failed reloads are reported to onError, if it is
+// not nil; a source whose Watch fails is no longer watched. WatchSources
+// returns ctx.Err() once all the sources have stopped.
+func (r *Reloadable[T]) WatchSources(ctx context.Context, onError func(error)) error {
+	watchers := watchersIn(newOptions(r.opts).sources)
+
+	changed := make(chan struct{}, 1)
+	errs := make(chan error)
+	var wg sync.WaitGroup
+	for _, w := range watchers {
+		wg.Add(1)
+		go func(w Watcher) {
+			defer wg.Done()
+			err := w.Watch(ctx, func() {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				select {
+				case errs <- errors.New(sourceName(w) + ": " + err.Error()):
+				case <-ctx.Done():
+				}
+			}
+		}(w)
+	}
+	defer wg.Wait()
+
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			report(err)
+		case <-changed:
+			if err := r.Reload(); err != nil {
+				report(err)
+			}
+		}
+	}
+}
+
+// RefreshFields re-resolves the fields with a `refresh` tag, each at the
+// interval given by its tag, until ctx is done:
+//
+//	type Config struct {
+//	  Token string `env:"API_TOKEN" refresh:"5m"`
+//	}
+//
+// Only the refreshed fields are read again; the rest of the config is left
+// as it is, and SetDefaults is not called. A field whose variable has gone
+// away falls back to its `default` tag. The Validate methods and validators
+// still run on the updated config. Failed refreshes are reported to onError,
+// if it is not nil, and keep the current config. RefreshFields returns
+// ctx.Err().
+func (r *Reloadable[T]) RefreshFields(ctx context.Context, onError func(error)) error {
+	var fields []fieldInfo
+	var intervals []time.Duration
+	for _, f := range fieldsOf(reflect.TypeOf(r.Get())) {
+		if refresh := f.field.Tag.Get("refresh"); refresh != "" {
+			d, _ := parseDuration(refresh) // checked by Parse
+			fields = append(fields, f)
+			intervals = append(intervals, d)
+		}
+	}
+
+	if len(fields) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	due := make([]time.Time, len(fields))
+	for i := range fields {
+		due[i] = now.Add(intervals[i])
+	}
+
+	for {
+		next := due[0]
+		for _, t := range due[1:] {
+			if t.Before(next) {
+				next = t
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		var refresh []fieldInfo
+		for i, f := range fields {
+			if !due[i].After(now) {
+				refresh = append(refresh, f)
+				due[i] = now.Add(intervals[i])
+			}
+		}
+
+		if err := r.refresh(refresh); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// refresh re-resolves fields in a copy of the config and, if that succeeds,
+// makes the copy current.
+func (r *Reloadable[T]) refresh(fields []fieldInfo) error {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+
+	config := r.Get()
+	values := make(map[string]interface{})
+	secrets := make(map[string]bool)
+
+	o := newOptions(r.opts)
+	onSet := o.onSet
+	o.onSet = func(key string, value interface{}, isDefault, secret bool) {
+		values[key] = value
+		secrets[key] = secret
+		if onSet != nil {
+			onSet(key, value, isDefault, secret)
+		}
+	}
+
+	if err := o.prefetch(lookupKeys(fields)); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(&config).Elem()
+	for _, f := range fields {
+		value := v.FieldByIndex(f.index)
+		// Start from the zero value so that pointers and maps shared with
+		// the current config are not written through.
+		value.Set(reflect.Zero(value.Type()))
+		if err := resolve(f, value, o); err != nil {
+			return err
+		}
+	}
+
+	if err := validateConfig(&config, o); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	var changed []string
+	for key, value := range values {
+		if !equalValues(r.values[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+
+	merged := make(map[string]interface{}, len(r.values))
+	for key, value := range r.values {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+		r.secrets[key] = secrets[key]
+	}
+	r.config, r.values = config, merged
+	r.mu.Unlock()
+
+	r.notify(config, changed)
+	return nil
+}
+
+// diff returns the sorted keys whose values differ between old and new.
+func diff(old, new map[string]interface{}) []string {
+	var changed []string
+	for key, value := range new {
+		if prev, ok := old[key]; !ok || !equalValues(prev, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// equalValues reports whether two field values are equal. Secrets are
+// compared by value, as their masks differ every time they are read.
+func equalValues(a, b interface{}) bool {
+	sa, ok := a.(Secret)
+	sb, ok2 := b.(Secret)
+	if !ok || !ok2 {
+		return reflect.DeepEqual(a, b)
+	}
+
+	va, erra := sa.peek()
+	vb, errb := sb.peek()
+	return erra == nil && errb == nil && string(va) == string(vb)
+}
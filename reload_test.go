@@ -0,0 +1,422 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// leaseSource is a Leaser whose values all share one ttl.
+type leaseSource struct {
+	mu      sync.Mutex
+	values  map[string]string
+	ttl     time.Duration
+	lookups int
+}
+
+func (s *leaseSource) Lookup(key string) (string, bool) {
+	value, _, ok := s.LookupLease(key)
+	return value, ok
+}
+
+func (s *leaseSource) LookupLease(key string) (string, time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	value, ok := s.values[key]
+	return value, s.ttl, ok
+}
+
+func (s *leaseSource) setTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+}
+
+func (s *leaseSource) lookupCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookups
+}
+
+// shortLeaseWait lowers the minimum wait of RefreshLeases for the duration
+// of a test.
+func shortLeaseWait(t *testing.T, d time.Duration) {
+	prev := minLeaseWait
+	minLeaseWait = d
+	t.Cleanup(func() { minLeaseWait = prev })
+}
+
+func (s *leaseSource) set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+func TestReloadable(t *testing.T) {
+	type Config struct {
+		User     string `env:"DB_USER"`
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	src := mapSource{"DB_USER": "app", "DB_PASSWORD": "one"}
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(src))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	var changed []string
+	r.OnChange(func(config Config, keys []string) {
+		changed = keys
+	})
+
+	src["DB_PASSWORD"] = "two"
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	if expected := (Config{User: "app", Password: "two"}); r.Get() != expected {
+		t.Errorf("Expected %+v, got %+v", expected, r.Get())
+	}
+	if expected := []string{"DB_PASSWORD"}; !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected changed keys %v, got %v", expected, changed)
+	}
+
+	delete(src, "DB_USER")
+	if err := r.Reload(); err == nil {
+		t.Error("Expected an error for a missing variable")
+	}
+	if r.Get().User != "app" {
+		t.Error("Expected a failed reload to keep the current config")
+	}
+}
+
+func TestReloadable_RefreshLeases(t *testing.T) {
+	shortLeaseWait(t, time.Millisecond)
+
+	type Config struct {
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	src := &leaseSource{values: map[string]string{"DB_PASSWORD": "one"}, ttl: 30 * time.Millisecond}
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(src))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	updated := make(chan Config, 1)
+	r.OnChange(func(config Config, _ []string) {
+		select {
+		case updated <- config:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.RefreshLeases(ctx, nil)
+	}()
+
+	src.set("DB_PASSWORD", "two")
+	select {
+	case config := <-updated:
+		if config.Password != "two" {
+			t.Errorf("Expected the rotated password, got %q", config.Password)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lease was not refreshed")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestReloadable_RefreshLeases_Expired(t *testing.T) {
+	shortLeaseWait(t, 20*time.Millisecond)
+
+	type Config struct {
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	src := &leaseSource{values: map[string]string{"DB_PASSWORD": "one"}, ttl: time.Nanosecond}
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(src))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	r.RefreshLeases(ctx, nil)
+
+	if n := src.lookupCount(); n > 10 {
+		t.Errorf("Expected expired leases to be refreshed at most every 20ms, got %d lookups in 100ms", n)
+	}
+}
+
+func TestReloadable_RefreshLeases_Gained(t *testing.T) {
+	shortLeaseWait(t, time.Millisecond)
+
+	type Config struct {
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	src := &leaseSource{values: map[string]string{"DB_PASSWORD": "one"}}
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(src))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	updated := make(chan Config, 1)
+	r.OnChange(func(config Config, _ []string) {
+		select {
+		case updated <- config:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.RefreshLeases(ctx, nil)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// A reload made elsewhere reads a lease, which RefreshLeases must pick up.
+	src.setTTL(30 * time.Millisecond)
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	src.set("DB_PASSWORD", "two")
+	select {
+	case config := <-updated:
+		if config.Password != "two" {
+			t.Errorf("Expected the rotated password, got %q", config.Password)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lease was not refreshed")
+	}
+}
+
+func TestLeaseWait(t *testing.T) {
+	tests := []struct {
+		ttl      time.Duration
+		failures int
+		expected time.Duration
+	}{
+		{time.Minute, 0, 40 * time.Second},
+		{time.Nanosecond, 0, time.Second},
+		{time.Minute, 1, 6 * time.Second},
+		{time.Minute, 3, 24 * time.Second},
+		{time.Hour, 10, 5 * time.Minute},
+		{time.Nanosecond, 5, time.Second},
+	}
+
+	for _, test := range tests {
+		if wait := leaseWait(test.ttl, test.failures); wait != test.expected {
+			t.Errorf("leaseWait(%v, %d): expected %v, got %v", test.ttl, test.failures, test.expected, wait)
+		}
+	}
+}
+
+func TestReloadable_RefreshFields(t *testing.T) {
+	type Config struct {
+		Host  string `env:"HOST"`
+		Token string `env:"API_TOKEN" refresh:"20ms"`
+	}
+
+	src := &leaseSource{values: map[string]string{"HOST": "a", "API_TOKEN": "one"}}
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(src))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	updated := make(chan []string, 1)
+	r.OnChange(func(_ Config, changed []string) {
+		select {
+		case updated <- changed:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.RefreshFields(ctx, nil)
+
+	src.set("HOST", "b")
+	src.set("API_TOKEN", "two")
+
+	select {
+	case changed := <-updated:
+		if expected := []string{"API_TOKEN"}; !reflect.DeepEqual(changed, expected) {
+			t.Errorf("Expected changed keys %v, got %v", expected, changed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Field was not refreshed")
+	}
+
+	if expected := (Config{Host: "a", Token: "two"}); r.Get() != expected {
+		t.Errorf("Expected only the refreshed field to change, got %+v", r.Get())
+	}
+}
+
+func TestParse_InvalidRefreshTag(t *testing.T) {
+	type Config struct {
+		Token string `env:"API_TOKEN" refresh:"often"`
+	}
+
+	err := Parse(&Config{}, WithNoOSEnv(), WithSource(mapSource{"API_TOKEN": "x"}))
+	if err == nil || err.Error() != `invalid refresh tag for environment variable: API_TOKEN: "often"` {
+		t.Errorf("Expected an invalid refresh tag error, got %v", err)
+	}
+}
+
+func TestReloadable_ReloadHandler(t *testing.T) {
+	type Config struct {
+		Level    string `env:"LOG_LEVEL" oneof:"debug,info"`
+		Password string `env:"DB_PASSWORD" match:"^[a-z]+$" secret:"true"`
+	}
+
+	src := mapSource{"LOG_LEVEL": "info", "DB_PASSWORD": "old"}
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(src))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	h := r.ReloadHandler("s3cret")
+	do := func(method, auth string) (int, string) {
+		req := httptest.NewRequest(method, "/reload", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code, strings.TrimSpace(w.Body.String())
+	}
+
+	if code, _ := do(http.MethodGet, "Bearer s3cret"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", code)
+	}
+	if code, _ := do(http.MethodPost, "Bearer wrong"); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", code)
+	}
+	if code, _ := do(http.MethodPost, ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", code)
+	}
+
+	src["LOG_LEVEL"] = "debug"
+	src["DB_PASSWORD"] = "new"
+	expected := `{"changed":["DB_PASSWORD","LOG_LEVEL"],"diff":{"DB_PASSWORD":{"old":"[REDACTED]","new":"[REDACTED]"},` +
+		`"LOG_LEVEL":{"old":"info","new":"debug"}}}`
+	if code, body := do(http.MethodPost, "Bearer s3cret"); code != http.StatusOK || body != expected {
+		t.Errorf("Unexpected response %d %s", code, body)
+	}
+	if code, body := do(http.MethodPost, "Bearer s3cret"); code != http.StatusOK || body != `{"changed":[]}` {
+		t.Errorf("Unexpected response %d %s", code, body)
+	}
+	if r.Get().Level != "debug" {
+		t.Errorf("Expected the config to be reloaded, got %+v", r.Get())
+	}
+
+	src["LOG_LEVEL"] = "trace"
+	code, body := do(http.MethodPost, "Bearer s3cret")
+	if code != http.StatusUnprocessableEntity || !strings.Contains(body, `"error":"invalid value for environment variable: LOG_LEVEL`) {
+		t.Errorf("Unexpected response %d %s", code, body)
+	}
+	if r.Get().Level != "debug" {
+		t.Errorf("Expected a failed reload to keep the config, got %+v", r.Get())
+	}
+
+	src["LOG_LEVEL"] = "debug"
+	src["DB_PASSWORD"] = "Sup3rS3cret!"
+	code, body = do(http.MethodPost, "Bearer s3cret")
+	if code != http.StatusUnprocessableEntity || strings.Contains(body, "Sup3rS3cret") ||
+		!strings.Contains(body, "DB_PASSWORD: [REDACTED]") {
+		t.Errorf("Unexpected response %d %s", code, body)
+	}
+}
+
+// watchSource is a Watcher that reports a change whenever set is called.
+type watchSource struct {
+	leaseSource
+	changes chan struct{}
+}
+
+func (s *watchSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.changes:
+			changed()
+		}
+	}
+}
+
+func TestReloadable_WatchSources(t *testing.T) {
+	type Config struct {
+		Level string `env:"LOG_LEVEL"`
+	}
+
+	for name, wrap := range map[string]func(Source) Source{
+		"source": func(s Source) Source { return s },
+		"chain":  func(s Source) Source { return Chain(MapSource(nil), s) },
+		"resolver": func(s Source) Source {
+			r := NewResolver()
+			r.Add(LayerRemote, s)
+			return r
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src := &watchSource{leaseSource{values: map[string]string{"LOG_LEVEL": "info"}}, make(chan struct{})}
+			r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(wrap(src)))
+			if err != nil {
+				t.Fatalf("Failed to parse environment variables: %v", err)
+			}
+
+			updated := make(chan Config, 1)
+			r.OnChange(func(config Config, _ []string) {
+				updated <- config
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- r.WatchSources(ctx, nil)
+			}()
+
+			src.set("LOG_LEVEL", "debug")
+			select {
+			case src.changes <- struct{}{}:
+			case <-time.After(time.Second):
+				t.Fatal("Source was not watched")
+			}
+
+			select {
+			case config := <-updated:
+				if config.Level != "debug" {
+					t.Errorf("Expected the new level, got %q", config.Level)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Config was not reloaded")
+			}
+
+			cancel()
+			if err := <-done; !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The layers of a Resolver, in their default order of precedence.
+const (
+	// LayerSet holds values set explicitly with Resolver.Set.
+	LayerSet = "set"
+	// LayerFlags holds command line flags.
+	LayerFlags = "flags"
+	// LayerEnv holds the process environment.
+	LayerEnv = "env"
+	// LayerFiles holds configuration files, such as .env files.
+	LayerFiles = "files"
+	// LayerRemote holds remote stores, such as secret managers.
+	LayerRemote = "remote"
+	// LayerDefaults holds defaults provided as a Source. The `default`
+	// tags of a config come after every layer.
+	LayerDefaults = "defaults"
+)
+
+// DefaultPrecedence is the order in which a new Resolver consults its
+// layers, highest precedence first.
+var DefaultPrecedence = []string{LayerSet, LayerFlags, LayerEnv, LayerFiles, LayerRemote, LayerDefaults}
+
+// Resolver is a Source that consults named layers of sources in a
+// configurable order of precedence, and can explain where a value came
+// from:
+//
+//	r := env.NewResolver()
+//	r.Add(env.LayerFiles, dotenvSource)
+//	r.Add(env.LayerRemote, vaultSource)
+//	err := env.Parse(&config, env.WithResolver(r))
+//
+//	fmt.Println(r.ExplainValue("DB_PASSWORD"))
+//	// DB_PASSWORD: from remote (vault), shadowing files (.env)
+//
+// Within a layer, sources are consulted in the order they were added. Like
+// a Chain, a Resolver keeps the leases of values read from a Leaser and
+// batches the lookups of its BatchSources. A Resolver is safe for
+// concurrent use.
+type Resolver struct {
+	mu     sync.RWMutex
+	order  []string
+	layers map[string][]Source
+	set    setSource
+}
+
+// NewResolver returns a Resolver with the DefaultPrecedence, whose env layer
+// holds the process environment.
+func NewResolver() *Resolver {
+	r := &Resolver{
+		order:  append([]string(nil), DefaultPrecedence...),
+		layers: make(map[string][]Source),
+		set:    make(setSource),
+	}
+	r.layers[LayerSet] = []Source{r.set}
+	r.layers[LayerEnv] = []Source{OS()}
+	return r
+}
+
+// Add adds s to layer, after the sources already in it. Layers not in the
+// order of precedence are never consulted.
+func (r *Resolver) Add(layer string, s Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.layers[layer] = append(r.layers[layer], s)
+}
+
+// Set sets the value of key in the set layer.
+func (r *Resolver) Set(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set[key] = value
+}
+
+// SetPrecedence changes the order in which the layers are consulted to
+// layers, highest precedence first. Layers may be left out to disable them,
+// but not repeated.
+func (r *Resolver) SetPrecedence(layers ...string) error {
+	seen := make(map[string]bool, len(layers))
+	for _, layer := range layers {
+		if seen[layer] {
+			return errors.New("layer listed twice: " + layer)
+		}
+		seen[layer] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = append([]string(nil), layers...)
+	return nil
+}
+
+// Precedence returns the layers in the order they are consulted.
+func (r *Resolver) Precedence() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.order...)
+}
+
+// Lookup implements Source, returning the value from the first layer that
+// has key.
+func (r *Resolver) Lookup(key string) (string, bool) {
+	value, _, ok := r.LookupLease(key)
+	return value, ok
+}
+
+// LookupLease implements Leaser, returning the lease of the value if it
+// came from a Leaser.
+func (r *Resolver) LookupLease(key string) (string, time.Duration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return chain(r.sources()).LookupLease(key)
+}
+
+// LookupBatch implements BatchSource, looking keys up at once in the
+// sources that are BatchSources.
+func (r *Resolver) LookupBatch(keys []string) map[string]string {
+	return batchValues(r.lookupBatch(keys))
+}
+
+func (r *Resolver) batches() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return anyBatches(r.sources())
+}
+
+func (r *Resolver) lookupBatch(keys []string) map[string]cachedLookup {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return lookupBatchIn(r.sources(), keys)
+}
+
+func (r *Resolver) watchers() []Watcher {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return watchersIn(r.sources())
+}
+
+// sources returns the sources of the layers, in order of precedence. r.mu
+// must be held while they are used, since the set layer is not safe for
+// concurrent use.
+func (r *Resolver) sources() []Source {
+	var sources []Source
+	for _, layer := range r.order {
+		sources = append(sources, r.layers[layer]...)
+	}
+	return sources
+}
+
+// String implements fmt.Stringer.
+func (r *Resolver) String() string {
+	return "resolver"
+}
+
+// Candidate is a value a layer of a Resolver holds for a key.
+type Candidate struct {
+	Layer  string
+	Source string
+	Value  string
+}
+
+// Explanation describes where the value of a key comes from.
+type Explanation struct {
+	Key string
+	// Candidates holds every value found for Key, in order of precedence.
+	// The first one is used; the others are shadowed by it.
+	Candidates []Candidate
+}
+
+// Found reports whether any layer has the key.
+func (e Explanation) Found() bool {
+	return len(e.Candidates) > 0
+}
+
+// String describes e without revealing any values, such as
+// "DB_PASSWORD: from remote (vault), shadowing files (.env)".
+func (e Explanation) String() string {
+	if !e.Found() {
+		return e.Key + ": not set in any layer"
+	}
+
+	var b strings.Builder
+	b.WriteString(e.Key + ": from " + e.Candidates[0].Layer + " (" + e.Candidates[0].Source + ")")
+	for i, c := range e.Candidates[1:] {
+		if i == 0 {
+			b.WriteString(", shadowing ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(c.Layer + " (" + c.Source + ")")
+	}
+	return b.String()
+}
+
+// ExplainValue reports which layers hold a value for key and which of them
+// wins.
+func (r *Resolver) ExplainValue(key string) Explanation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e := Explanation{Key: key}
+	for _, layer := range r.order {
+		for _, s := range r.layers[layer] {
+			if value, ok := s.Lookup(key); ok {
+				e.Candidates = append(e.Candidates, Candidate{Layer: layer, Source: sourceName(s), Value: value})
+			}
+		}
+	}
+	return e
+}
+
+// WithResolver makes Parse read from r alone, so that r's order of
+// precedence decides between all sources, the process environment
+// included. Values from r still take precedence over `default` tags.
+func WithResolver(r *Resolver) Option {
+	return func(o *options) {
+		o.noOSEnv = true
+		o.sources = []Source{r}
+	}
+}
+
+// setSource holds the values of the set layer of a Resolver.
+type setSource map[string]string
+
+func (s setSource) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+func (setSource) String() string {
+	return "set"
+}
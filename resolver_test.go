@@ -0,0 +1,111 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResolver(t *testing.T) {
+	type Config struct {
+		Host     string `env:"HOST"`
+		Port     int    `env:"PORT"`
+		Password string `env:"DB_PASSWORD"`
+		Debug    bool   `env:"DEBUG" default:"true"`
+	}
+
+	clearenv(t)
+	t.Setenv("PORT", "9090")
+
+	r := NewResolver()
+	r.Add(LayerFiles, mapSource{"HOST": "file-host", "PORT": "8080", "DB_PASSWORD": "from-file"})
+	r.Add(LayerRemote, mapSource{"DB_PASSWORD": "from-vault"})
+	r.Set("HOST", "set-host")
+
+	var config Config
+	if err := Parse(&config, WithResolver(r)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := Config{Host: "set-host", Port: 9090, Password: "from-file", Debug: true}
+	if config != expected {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+
+	if err := r.SetPrecedence(LayerRemote, LayerFiles); err != nil {
+		t.Fatalf("Failed to set precedence: %v", err)
+	}
+	if err := Parse(&config, WithResolver(r)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected = Config{Host: "file-host", Port: 8080, Password: "from-vault", Debug: true}
+	if config != expected {
+		t.Errorf("Expected %+v after reordering, got %+v", expected, config)
+	}
+
+	if err := r.SetPrecedence(LayerEnv, LayerEnv); err == nil {
+		t.Error("Expected an error for a repeated layer")
+	}
+	if expected := []string{LayerRemote, LayerFiles}; !reflect.DeepEqual(r.Precedence(), expected) {
+		t.Errorf("Expected precedence %v, got %v", expected, r.Precedence())
+	}
+}
+
+func TestResolver_LeaseAndBatch(t *testing.T) {
+	clearenv(t)
+
+	batch := &batchSource{mapSource: mapSource{"HOST": "db", "TOKEN": "shadowed"}}
+	r := NewResolver()
+	r.Add(LayerFiles, batch)
+	r.Add(LayerRemote, &leaseSource{values: map[string]string{"TOKEN": "t"}, ttl: time.Minute})
+	r.SetPrecedence(LayerRemote, LayerFiles)
+
+	type Config struct {
+		Host  string `env:"HOST"`
+		Token string `env:"TOKEN"`
+	}
+	leases := map[string]time.Duration{}
+	o := newOptions([]Option{WithResolver(r)})
+	o.onLease = func(key string, ttl time.Duration) { leases[key] = ttl }
+
+	var config Config
+	if err := parseWith(o, &config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if config.Host != "db" || config.Token != "t" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if batch.batches != 1 || batch.lookups != 0 {
+		t.Errorf("Expected a single batch lookup, got %d batches and %d lookups", batch.batches, batch.lookups)
+	}
+	if expected := map[string]time.Duration{"TOKEN": time.Minute}; !reflect.DeepEqual(leases, expected) {
+		t.Errorf("Expected the lease of TOKEN, got %v", leases)
+	}
+}
+
+func TestResolver_ExplainValue(t *testing.T) {
+	clearenv(t)
+	t.Setenv("DB_PASSWORD", "from-env")
+
+	r := NewResolver()
+	r.Add(LayerFiles, mapSource{"DB_PASSWORD": "from-file"})
+
+	e := r.ExplainValue("DB_PASSWORD")
+	expected := []Candidate{
+		{Layer: LayerEnv, Source: "os", Value: "from-env"},
+		{Layer: LayerFiles, Source: "env.mapSource", Value: "from-file"},
+	}
+	if !reflect.DeepEqual(e.Candidates, expected) {
+		t.Errorf("Expected candidates %+v, got %+v", expected, e.Candidates)
+	}
+
+	if s := e.String(); s != "DB_PASSWORD: from env (os), shadowing files (env.mapSource)" {
+		t.Errorf("Unexpected explanation %q", s)
+	}
+
+	if s := r.ExplainValue("MISSING").String(); s != "MISSING: not set in any layer" {
+		t.Errorf("Unexpected explanation %q", s)
+	}
+}
@@ -0,0 +1,85 @@
+package env
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// jsonSchema is the subset of JSON Schema written by Schema.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Default     *string                `json:"default,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	WriteOnly   bool                   `json:"writeOnly,omitempty"`
+	GoType      string                 `json:"x-go-type,omitempty"`
+}
+
+// Schema returns a JSON Schema document describing the environment read by
+// config, a struct or a pointer to one, for validating deployment manifests
+// or generating forms. The document is an object with a property for every
+// variable:
+//
+//	{
+//	  "$schema": "https://json-schema.org/draft/2020-12/schema",
+//	  "title": "main.Config",
+//	  "type": "object",
+//	  "properties": {
+//	    "LOG_LEVEL": {
+//	      "type": "string",
+//	      "default": "info",
+//	      "enum": ["debug", "info"],
+//	      "x-go-type": "string"
+//	    }
+//	  },
+//	  "required": [...]
+//	}
+//
+// Every property is a string, as environment variables are, with the Go
+// type of its field in "x-go-type". Allowed values come from `oneof` and
+// `envValues` tags and patterns from `match` tags; secrets are marked
+// writeOnly and their defaults are left out. Variables that may be missing
+// because of a default, including one set by a SetDefaults method, are not
+// required.
+func Schema(config interface{}) ([]byte, error) {
+	docs, err := describe(config)
+	if err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf(config)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	s := &jsonSchema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      t.String(),
+		Type:       "object",
+		Properties: make(map[string]*jsonSchema, len(docs)),
+	}
+	for _, d := range docs {
+		p := &jsonSchema{
+			Description: d.Description,
+			Type:        "string",
+			Enum:        d.allowed,
+			Pattern:     d.Match,
+			WriteOnly:   d.Secret,
+			GoType:      d.Type,
+		}
+		if !d.Secret {
+			p.Default = d.Default
+		}
+		s.Properties[d.Name] = p
+
+		if d.Required {
+			s.Required = append(s.Required, d.Name)
+		}
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
@@ -0,0 +1,53 @@
+package env
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	type Config struct {
+		Host     string     `env:"DB_HOST" default:"localhost" desc:"Database host"`
+		Password Secret     `env:"DB_PASSWORD" default:"hunter2"`
+		Port     int        `env:"DB_PORT" match:"^[0-9]+$"`
+		Priority int        `env:"PRIORITY" envValues:"low=1,high=10"`
+		Token    Credential `env:"TOKEN"`
+	}
+
+	data, err := Schema(&Config{})
+	if err != nil {
+		t.Fatalf("Failed to export schema: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+
+	var expected map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "env.Config",
+		"type": "object",
+		"properties": {
+			"DB_HOST": {"type": "string", "description": "Database host", "default": "localhost", "x-go-type": "string"},
+			"DB_PASSWORD": {"type": "string", "writeOnly": true, "x-go-type": "env.Secret"},
+			"DB_PORT": {"type": "string", "pattern": "^[0-9]+$", "x-go-type": "int"},
+			"PRIORITY": {"type": "string", "enum": ["low", "high"], "x-go-type": "int"},
+			"TOKEN": {"type": "string", "x-go-type": "env.Credential"},
+			"TOKEN_EXPIRES_AT": {"type": "string", "description": "Expiry of TOKEN, as an RFC 3339 time or in seconds since the Unix epoch.", "x-go-type": "time.Time"}
+		},
+		"required": ["DB_PORT", "PRIORITY", "TOKEN"]
+	}`), &expected)
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %s", expected, data)
+	}
+}
+
+func TestSchema_InvalidConfig(t *testing.T) {
+	if _, err := Schema("config"); err == nil {
+		t.Error("Expected an error for a non-struct config")
+	}
+}
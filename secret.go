@@ -0,0 +1,206 @@
+package env
+
+import (
+	"errors"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// secretValue returns the value of a secret field as a string, and whether it
+// is of a kind that can hold one.
+func secretValue(v reflect.Value) (string, bool) {
+	switch {
+	case v.Kind() == reflect.String:
+		return v.String(), true
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return string(v.Bytes()), true
+	case v.Type() == secretType:
+		b, err := v.Interface().(Secret).peek()
+		return string(b), err == nil
+	}
+	return "", false
+}
+
+// CheckArgs reports an error naming the variables of the fields of config
+// tagged `secret:"true"` whose values also appear in the command line
+// arguments, which other users of the machine can see in process listings:
+//
+//	if err := env.CheckArgs(&config); err != nil {
+//		log.Printf("warning: %v", err)
+//	}
+//
+// The error never contains the secret values.
+func CheckArgs(config interface{}) error {
+	return checkArgs(config, os.Args[1:])
+}
+
+func checkArgs(config interface{}, args []string) error {
+	if err := checkTarget(config); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(config).Elem()
+
+	var exposed []string
+	for _, f := range fieldsOf(v.Type()) {
+		if !f.secret {
+			continue
+		}
+
+		secret, ok := secretValue(v.FieldByIndex(f.index))
+		if !ok || secret == "" {
+			continue
+		}
+
+		for _, arg := range args {
+			if strings.Contains(arg, secret) {
+				exposed = append(exposed, f.key)
+				break
+			}
+		}
+	}
+
+	if len(exposed) > 0 {
+		return errors.New("secret values appear in the command line: " + strings.Join(exposed, ", "))
+	}
+	return nil
+}
+
+// MinSecretLength is the length below which CheckSecrets considers a secret
+// weak.
+const MinSecretLength = 16
+
+// placeholders are values commonly left in secrets from examples and
+// templates.
+var placeholders = []string{
+	"changeme", "change-me", "change_me", "changeit", "password", "passw0rd",
+	"secret", "default", "example", "placeholder", "todo", "fixme", "test",
+	"admin", "letmein", "123456", "qwerty", "xxx",
+}
+
+// CheckSecrets reports an error naming the variables of the fields of config
+// tagged `secret:"true"` that hold obviously weak values: empty values,
+// values shorter than MinSecretLength, common placeholders such as
+// "changeme", alone or followed only by digits and punctuation as in
+// "changeme123!", and values made of very few distinct characters.
+//
+// It catches placeholder secrets copied from examples before they reach
+// production. The checks are heuristics, so call it after Parse and log the
+// error as a warning:
+//
+//	if err := env.CheckSecrets(&config); err != nil {
+//		log.Printf("warning: %v", err)
+//	}
+//
+// Deployments that control their secrets can also make Parse fail with
+// env.WithValidator(env.CheckSecrets). The error never contains the secret
+// values.
+func CheckSecrets(config interface{}) error {
+	if err := checkTarget(config); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(config).Elem()
+
+	var weak []string
+	for _, f := range fieldsOf(v.Type()) {
+		if !f.secret {
+			continue
+		}
+
+		secret, ok := secretValue(v.FieldByIndex(f.index))
+		if !ok {
+			continue
+		}
+
+		if reason := weakness(secret); reason != "" {
+			weak = append(weak, f.key+" "+reason)
+		}
+	}
+
+	if len(weak) > 0 {
+		return errors.New("weak secrets: " + strings.Join(weak, ", "))
+	}
+	return nil
+}
+
+// weakness returns why secret is weak, or "" if it looks strong enough.
+func weakness(secret string) string {
+	if secret == "" {
+		return "is empty"
+	}
+
+	lower := strings.ToLower(secret)
+	for _, p := range placeholders {
+		if rest, ok := strings.CutPrefix(lower, p); ok && strings.IndexFunc(rest, unicode.IsLetter) < 0 {
+			return "is the placeholder " + strconv.Quote(p)
+		}
+	}
+
+	if len(secret) < MinSecretLength {
+		return "is shorter than " + strconv.Itoa(MinSecretLength) + " characters"
+	}
+
+	if entropy(secret) < 2 {
+		return "has too little variety"
+	}
+
+	return ""
+}
+
+// entropy returns the Shannon entropy of s in bits per byte.
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	var h float64
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / float64(len(s))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// Zeroize wipes the fields of config tagged `secret:"true"`, and its Secret
+// and Credential fields, for when the application shuts down or has handed
+// its secrets to the code that uses them. []byte fields are overwritten with
+// zeros, Secret fields are zeroized and string fields are cleared.
+//
+// Zeroize is best effort: Go strings cannot be overwritten in place, so the
+// memory of a cleared string is only released to the garbage collector, and
+// copies made earlier are not affected.
+func Zeroize(config interface{}) error {
+	if err := checkTarget(config); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(config).Elem()
+	for _, f := range fieldsOf(v.Type()) {
+		if !f.secret && !isCredential(f.field.Type) {
+			continue
+		}
+
+		field := reflect.Indirect(v.FieldByIndex(f.index))
+		switch {
+		case !field.IsValid():
+		case field.Type() == secretType:
+			field.Interface().(Secret).Zeroize()
+		case field.Type() == credentialType:
+			field.Set(reflect.Zero(credentialType))
+		case field.Kind() == reflect.String:
+			field.SetString("")
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+			wipe(field.Bytes())
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+	return nil
+}
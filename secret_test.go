@@ -0,0 +1,99 @@
+package env
+
+import (
+	"testing"
+)
+
+type secretConfig struct {
+	User     string `env:"DB_USER"`
+	Password string `env:"DB_PASSWORD" secret:"true"`
+	Token    []byte `env:"API_TOKEN" secret:"true"`
+	Empty    string `env:"EMPTY_SECRET" secret:"true"`
+}
+
+func TestCheckArgs(t *testing.T) {
+	config := secretConfig{User: "admin", Password: "hunter2", Token: []byte("t0k3n")}
+
+	if err := checkArgs(&config, []string{"-user", "admin", "-v"}); err != nil {
+		t.Errorf("Expected no error when only non-secret values appear, got %v", err)
+	}
+
+	err := checkArgs(&config, []string{"--password=hunter2", "-token", "t0k3n"})
+	if err == nil || err.Error() != "secret values appear in the command line: DB_PASSWORD, API_TOKEN" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestCheckSecrets(t *testing.T) {
+	strong := "q8Zt1xV3mR9pL2wK7nB4"
+
+	config := secretConfig{User: "admin", Password: strong, Token: []byte(strong), Empty: strong}
+	if err := CheckSecrets(&config); err != nil {
+		t.Errorf("Expected strong secrets to pass, got %v", err)
+	}
+
+	for _, value := range []string{"contestant-Q8zT1xV3mR9p", "testament4Zq8Zt1xV3mW", "xxxTRq8Zt1xV3mR9pL2", "my-default-route-7Kq9Zt"} {
+		config.Password = value
+		if err := CheckSecrets(&config); err != nil {
+			t.Errorf("Expected %q not to be taken for a placeholder, got %v", value, err)
+		}
+	}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", "DB_PASSWORD is empty"},
+		{"changeme", `DB_PASSWORD is the placeholder "changeme"`},
+		{"Password123!-2024", `DB_PASSWORD is the placeholder "password"`},
+		{"Zt1xV3mR9", "DB_PASSWORD is shorter than 16 characters"},
+		{"abababababababababab", "DB_PASSWORD has too little variety"},
+	}
+
+	for _, tt := range tests {
+		config.Password = tt.value
+		err := CheckSecrets(&config)
+		if err == nil || err.Error() != "weak secrets: "+tt.want {
+			t.Errorf("CheckSecrets with %q returned %v, expected %q", tt.value, err, tt.want)
+		}
+	}
+}
+
+func TestZeroize(t *testing.T) {
+	type Config struct {
+		Host     string     `env:"HOST"`
+		Password string     `env:"PASSWORD" secret:"true"`
+		Key      []byte     `env:"KEY" secret:"true"`
+		Token    *string    `env:"TOKEN" secret:"true"`
+		Handle   Secret     `env:"HANDLE"`
+		Cred     Credential `env:"CRED"`
+	}
+
+	key := []byte("signing key")
+	token := "token"
+	config := Config{Host: "db", Password: "hunter2", Key: key, Token: &token}
+	config.Cred.Value = "cred"
+	if err := config.Handle.UnmarshalText([]byte("handle")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Zeroize(&config); err != nil {
+		t.Fatalf("Failed to zeroize: %v", err)
+	}
+
+	if config.Host != "db" {
+		t.Errorf("Expected non-secret fields to be kept, got %q", config.Host)
+	}
+	if config.Password != "" || config.Key != nil || *config.Token != "" || config.Cred.Value != "" {
+		t.Errorf("Expected secrets to be cleared, got %+v", config)
+	}
+	for _, b := range key {
+		if b != 0 {
+			t.Errorf("Expected the byte slice to be overwritten, got %q", key)
+			break
+		}
+	}
+	if _, err := config.Handle.Reveal(); err != ErrZeroized {
+		t.Errorf("Expected ErrZeroized, got %v", err)
+	}
+}
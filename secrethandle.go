@@ -0,0 +1,149 @@
+package env
+
+import (
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var secretType = reflect.TypeOf(Secret{})
+
+var (
+	// ErrZeroized is returned when revealing a Secret that has been
+	// zeroized.
+	ErrZeroized = errors.New("secret has been zeroized")
+	// ErrSecretUsed is returned when revealing a one-time Secret for the
+	// second time.
+	ErrSecretUsed = errors.New("one-time secret has already been revealed")
+)
+
+// Secret holds a secret value without keeping it in the clear: the value is
+// masked with a random key in memory and only unmasked, into a fresh copy,
+// by Reveal. Its String method returns a placeholder, so it cannot be logged
+// by accident. Fields of type Secret are treated as if tagged
+// `secret:"true"`:
+//
+//	type Config struct {
+//	  Password env.Secret `env:"DB_PASSWORD"`
+//	}
+//
+//	password, err := config.Password.Reveal()
+//
+// A Secret field tagged `secret:"once"` can be revealed only once: its
+// value is zeroized by the first Reveal, and later calls return
+// ErrSecretUsed. This enforces that bootstrap tokens are neither retained
+// nor reused.
+//
+// Copies of a Secret share its value, so zeroizing one zeroizes all of
+// them. This is a best-effort measure: it keeps secrets out of heap dumps
+// and logs of the struct, but cannot stop the runtime from having copied
+// them, and revealed values are ordinary strings.
+type Secret struct {
+	s *sealed
+}
+
+type sealed struct {
+	mu   sync.Mutex
+	mask []byte
+	data []byte
+
+	// once is set for one-time secrets, and used once they are revealed.
+	once, used bool
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Secret) UnmarshalText(text []byte) error {
+	mask := make([]byte, len(text))
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+
+	data := make([]byte, len(text))
+	for i := range text {
+		data[i] = text[i] ^ mask[i]
+	}
+
+	s.s = &sealed{mask: mask, data: data}
+	return nil
+}
+
+// Reveal returns the secret value. It returns ErrZeroized once the secret
+// has been zeroized. An unset Secret reveals as the empty string.
+func (s Secret) Reveal() (string, error) {
+	b, err := s.RevealBytes()
+	return string(b), err
+}
+
+// RevealBytes is like Reveal but returns the value as a new byte slice,
+// which the caller may wipe when done with it.
+func (s Secret) RevealBytes() ([]byte, error) {
+	if s.s == nil {
+		return nil, nil
+	}
+
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+
+	b, err := s.s.reveal()
+	if err == nil && s.s.once {
+		s.s.used = true
+		s.s.zeroize()
+	}
+	return b, err
+}
+
+// peek returns the value of s without using up a one-time secret.
+func (s Secret) peek() ([]byte, error) {
+	if s.s == nil {
+		return nil, nil
+	}
+
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	return s.s.reveal()
+}
+
+func (s *sealed) reveal() ([]byte, error) {
+	switch {
+	case s.used:
+		return nil, ErrSecretUsed
+	case s.data == nil:
+		return nil, ErrZeroized
+	}
+
+	b := make([]byte, len(s.data))
+	for i := range s.data {
+		b[i] = s.data[i] ^ s.mask[i]
+	}
+	return b, nil
+}
+
+// Zeroize overwrites the secret in memory. Later calls to Reveal return
+// ErrZeroized.
+func (s Secret) Zeroize() {
+	if s.s == nil {
+		return
+	}
+
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	s.s.zeroize()
+}
+
+func (s *sealed) zeroize() {
+	wipe(s.data)
+	wipe(s.mask)
+	s.data, s.mask = nil, nil
+}
+
+// String returns a placeholder, so that secrets are not logged by accident.
+func (s Secret) String() string {
+	return "[REDACTED]"
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
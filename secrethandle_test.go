@@ -0,0 +1,101 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	type Config struct {
+		Password Secret `env:"DB_PASSWORD"`
+		Unset    Secret `env:"UNSET" default:""`
+	}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(mapSource{"DB_PASSWORD": "correct horse battery"})); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if password, err := config.Password.Reveal(); err != nil || password != "correct horse battery" {
+		t.Errorf("Expected the password, got %q, %v", password, err)
+	}
+
+	if s := fmt.Sprintf("%v %+v", config.Password, config); strings.Contains(s, "horse") {
+		t.Errorf("Expected the secret not to be printed, got %s", s)
+	}
+
+	if err := CheckArgs(&config); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := checkArgs(&config, []string{"--password=correct horse battery"}); err == nil {
+		t.Error("Expected Secret fields to be checked like secret-tagged fields")
+	}
+
+	copied := config
+	copied.Password.Zeroize()
+	if _, err := config.Password.Reveal(); !errors.Is(err, ErrZeroized) {
+		t.Errorf("Expected ErrZeroized, got %v", err)
+	}
+
+	if s, err := (Secret{}).Reveal(); err != nil || s != "" {
+		t.Errorf("Expected an unset secret to reveal empty, got %q, %v", s, err)
+	}
+}
+
+func TestSecret_Once(t *testing.T) {
+	type Config struct {
+		Token Secret `env:"BOOTSTRAP_TOKEN" secret:"once"`
+	}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(mapSource{"BOOTSTRAP_TOKEN": "t0k3n"})); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if err := checkArgs(&config, []string{"t0k3n"}); err == nil {
+		t.Error("Expected CheckArgs to see the secret")
+	}
+
+	if token, err := config.Token.Reveal(); err != nil || token != "t0k3n" {
+		t.Errorf("Expected the token, got %q, %v", token, err)
+	}
+	if _, err := config.Token.Reveal(); !errors.Is(err, ErrSecretUsed) {
+		t.Errorf("Expected ErrSecretUsed, got %v", err)
+	}
+
+	type Invalid struct {
+		Token string `env:"BOOTSTRAP_TOKEN" secret:"once"`
+	}
+	err := Parse(&Invalid{}, WithNoOSEnv(), WithSource(mapSource{"BOOTSTRAP_TOKEN": "t0k3n"}))
+	if err == nil || err.Error() != `secret:"once" requires a Secret field: BOOTSTRAP_TOKEN` {
+		t.Errorf("Expected an error for a non-Secret field, got %v", err)
+	}
+}
+
+func TestReloadable_SecretUnchanged(t *testing.T) {
+	type Config struct {
+		Password Secret `env:"DB_PASSWORD"`
+	}
+
+	src := mapSource{"DB_PASSWORD": "one"}
+	r, err := NewReloadable[Config](WithNoOSEnv(), WithSource(src))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	var changed []string
+	r.OnChange(func(_ Config, keys []string) {
+		changed = keys
+	})
+
+	if err := r.Reload(); err != nil || changed != nil {
+		t.Errorf("Expected an unchanged secret not to be reported, got %v, %v", changed, err)
+	}
+
+	src["DB_PASSWORD"] = "two"
+	if err := r.Reload(); err != nil || len(changed) != 1 {
+		t.Errorf("Expected a changed secret to be reported, got %v, %v", changed, err)
+	}
+}
@@ -0,0 +1,49 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Set functions set environment variables to values formatted the way
+// the corresponding Get functions parse them, so that values round-trip in
+// tests and tools that prepare an environment for another process.
+
+// SetInt sets the environment variable named by the key to i.
+func SetInt(key string, i int) error {
+	return os.Setenv(key, strconv.Itoa(i))
+}
+
+// SetBool sets the environment variable named by the key to "true" or
+// "false".
+func SetBool(key string, b bool) error {
+	return os.Setenv(key, strconv.FormatBool(b))
+}
+
+// SetDuration sets the environment variable named by the key to d, formatted
+// like "1m30s".
+func SetDuration(key string, d time.Duration) error {
+	return os.Setenv(key, d.String())
+}
+
+// SetSlice sets the environment variable named by the key to values joined
+// by sep, each formatted with fmt.Sprint, for the slice getters such as
+// GetStringSlice and GetDurationSlice to read back. Since the getters trim
+// elements and skip empty ones, SetSlice returns an error, and leaves the
+// variable unchanged, if an element is empty, contains sep or has leading
+// or trailing space.
+func SetSlice[T any](key, sep string, values []T) error {
+	list := make([]string, len(values))
+	for i, v := range values {
+		s := fmt.Sprint(v)
+		if s == "" || strings.Contains(s, sep) || strings.TrimSpace(s) != s {
+			return errors.New("cannot represent slice element " + strconv.Quote(s) + " of environment variable: " + key)
+		}
+		list[i] = s
+	}
+	return os.Setenv(key, strings.Join(list, sep))
+}
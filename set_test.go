@@ -0,0 +1,63 @@
+package env
+
+import (
+	"net"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSet(t *testing.T) {
+	clearenv(t)
+
+	if err := SetInt("WORKERS", -4); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := GetInt("WORKERS"); !ok || v != -4 {
+		t.Errorf("Expected -4, got %d", v)
+	}
+
+	if err := SetBool("DEBUG", true); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := GetBool("DEBUG"); !ok || !v {
+		t.Errorf("Expected true, got %v", v)
+	}
+
+	if err := SetDuration("TIMEOUT", 90*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if v := GetDuration("TIMEOUT", 0); v != 90*time.Second {
+		t.Errorf("Expected 1m30s, got %v", v)
+	}
+
+	backoffs := []time.Duration{time.Second, 2500 * time.Millisecond}
+	if err := SetSlice("RETRY_BACKOFFS", ",", backoffs); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := GetDurationSliceE("RETRY_BACKOFFS", ","); err != nil || !reflect.DeepEqual(v, backoffs) {
+		t.Errorf("Expected %v, got %v, %v", backoffs, v, err)
+	}
+
+	peers := []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("::1")}
+	if err := SetSlice("PEERS", ";", peers); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := GetIPSliceE("PEERS", ";"); err != nil || !reflect.DeepEqual(v, peers) {
+		t.Errorf("Expected %v, got %v, %v", peers, v, err)
+	}
+}
+
+func TestSetSlice_Unrepresentable(t *testing.T) {
+	t.Setenv("HOSTS", "kept")
+
+	for _, values := range [][]string{{"a", "b,c"}, {"a", ""}, {" a"}, {"a\n"}} {
+		if err := SetSlice("HOSTS", ",", values); err == nil {
+			t.Errorf("Expected an error for %q", values)
+		}
+	}
+	if v := os.Getenv("HOSTS"); v != "kept" {
+		t.Errorf("Expected the variable to be left unchanged, got %q", v)
+	}
+}
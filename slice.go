@@ -0,0 +1,177 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetStringSlice returns the value of the environment variable named by the
+// key split on sep, with white space trimmed from each element and empty
+// elements dropped. A variable that is present but empty yields an empty
+// slice. If the variable is not present, def is returned.
+func GetStringSlice(key string, sep string, def []string) []string {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+	return splitList(value, sep)
+}
+
+// splitList splits value on sep, trimming white space from each element and
+// dropping empty ones.
+func splitList(value, sep string) []string {
+	var list []string
+	for _, s := range strings.Split(value, sep) {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// GetIntSlice returns the value of the environment variable named by the key
+// split on sep and parsed as ints, like GetStringSlice. Elements that cannot
+// be parsed are skipped; use GetIntSliceE to reject them instead. If the
+// variable is not present, def is returned.
+func GetIntSlice(key string, sep string, def []int) []int {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+	return parseListLenient(key, value, sep, parseIntElem)
+}
+
+// GetIntSliceE is like GetIntSlice but returns an error, wrapping ErrNotFound
+// if the variable is not present, or naming the first element that cannot be
+// parsed.
+func GetIntSliceE(key string, sep string) ([]int, error) {
+	return getListE(key, sep, parseIntElem)
+}
+
+// GetFloatSlice returns the value of the environment variable named by the
+// key split on sep and parsed as float64s, like GetStringSlice. Elements that
+// cannot be parsed are skipped; use GetFloatSliceE to reject them instead. If
+// the variable is not present, def is returned.
+func GetFloatSlice(key string, sep string, def []float64) []float64 {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+	return parseListLenient(key, value, sep, parseFloat)
+}
+
+// GetFloatSliceE is like GetFloatSlice but returns an error, wrapping
+// ErrNotFound if the variable is not present, or naming the first element
+// that cannot be parsed.
+func GetFloatSliceE(key string, sep string) ([]float64, error) {
+	return getListE(key, sep, parseFloat)
+}
+
+func parseIntElem(s string) (int, error) {
+	i, err := strconv.ParseInt(s, 10, 0)
+	return int(i), err
+}
+
+// parseListLenient parses the elements of value with parse, skipping those
+// that fail.
+func parseListLenient[T any](key, value, sep string, parse func(string) (T, error)) []T {
+	var list []T
+	for _, s := range splitList(value, sep) {
+		v, err := parse(s)
+		if err != nil {
+			malformed(key, err)
+			continue
+		}
+		list = append(list, v)
+	}
+	return list
+}
+
+func getListE[T any](key, sep string, parse func(string) (T, error)) ([]T, error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	var list []T
+	for i, s := range splitList(value, sep) {
+		v, err := parse(s)
+		if err != nil {
+			return nil, errors.New("invalid value for environment variable: " + key +
+				": element " + strconv.Itoa(i) + ": " + strconv.Quote(s))
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// GetDurationSlice returns the value of the environment variable named by
+// the key split on sep and parsed as time.Durations, such as "1s,2s,5s".
+// Elements that cannot be parsed are skipped; use GetDurationSliceE to
+// reject them instead. If the variable is not present, def is returned.
+func GetDurationSlice(key string, sep string, def []time.Duration) []time.Duration {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+	return parseListLenient(key, value, sep, parseDuration)
+}
+
+// GetDurationSliceE is like GetDurationSlice but returns an error, wrapping
+// ErrNotFound if the variable is not present, or naming the first element
+// that cannot be parsed.
+func GetDurationSliceE(key string, sep string) ([]time.Duration, error) {
+	return getListE(key, sep, parseDuration)
+}
+
+// GetURLSlice returns the value of the environment variable named by the
+// key split on sep and parsed as absolute URLs, like GetURL. Elements that
+// cannot be parsed are skipped; use GetURLSliceE to reject them instead. If
+// the variable is not present, def is returned.
+func GetURLSlice(key string, sep string, def []*url.URL) []*url.URL {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+	return parseListLenient(key, value, sep, parseURL)
+}
+
+// GetURLSliceE is like GetURLSlice but returns an error, wrapping
+// ErrNotFound if the variable is not present, or naming the first element
+// that cannot be parsed.
+func GetURLSliceE(key string, sep string) ([]*url.URL, error) {
+	return getListE(key, sep, parseURL)
+}
+
+// GetIPSlice returns the value of the environment variable named by the key
+// split on sep and parsed as IPv4 or IPv6 addresses, such as
+// "10.0.0.1,10.0.0.2". Elements that cannot be parsed are skipped; use
+// GetIPSliceE to reject them instead. If the variable is not present, def is
+// returned.
+func GetIPSlice(key string, sep string, def []net.IP) []net.IP {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+	return parseListLenient(key, value, sep, parseIP)
+}
+
+// GetIPSliceE is like GetIPSlice but returns an error, wrapping ErrNotFound
+// if the variable is not present, or naming the first element that cannot
+// be parsed.
+func GetIPSliceE(key string, sep string) ([]net.IP, error) {
+	return getListE(key, sep, parseIP)
+}
+
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.New("invalid IP address " + strconv.Quote(s))
+	}
+	return ip, nil
+}
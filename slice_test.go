@@ -0,0 +1,125 @@
+package env
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetStringSlice(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOSTS", " a.example.com, b.example.com ,,c.example.com ")
+	t.Setenv("PATHS", "/bin;/usr/bin")
+	t.Setenv("EMPTY", "")
+
+	def := []string{"localhost"}
+
+	tests := []struct {
+		key, sep string
+		want     []string
+	}{
+		{"HOSTS", ",", []string{"a.example.com", "b.example.com", "c.example.com"}},
+		{"PATHS", ";", []string{"/bin", "/usr/bin"}},
+		{"EMPTY", ",", nil},
+		{"MISSING", ",", def},
+	}
+
+	for _, tt := range tests {
+		if got := GetStringSlice(tt.key, tt.sep, def); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetStringSlice(%q, %q) = %q, expected %q", tt.key, tt.sep, got, tt.want)
+		}
+	}
+}
+
+func TestGetIntSlice(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PORTS", "80, 443,x,8080")
+	t.Setenv("GOOD_PORTS", "80,443")
+
+	if got := GetIntSlice("PORTS", ",", nil); !reflect.DeepEqual(got, []int{80, 443, 8080}) {
+		t.Errorf("Expected malformed elements to be skipped, got %v", got)
+	}
+
+	if got := GetIntSlice("MISSING", ",", []int{1}); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("Expected the default, got %v", got)
+	}
+
+	if _, err := GetIntSliceE("PORTS", ","); err == nil || err.Error() != `invalid value for environment variable: PORTS: element 2: "x"` {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if got, err := GetIntSliceE("GOOD_PORTS", ","); err != nil || !reflect.DeepEqual(got, []int{80, 443}) {
+		t.Errorf("GetIntSliceE returned %v, %v", got, err)
+	}
+
+	if _, err := GetIntSliceE("MISSING", ","); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetFloatSlice(t *testing.T) {
+	clearenv(t)
+	t.Setenv("RATIOS", "0.5;1e-3;;bad")
+
+	if got := GetFloatSlice("RATIOS", ";", nil); !reflect.DeepEqual(got, []float64{0.5, 0.001}) {
+		t.Errorf("Expected malformed elements to be skipped, got %v", got)
+	}
+
+	if _, err := GetFloatSliceE("RATIOS", ";"); err == nil {
+		t.Error("Expected an error for a malformed element")
+	}
+}
+
+func TestGetDurationSlice(t *testing.T) {
+	clearenv(t)
+	t.Setenv("RETRY_BACKOFFS", "1s, 2s,x,5s")
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 5 * time.Second}
+	if got := GetDurationSlice("RETRY_BACKOFFS", ",", nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	_, err := GetDurationSliceE("RETRY_BACKOFFS", ",")
+	if err == nil || err.Error() != `invalid value for environment variable: RETRY_BACKOFFS: element 2: "x"` {
+		t.Errorf("Expected an error naming the element, got %v", err)
+	}
+}
+
+func TestGetURLSlice(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PEERS", "https://a.example.com,b.example.com,http://c.example.com:8080")
+
+	urls := GetURLSlice("PEERS", ",", nil)
+	if len(urls) != 2 || urls[0].Host != "a.example.com" || urls[1].Host != "c.example.com:8080" {
+		t.Errorf("Unexpected URLs %v", urls)
+	}
+
+	if _, err := GetURLSliceE("PEERS", ","); err == nil {
+		t.Error("Expected an error for a URL without a scheme")
+	}
+}
+
+func TestGetIPSlice(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PEERS", "10.0.0.1, 10.0.0.2,::1")
+
+	expected := []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"), net.ParseIP("::1")}
+	ips, err := GetIPSliceE("PEERS", ",")
+	if err != nil || !reflect.DeepEqual(ips, expected) {
+		t.Errorf("Expected %v, got %v, %v", expected, ips, err)
+	}
+
+	t.Setenv("PEERS", "10.0.0.1,10.0.0.300")
+	if got := GetIPSlice("PEERS", ",", nil); len(got) != 1 {
+		t.Errorf("Expected the invalid address to be skipped, got %v", got)
+	}
+	if _, err := GetIPSliceE("PEERS", ","); err == nil {
+		t.Error("Expected an error for an invalid address")
+	}
+
+	if _, err := GetIPSliceE("MISSING", ","); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package env
+
+import (
+	"os"
+	"strings"
+)
+
+// Restore resets the process environment to the state captured by
+// Snapshot.
+type Restore func()
+
+// Snapshot captures the process environment and returns a function that
+// restores it: variables set since are unset, and changed or unset ones get
+// their captured values back. It is meant for tests and for tools that
+// mutate the environment temporarily:
+//
+//	defer env.Snapshot()()
+func Snapshot() Restore {
+	saved := environ()
+
+	return func() {
+		for key := range environ() {
+			if _, ok := saved[key]; !ok {
+				os.Unsetenv(key)
+			}
+		}
+		for key, value := range saved {
+			if current, ok := os.LookupEnv(key); !ok || current != value {
+				os.Setenv(key, value)
+			}
+		}
+	}
+}
+
+// environ returns the process environment as a map.
+func environ() map[string]string {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		// On Windows, names of hidden variables such as "=C:" start
+		// with "=", so the separator is searched after the first byte.
+		if kv == "" {
+			continue
+		}
+		if i := strings.IndexByte(kv[1:], '=') + 1; i > 0 {
+			vars[kv[:i]] = kv[i+1:]
+		}
+	}
+	return vars
+}
@@ -0,0 +1,34 @@
+package env
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	clearenv(t)
+	t.Setenv("KEEP", "1")
+	t.Setenv("CHANGE", "before")
+	t.Setenv("REMOVE", "x")
+
+	restore := Snapshot()
+
+	t.Setenv("CHANGE", "after")
+	unsetenv(t, "REMOVE")
+	t.Setenv("ADDED", "y")
+
+	restore()
+
+	expected := map[string]string{"KEEP": "1", "CHANGE": "before", "REMOVE": "x"}
+	if got := environ(); len(got) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	for key, value := range expected {
+		if got, ok := os.LookupEnv(key); !ok || got != value {
+			t.Errorf("Expected %s=%s, got %q", key, value, got)
+		}
+	}
+	if _, ok := os.LookupEnv("ADDED"); ok {
+		t.Error("Expected ADDED to be unset")
+	}
+}
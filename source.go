@@ -0,0 +1,276 @@
+package env
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source provides values for environment variable names.
+//
+// Parse reads every variable through a Source, so configuration can come
+// from anywhere a Source can be written for: the process environment, files,
+// remote stores or test fixtures. Implementations outside this package are
+// expected to pass the checks in the sourcetest package.
+type Source interface {
+	// Lookup returns the value stored under key and whether it is present.
+	// A key that is present with an empty value must be reported as present.
+	// Lookup must be safe for concurrent use.
+	Lookup(key string) (string, bool)
+}
+
+// Watcher is a Source whose values can change while the program runs.
+type Watcher interface {
+	Source
+
+	// Watch blocks until ctx is done, calling changed every time values of
+	// the source may have changed. It returns ctx.Err() once ctx is done,
+	// or another error if watching is no longer possible.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// Leaser is a Source whose values are leased and stop being valid after a
+// while, such as dynamic secrets issued by Vault. Reloadable refreshes values
+// read from a Leaser before their leases end.
+type Leaser interface {
+	Source
+
+	// LookupLease is like Lookup but also returns how long the value
+	// remains valid. A ttl of 0 means the value does not expire.
+	LookupLease(key string) (value string, ttl time.Duration, ok bool)
+}
+
+// BatchSource is a Source that can look up many keys at once, such as a
+// remote store that would otherwise need one round trip per key. Parse
+// collects the names of all the variables a config may read and looks them
+// up in a single LookupBatch call before setting any field.
+type BatchSource interface {
+	Source
+
+	// LookupBatch returns the values of the keys that are present.
+	LookupBatch(keys []string) map[string]string
+}
+
+// OS returns the Source backed by the process environment.
+func OS() Source {
+	return osSource{}
+}
+
+type osSource struct{}
+
+func (osSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (osSource) String() string {
+	return "os"
+}
+
+// MapSource returns a Source holding a copy of values, for configuration
+// loaded from anywhere else, such as a decrypted blob or a JSON document,
+// to go through Parse like the environment does.
+func MapSource(values map[string]string) Source {
+	m := make(mapValues, len(values))
+	for key, value := range values {
+		m[key] = value
+	}
+	return m
+}
+
+type mapValues map[string]string
+
+func (m mapValues) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func (mapValues) String() string {
+	return "map"
+}
+
+// Chain returns a Source that looks keys up in sources in order and
+// returns the first value found, so that precedence is declared in one
+// place:
+//
+//	src := env.Chain(env.OS(), dotenvSource, defaults)
+//
+// The Source is a Leaser and a BatchSource: leases of values read from a
+// Leaser are kept, and Parse looks keys up in a BatchSource of the chain
+// in one call, as it would if the sources were given to it directly.
+func Chain(sources ...Source) Source {
+	return chain(append([]Source(nil), sources...))
+}
+
+type chain []Source
+
+func (c chain) Lookup(key string) (string, bool) {
+	value, _, ok := c.LookupLease(key)
+	return value, ok
+}
+
+// LookupLease implements Leaser, returning the lease of the value if it
+// came from a Leaser.
+func (c chain) LookupLease(key string) (string, time.Duration, bool) {
+	for _, s := range c {
+		if value, ttl, ok := lookupSource(s, key); ok {
+			return value, ttl, true
+		}
+	}
+	return "", 0, false
+}
+
+// LookupBatch implements BatchSource.
+func (c chain) LookupBatch(keys []string) map[string]string {
+	return batchValues(c.lookupBatch(keys))
+}
+
+func (c chain) batches() bool {
+	return anyBatches(c)
+}
+
+func (c chain) lookupBatch(keys []string) map[string]cachedLookup {
+	return lookupBatchIn(c, keys)
+}
+
+func (c chain) watchers() []Watcher {
+	return watchersIn(c)
+}
+
+func (c chain) String() string {
+	names := make([]string, len(c))
+	for i, s := range c {
+		names[i] = sourceName(s)
+	}
+	return "chain(" + strings.Join(names, ", ") + ")"
+}
+
+// composite is implemented by the sources that combine others, Chain and
+// Resolver. They implement BatchSource whatever they hold, so batches
+// reports whether any of them actually batches, and lookupBatch keeps the
+// leases of the values. watchers returns the Watchers they hold.
+type composite interface {
+	batches() bool
+	lookupBatch(keys []string) map[string]cachedLookup
+	watchers() []Watcher
+}
+
+// lookupBatch looks keys up in s at once if s batches lookups, keeping
+// their leases if s is a composite. ok is false if s does not batch.
+func lookupBatch(s Source, keys []string) (values map[string]cachedLookup, ok bool) {
+	switch b := s.(type) {
+	case composite:
+		if !b.batches() {
+			return nil, false
+		}
+		return b.lookupBatch(keys), true
+	case BatchSource:
+		batch := b.LookupBatch(keys)
+		values = make(map[string]cachedLookup, len(batch))
+		for key, value := range batch {
+			values[key] = cachedLookup{value: value, ok: true}
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// anyBatches reports whether any of sources batches lookups.
+func anyBatches(sources []Source) bool {
+	for _, s := range sources {
+		switch b := s.(type) {
+		case composite:
+			if b.batches() {
+				return true
+			}
+		case BatchSource:
+			return true
+		}
+	}
+	return false
+}
+
+// watchersIn returns the Watchers among sources, including those held by
+// composites.
+func watchersIn(sources []Source) []Watcher {
+	var watchers []Watcher
+	for _, s := range sources {
+		switch w := s.(type) {
+		case composite:
+			watchers = append(watchers, w.watchers()...)
+		case Watcher:
+			watchers = append(watchers, w)
+		}
+	}
+	return watchers
+}
+
+// lookupBatchIn looks keys up in sources in order, each key in the first
+// source that has it, batching the lookups of the sources that batch.
+func lookupBatchIn(sources []Source, keys []string) map[string]cachedLookup {
+	found := make(map[string]cachedLookup, len(keys))
+	for _, s := range sources {
+		var remaining []string
+		for _, key := range keys {
+			if _, ok := found[key]; !ok {
+				remaining = append(remaining, key)
+			}
+		}
+		if len(remaining) == 0 {
+			break
+		}
+
+		if values, ok := lookupBatch(s, remaining); ok {
+			for key, c := range values {
+				found[key] = c
+			}
+			continue
+		}
+		for _, key := range remaining {
+			if value, ttl, ok := lookupSource(s, key); ok {
+				found[key] = cachedLookup{value: value, ttl: ttl, ok: true}
+			}
+		}
+	}
+	return found
+}
+
+// batchValues returns the values of found, without their leases.
+func batchValues(found map[string]cachedLookup) map[string]string {
+	values := make(map[string]string, len(found))
+	for key, c := range found {
+		values[key] = c.value
+	}
+	return values
+}
+
+var defaultSource struct {
+	sync.RWMutex
+	Source
+}
+
+// SetDefaultSource makes the Get, Lookup and Must functions read from s
+// instead of the process environment, for example to serve them from a
+// .env file or a remote store. Parse is unaffected; it reads from the
+// sources given to it. A nil s restores the process environment.
+//
+// SetDefaultSource is meant to be called once, early in main, or by tests
+// that restore the previous source when they are done.
+func SetDefaultSource(s Source) {
+	defaultSource.Lock()
+	defer defaultSource.Unlock()
+	defaultSource.Source = s
+}
+
+// lookupEnv looks key up in the default source.
+func lookupEnv(key string) (string, bool) {
+	defaultSource.RLock()
+	s := defaultSource.Source
+	defaultSource.RUnlock()
+
+	if s == nil {
+		return os.LookupEnv(key)
+	}
+	return s.Lookup(key)
+}
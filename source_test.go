@@ -0,0 +1,199 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mapSource map[string]string
+
+func (m mapSource) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func TestWithSource(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOST", "from-os")
+
+	var config Config
+	err := Parse(&config, WithSource(mapSource{"HOST": "from-source", "PORT": "8080"}))
+	if err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	expectedConfig := Config{Port: 8080, Host: "from-os"}
+	if config != expectedConfig {
+		t.Errorf("Parsed config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, config)
+	}
+}
+
+// batchSource is a BatchSource that counts its calls.
+type batchSource struct {
+	mapSource
+	lookups, batches int
+	keys             []string
+}
+
+func (s *batchSource) Lookup(key string) (string, bool) {
+	s.lookups++
+	return s.mapSource.Lookup(key)
+}
+
+func (s *batchSource) LookupBatch(keys []string) map[string]string {
+	s.batches++
+	s.keys = keys
+	values := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := s.mapSource[key]; ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+func TestBatchSource(t *testing.T) {
+	type Config struct {
+		Host  string     `env:"HOST"`
+		Port  int        `env:"PORT" fallback:"HTTP_PORT"`
+		Debug bool       `env:"DEBUG" default:"false"`
+		Token Credential `env:"TOKEN"`
+	}
+
+	src := &batchSource{mapSource: mapSource{"HOST": "db", "HTTP_PORT": "5432", "TOKEN": "t"}}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if config.Host != "db" || config.Port != 5432 || config.Token.Value != "t" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	if src.batches != 1 || src.lookups != 0 {
+		t.Errorf("Expected a single batch lookup, got %d batches and %d lookups", src.batches, src.lookups)
+	}
+
+	expected := []string{"HOST", "PORT", "HTTP_PORT", "DEBUG", "TOKEN", "TOKEN_EXPIRES_AT"}
+	if !reflect.DeepEqual(src.keys, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, src.keys)
+	}
+}
+
+func TestSetDefaultSource(t *testing.T) {
+	clearenv(t)
+	t.Setenv("PORT", "1")
+
+	SetDefaultSource(mapSource{"PORT": "8080", "HOSTS": "a,b"})
+	defer SetDefaultSource(nil)
+
+	if port, ok := GetInt("PORT"); !ok || port != 8080 {
+		t.Errorf("Expected the port from the default source, got %d", port)
+	}
+	if hosts := GetStringSlice("HOSTS", ",", nil); !reflect.DeepEqual(hosts, []string{"a", "b"}) {
+		t.Errorf("Expected the hosts from the default source, got %v", hosts)
+	}
+
+	SetDefaultSource(nil)
+	if port, ok := GetInt("PORT"); !ok || port != 1 {
+		t.Errorf("Expected the port from the process environment, got %d", port)
+	}
+}
+
+func TestChain(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOST", "from-os")
+
+	src := Chain(OS(), mapSource{"HOST": "from-file", "PORT": "8080"}, mapSource{"PORT": "80", "DEBUG": "true"})
+
+	for key, expected := range map[string]string{"HOST": "from-os", "PORT": "8080", "DEBUG": "true"} {
+		if value, ok := src.Lookup(key); !ok || value != expected {
+			t.Errorf("Expected %s=%s, got %q", key, expected, value)
+		}
+	}
+	if _, ok := src.Lookup("MISSING"); ok {
+		t.Error("Expected MISSING not to be found")
+	}
+
+	if s := src.(fmt.Stringer).String(); s != "chain(os, env.mapSource, env.mapSource)" {
+		t.Errorf("Unexpected name %q", s)
+	}
+}
+
+func TestChain_Batch(t *testing.T) {
+	batch := &batchSource{mapSource: mapSource{"HOST": "from-batch", "PORT": "8080"}}
+	src := Chain(mapSource{"HOST": "from-file"}, batch)
+
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if config.Host != "from-file" || config.Port != 8080 {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	if batch.batches != 1 || batch.lookups != 0 {
+		t.Errorf("Expected a single batch lookup, got %d batches and %d lookups", batch.batches, batch.lookups)
+	}
+	if expected := []string{"PORT"}; !reflect.DeepEqual(batch.keys, expected) {
+		t.Errorf("Expected only the keys missing from the first source to be batched, got %v", batch.keys)
+	}
+}
+
+func TestChain_Lease(t *testing.T) {
+	leased := &leaseSource{values: map[string]string{"TOKEN": "t"}, ttl: time.Minute}
+	batch := &batchSource{mapSource: mapSource{"HOST": "db"}}
+	src := Chain(leased, batch)
+
+	if value, ttl, ok := src.(Leaser).LookupLease("TOKEN"); !ok || value != "t" || ttl != time.Minute {
+		t.Errorf("Expected the lease of TOKEN, got %q %v %v", value, ttl, ok)
+	}
+	if _, ttl, ok := src.(Leaser).LookupLease("HOST"); !ok || ttl != 0 {
+		t.Errorf("Expected HOST not to be leased, got %v %v", ttl, ok)
+	}
+
+	type Config struct {
+		Token string `env:"TOKEN"`
+		Host  string `env:"HOST"`
+	}
+	leases := map[string]time.Duration{}
+	o := newOptions([]Option{WithNoOSEnv(), WithSource(src)})
+	o.onLease = func(key string, ttl time.Duration) { leases[key] = ttl }
+
+	var config Config
+	if err := parseWith(o, &config); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if expected := map[string]time.Duration{"TOKEN": time.Minute}; !reflect.DeepEqual(leases, expected) {
+		t.Errorf("Expected the lease to survive the batch lookup, got %v", leases)
+	}
+	if batch.batches != 1 {
+		t.Errorf("Expected a batch lookup, got %d", batch.batches)
+	}
+}
+
+func TestMapSource(t *testing.T) {
+	values := map[string]string{"HOST": "db", "PORT": "5432", "EMPTY": ""}
+	src := MapSource(values)
+	values["HOST"] = "changed"
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if expected := (Config{Host: "db", Port: 5432}); config != expected {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+
+	if value, ok := src.Lookup("EMPTY"); !ok || value != "" {
+		t.Errorf("Expected an empty value to be present, got %q, %v", value, ok)
+	}
+}
@@ -0,0 +1,153 @@
+// Package sourcetest checks that an env.Source implementation behaves the way
+// Parse expects.
+//
+// A source author calls Run from a test with a function that builds the
+// source under test pre-populated with a set of variables:
+//
+//	func TestSource(t *testing.T) {
+//		sourcetest.Run(t, func(t *testing.T, vars map[string]string) env.Source {
+//			return mysource.New(vars)
+//		})
+//	}
+//
+// Sources that implement env.Watcher are additionally checked to stop
+// watching when their context is cancelled, and, if they implement Setter,
+// to report changes made through it.
+package sourcetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caleflat/env"
+)
+
+// Factory returns a new source holding exactly vars.
+type Factory func(t *testing.T, vars map[string]string) env.Source
+
+// Setter is implemented by sources whose values can be changed while they
+// are in use. Run uses it to check that watchers report changes.
+type Setter interface {
+	Set(key, value string)
+}
+
+// Timeout bounds how long Run waits for a watcher to react.
+var Timeout = 5 * time.Second
+
+// Run runs the conformance checks against sources created by newSource.
+func Run(t *testing.T, newSource Factory) {
+	t.Run("Lookup", func(t *testing.T) {
+		testLookup(t, newSource)
+	})
+	t.Run("EmptyValue", func(t *testing.T) {
+		testEmptyValue(t, newSource)
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		testConcurrent(t, newSource)
+	})
+	t.Run("Watch", func(t *testing.T) {
+		testWatch(t, newSource)
+	})
+}
+
+func testLookup(t *testing.T, newSource Factory) {
+	vars := map[string]string{
+		"SOURCETEST_HOST": "localhost",
+		"SOURCETEST_PORT": "8080",
+	}
+	s := newSource(t, vars)
+
+	for key, want := range vars {
+		got, ok := s.Lookup(key)
+		if !ok {
+			t.Errorf("Lookup(%q) reported missing, expected %q", key, want)
+		} else if got != want {
+			t.Errorf("Lookup(%q) = %q, expected %q", key, got, want)
+		}
+	}
+
+	if got, ok := s.Lookup("SOURCETEST_MISSING"); ok {
+		t.Errorf("Lookup of a missing key reported present with %q", got)
+	}
+}
+
+func testEmptyValue(t *testing.T, newSource Factory) {
+	s := newSource(t, map[string]string{"SOURCETEST_EMPTY": ""})
+
+	got, ok := s.Lookup("SOURCETEST_EMPTY")
+	if !ok {
+		t.Error("Lookup of a key set to the empty string reported missing")
+	} else if got != "" {
+		t.Errorf("Lookup of a key set to the empty string returned %q", got)
+	}
+}
+
+func testConcurrent(t *testing.T, newSource Factory) {
+	s := newSource(t, map[string]string{"SOURCETEST_HOST": "localhost"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got, ok := s.Lookup("SOURCETEST_HOST"); !ok || got != "localhost" {
+					t.Errorf("Concurrent Lookup returned %q, %v", got, ok)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func testWatch(t *testing.T, newSource Factory) {
+	s := newSource(t, map[string]string{"SOURCETEST_HOST": "localhost"})
+
+	w, ok := s.(env.Watcher)
+	if !ok {
+		t.Skip("source does not implement env.Watcher")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	if setter, ok := s.(Setter); ok {
+		setter.Set("SOURCETEST_HOST", "example.com")
+
+		select {
+		case <-changed:
+		case <-time.After(Timeout):
+			t.Fatal("Watch did not report a change made through Set")
+		}
+
+		if got, _ := s.Lookup("SOURCETEST_HOST"); got != "example.com" {
+			t.Errorf("Lookup after a reported change returned %q, expected %q", got, "example.com")
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Watch returned %v after cancellation, expected context.Canceled", err)
+		}
+	case <-time.After(Timeout):
+		t.Fatal("Watch did not return after its context was cancelled")
+	}
+}
@@ -0,0 +1,60 @@
+package sourcetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/caleflat/env"
+)
+
+func TestOS(t *testing.T) {
+	Run(t, func(t *testing.T, vars map[string]string) env.Source {
+		for key, value := range vars {
+			t.Setenv(key, value)
+		}
+		return env.OS()
+	})
+}
+
+// watchSource is a minimal env.Watcher used to exercise the watch checks.
+type watchSource struct {
+	mu      sync.Mutex
+	vars    map[string]string
+	changed chan struct{}
+}
+
+func (s *watchSource) Lookup(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.vars[key]
+	return value, ok
+}
+
+func (s *watchSource) Set(key, value string) {
+	s.mu.Lock()
+	s.vars[key] = value
+	s.mu.Unlock()
+	s.changed <- struct{}{}
+}
+
+func (s *watchSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		select {
+		case <-s.changed:
+			changed()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	Run(t, func(t *testing.T, vars map[string]string) env.Source {
+		s := &watchSource{vars: map[string]string{}, changed: make(chan struct{}, 1)}
+		for key, value := range vars {
+			s.vars[key] = value
+		}
+		return s
+	})
+}
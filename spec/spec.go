@@ -0,0 +1,199 @@
+// Package spec describes the environment variables a config struct reads,
+// as declared by its struct tags. It is the model shared by env.Parse and
+// the tools built around it, such as generators of documentation and
+// examples, so that they all agree on which variables a struct reads and
+// how:
+//
+//	vars, err := spec.For(&config)
+//	for _, v := range vars {
+//		fmt.Println(v.Name, v.Type, v.Description)
+//	}
+package spec
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Tag is the struct tag naming the variable a field reads.
+const Tag = "env"
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Variable describes an environment variable read by a config field.
+type Variable struct {
+	// Name is the variable, including the prefixes of nested structs.
+	Name string
+	// Fallbacks are older names consulted in order when Name is not set,
+	// from the `fallback` tag.
+	Fallbacks []string
+	// Type is the Go type of the field, such as "int" or "time.Duration".
+	Type string
+	// Default is the `default` tag, or nil if the field has none.
+	Default *string
+	// Required is true if the field has no default tag. A field that holds
+	// a value before it is parsed, for example one set by a SetDefaults
+	// method, does not need its variable either.
+	Required bool
+	// Secret is true if the field holds a secret: it is tagged
+	// `secret:"true"` or `secret:"once"`, or is an env.Secret.
+	Secret bool
+	// Description is the `desc` tag.
+	Description string
+
+	// OneOf lists the values allowed by the `oneof` tag.
+	OneOf []string
+	// Min and Max are the `min` and `max` tags.
+	Min, Max string
+	// Match is the regular expression of the `match` tag.
+	Match string
+
+	// Field names the field, such as "main.Config.DB.DSN".
+	Field string
+	// Index is the index sequence of the field for
+	// reflect.Value.FieldByIndex.
+	Index []int
+	// StructField is the field itself, for reading other tags.
+	StructField reflect.StructField
+}
+
+// For returns the variables read by config, a struct or a pointer to one.
+func For(config interface{}) ([]Variable, error) {
+	t := reflect.TypeOf(config)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("config must be a struct or a pointer to one")
+	}
+	return Of(t)
+}
+
+// Of returns the variables read by the fields of the struct type t,
+// including those of nested structs, in declaration order.
+//
+// A nested struct field, one whose pointer does not implement
+// encoding.TextUnmarshaler, contributes the variables of its own fields,
+// prefixed with its env tag and an underscore if it has one. Other fields
+// read the variable named by their env tag, and fields without one are
+// skipped. Of reports an error for unexported fields with an env tag, or
+// unexported nested structs with tagged fields, along with the variables of
+// the other fields.
+func Of(t reflect.Type) ([]Variable, error) {
+	var vars []Variable
+	err := collect(t, "", t.String(), nil, &vars)
+	return vars, err
+}
+
+func collect(t reflect.Type, prefix, path string, index []int, vars *[]Variable) error {
+	var err error
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get(Tag)
+
+		if !field.IsExported() {
+			if (name != "" || field.Type.Kind() == reflect.Struct && hasTags(field.Type)) && err == nil {
+				err = errors.New("env tag on unexported field: " + t.String() + "." + field.Name)
+			}
+			continue
+		}
+
+		fieldPath := path + "." + field.Name
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if IsNested(field.Type) {
+			if nestedErr := collect(field.Type, JoinKey(prefix, name), fieldPath, fieldIndex, vars); err == nil {
+				err = nestedErr
+			}
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+
+		*vars = append(*vars, newVariable(field, JoinKey(prefix, name), fieldPath, fieldIndex))
+	}
+	return err
+}
+
+func newVariable(field reflect.StructField, name, path string, index []int) Variable {
+	v := Variable{
+		Name:        name,
+		Type:        field.Type.String(),
+		Description: field.Tag.Get("desc"),
+		Min:         field.Tag.Get("min"),
+		Max:         field.Tag.Get("max"),
+		Match:       field.Tag.Get("match"),
+		Field:       path,
+		Index:       index,
+		StructField: field,
+	}
+
+	if def, ok := field.Tag.Lookup("default"); ok {
+		v.Default = &def
+	}
+	v.Required = v.Default == nil
+
+	secret := field.Tag.Get("secret")
+	v.Secret = secret == "true" || secret == "once" || isSecretType(field.Type)
+
+	if fallback := field.Tag.Get("fallback"); fallback != "" {
+		v.Fallbacks = splitList(fallback)
+	}
+	if oneof := field.Tag.Get("oneof"); oneof != "" {
+		v.OneOf = splitList(oneof)
+	}
+	return v
+}
+
+// isSecretType reports whether t is env.Secret, which is always secret.
+func isSecretType(t reflect.Type) bool {
+	return t.PkgPath() == "github.com/caleflat/env" && t.Name() == "Secret"
+}
+
+// IsNested reports whether t is a struct whose fields are read one by one,
+// rather than a value read from a single variable.
+func IsNested(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// JoinKey joins a prefix and a variable name with an underscore.
+func JoinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return prefix + "_" + name
+}
+
+// hasTags reports whether the struct type t or any struct nested in it has a
+// field with an env tag.
+func hasTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get(Tag) != "" {
+			return true
+		}
+		if field.Type.Kind() == reflect.Struct && hasTags(field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated tag, trimming white space from each
+// element and dropping empty ones.
+func splitList(value string) []string {
+	var list []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
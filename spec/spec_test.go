@@ -0,0 +1,89 @@
+package spec_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caleflat/env"
+	"github.com/caleflat/env/spec"
+)
+
+func TestFor(t *testing.T) {
+	type DB struct {
+		DSN      string     `env:"DSN" desc:"Database connection string"`
+		Password env.Secret `env:"PASSWORD"`
+	}
+
+	type Config struct {
+		Port    int           `env:"PORT" fallback:"HTTP_PORT, LISTEN_PORT" default:"8080" min:"1" max:"65535"`
+		Level   string        `env:"LEVEL" oneof:"debug, info" match:"^[a-z]+$"`
+		Token   string        `env:"TOKEN" secret:"true"`
+		Timeout time.Duration `env:"TIMEOUT" default:""`
+		DB      DB            `env:"DB"`
+		Ignored string
+	}
+
+	vars, err := spec.For(&Config{})
+	if err != nil {
+		t.Fatalf("Failed to describe Config: %v", err)
+	}
+
+	def, empty := "8080", ""
+	expected := []spec.Variable{
+		{Name: "PORT", Fallbacks: []string{"HTTP_PORT", "LISTEN_PORT"}, Type: "int", Default: &def, Min: "1", Max: "65535"},
+		{Name: "LEVEL", Type: "string", Required: true, OneOf: []string{"debug", "info"}, Match: "^[a-z]+$"},
+		{Name: "TOKEN", Type: "string", Required: true, Secret: true},
+		{Name: "TIMEOUT", Type: "time.Duration", Default: &empty},
+		{Name: "DB_DSN", Type: "string", Required: true, Description: "Database connection string"},
+		{Name: "DB_PASSWORD", Type: "env.Secret", Required: true, Secret: true},
+	}
+	if len(vars) != len(expected) {
+		t.Fatalf("Expected %d variables, got %d", len(expected), len(vars))
+	}
+
+	for i, v := range vars {
+		if !strings.HasPrefix(v.Field, "spec_test.Config.") || len(v.Index) == 0 || v.StructField.Name == "" {
+			t.Errorf("Expected %s to locate its field, got %q, %v", v.Name, v.Field, v.Index)
+		}
+		v.Field, v.Index, v.StructField = "", nil, reflect.StructField{}
+		if !reflect.DeepEqual(v, expected[i]) {
+			t.Errorf("Expected %+v, got %+v", expected[i], v)
+		}
+	}
+
+	if vars[4].Field != "spec_test.Config.DB.DSN" || !reflect.DeepEqual(vars[4].Index, []int{4, 0}) {
+		t.Errorf("Unexpected location of DB_DSN: %s %v", vars[4].Field, vars[4].Index)
+	}
+}
+
+func TestFor_Errors(t *testing.T) {
+	if _, err := spec.For(42); err == nil {
+		t.Error("Expected an error for a non-struct")
+	}
+
+	type Config struct {
+		Port int    `env:"PORT"`
+		host string `env:"HOST"`
+	}
+	vars, err := spec.For(Config{})
+	if err == nil || err.Error() != "env tag on unexported field: spec_test.Config.host" {
+		t.Errorf("Expected an error naming the unexported field, got %v", err)
+	}
+	if len(vars) != 1 || vars[0].Name != "PORT" {
+		t.Errorf("Expected the other variables to be returned, got %+v", vars)
+	}
+}
+
+func TestJoinKey(t *testing.T) {
+	for _, tt := range [][3]string{
+		{"", "PORT", "PORT"},
+		{"DB", "", "DB"},
+		{"DB", "DSN", "DB_DSN"},
+	} {
+		if got := spec.JoinKey(tt[0], tt[1]); got != tt[2] {
+			t.Errorf("Expected %q, got %q", tt[2], got)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package env
+
+import "time"
+
+// GetTime returns the value of the environment variable named by the key
+// parsed as a time with layout, such as time.RFC3339.
+// If the variable is not present or cannot be parsed, def is returned.
+func GetTime(key, layout string, def time.Time) time.Time {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		malformed(key, err)
+		return def
+	}
+
+	return t
+}
+
+// GetTimeE returns the value of the environment variable named by the key
+// parsed as a time with layout.
+func GetTimeE(key, layout string) (time.Time, error) {
+	return getE(key, func(key string) (time.Time, bool, error) {
+		return lookupAs(key, func(value string) (time.Time, error) {
+			return time.Parse(layout, value)
+		})
+	})
+}
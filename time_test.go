@@ -0,0 +1,48 @@
+package env
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetTime(t *testing.T) {
+	clearenv(t)
+	t.Setenv("NOT_BEFORE", "2024-03-01T12:00:00Z")
+	t.Setenv("RELEASE_DATE", "2024-03-01")
+	t.Setenv("BROKEN", "yesterday")
+
+	expected := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if got := GetTime("NOT_BEFORE", time.RFC3339, time.Time{}); !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	expected = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got := GetTime("RELEASE_DATE", time.DateOnly, time.Time{}); !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	def := time.Unix(0, 0)
+	if got := GetTime("BROKEN", time.RFC3339, def); !got.Equal(def) {
+		t.Errorf("Expected the default for a malformed value, got %v", got)
+	}
+	if got := GetTime("MISSING", time.RFC3339, def); !got.Equal(def) {
+		t.Errorf("Expected the default for a missing value, got %v", got)
+	}
+}
+
+func TestGetTimeE(t *testing.T) {
+	clearenv(t)
+	t.Setenv("NOT_BEFORE", "2024-03-01T12:00:00Z")
+	t.Setenv("BROKEN", "yesterday")
+
+	if _, err := GetTimeE("NOT_BEFORE", time.RFC3339); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := GetTimeE("BROKEN", time.RFC3339); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+	if _, err := GetTimeE("MISSING", time.RFC3339); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
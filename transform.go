@@ -0,0 +1,133 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+)
+
+// A Transformer rewrites the raw value of a variable before it is
+// converted to the type of its field, for example to trim, decrypt or
+// decode it.
+type Transformer func(value string) (string, error)
+
+var (
+	transformersMu sync.RWMutex
+	transformers   = map[string]Transformer{
+		"trim":   func(value string) (string, error) { return strings.TrimSpace(value), nil },
+		"lower":  func(value string) (string, error) { return strings.ToLower(value), nil },
+		"upper":  func(value string) (string, error) { return strings.ToUpper(value), nil },
+		"base64": decodeBase64String,
+	}
+)
+
+// RegisterTransformer makes t available under name to the `transform` tag
+// and WithTransformers, replacing any transformer registered under the same
+// name. The transformers built in are:
+//
+//	trim    removes leading and trailing white space
+//	lower   converts to lower case
+//	upper   converts to upper case
+//	expand  replaces ${VAR} and $VAR with the values of other variables
+//	base64  decodes standard or URL-safe base64, padded or not
+//
+// expand reads other variables through the options of each Parse, so it
+// cannot be replaced: RegisterTransformer panics if name is "expand".
+// RegisterTransformer is meant to be called from init functions.
+func RegisterTransformer(name string, t Transformer) {
+	if name == "expand" {
+		panic(errors.New("env: RegisterTransformer: " + name + " is built in and cannot be replaced"))
+	}
+
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+	transformers[name] = t
+}
+
+// WithTransformers applies the named transformers, in order, to the raw
+// value of every field before the transformers of its own `transform` tag:
+//
+//	type Config struct {
+//	  Key string `env:"SIGNING_KEY" transform:"base64"`
+//	}
+//
+//	err := env.Parse(&config, env.WithTransformers("trim", "expand"))
+//
+// Transformers also apply to `default` tags. Validation tags check the
+// transformed value.
+func WithTransformers(names ...string) Option {
+	return func(o *options) {
+		o.transformers = append(o.transformers, names...)
+	}
+}
+
+// checkTransformers reports the first of names that is not registered.
+func checkTransformers(names []string) error {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+
+	for _, name := range names {
+		if _, ok := transformers[name]; !ok && name != "expand" {
+			return errors.New("unknown transformer: " + name)
+		}
+	}
+	return nil
+}
+
+// transform applies the global transformers and those named by tag to the
+// raw value of env.
+func (o *options) transform(env, tag, raw string) (string, error) {
+	for _, name := range o.transformerNames(tag) {
+		var err error
+		if raw, err = o.applyTransformer(env, name, raw); err != nil {
+			return "", err
+		}
+	}
+	return raw, nil
+}
+
+// transformerNames returns the global transformers followed by those named
+// by tag.
+func (o *options) transformerNames(tag string) []string {
+	names := o.transformers
+	if tag != "" {
+		names = append(names[:len(names):len(names)], splitList(tag, ",")...)
+	}
+	return names
+}
+
+// applyTransformer applies the transformer name to the raw value of env.
+func (o *options) applyTransformer(env, name, raw string) (string, error) {
+	var t Transformer
+	if name == "expand" {
+		t = o.expand
+	} else {
+		transformersMu.RLock()
+		t = transformers[name]
+		transformersMu.RUnlock()
+	}
+	if t == nil {
+		return "", errors.New("unknown transformer for environment variable: " + env + ": " + name)
+	}
+
+	value, err := t(raw)
+	if err != nil {
+		return "", errors.New("invalid value for environment variable: " + env + ": " + name + ": " + err.Error())
+	}
+	return value, nil
+}
+
+// expand replaces references to variables in value with their values, read
+// from the sources.
+func (o *options) expand(value string) (string, error) {
+	return os.Expand(value, func(key string) string {
+		v, _ := o.lookup(key)
+		return v
+	}), nil
+}
+
+func decodeBase64String(value string) (string, error) {
+	b, err := decodeBase64(value)
+	return string(b), err
+}
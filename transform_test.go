@@ -0,0 +1,87 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTransformers(t *testing.T) {
+	type Config struct {
+		Dir   string `env:"DATA_DIR" default:"${HOME}/data" transform:"expand"`
+		Key   string `env:"SIGNING_KEY" transform:"base64"`
+		Level string `env:"LOG_LEVEL" transform:"lower" oneof:"debug,info"`
+	}
+
+	src := mapSource{
+		"HOME":        "/home/app",
+		"SIGNING_KEY": "  c2VjcmV0\n",
+		"LOG_LEVEL":   " INFO ",
+	}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src), WithTransformers("trim")); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	expected := Config{Dir: "/home/app/data", Key: "secret", Level: "info"}
+	if config != expected {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+}
+
+func TestRegisterTransformer_Expand(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering expand to panic")
+		}
+	}()
+	RegisterTransformer("expand", func(value string) (string, error) { return value, nil })
+}
+
+func TestRegisterTransformer(t *testing.T) {
+	RegisterTransformer("rot13-test", func(value string) (string, error) {
+		if value == "" {
+			return "", errors.New("empty")
+		}
+		return strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' {
+				return 'a' + (r-'a'+13)%26
+			}
+			return r
+		}, value), nil
+	})
+
+	type Config struct {
+		Word string `env:"WORD" transform:"rot13-test"`
+	}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(mapSource{"WORD": "uryyb"})); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if config.Word != "hello" {
+		t.Errorf("Expected hello, got %q", config.Word)
+	}
+
+	err := Parse(&config, WithNoOSEnv(), WithSource(mapSource{"WORD": ""}))
+	if err == nil || err.Error() != "invalid value for environment variable: WORD: rot13-test: empty" {
+		t.Errorf("Expected a transformer error, got %v", err)
+	}
+}
+
+func TestTransformers_Unknown(t *testing.T) {
+	type Config struct {
+		Word string `env:"WORD" transform:"trim,nope"`
+	}
+
+	err := Parse(&Config{}, WithNoOSEnv(), WithSource(mapSource{"WORD": "x"}))
+	if err == nil || err.Error() != "invalid transform tag for environment variable: WORD: unknown transformer: nope" {
+		t.Errorf("Expected an unknown transformer error, got %v", err)
+	}
+
+	err = Parse(&struct{}{}, WithTransformers("nope"))
+	if err == nil || err.Error() != "unknown transformer: nope" {
+		t.Errorf("Expected an unknown transformer error, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package env
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that marshals to and from text such as
+// "1m30s", so that a config parsed from the environment serializes to JSON
+// the way it was written rather than as nanoseconds.
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := parseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// String returns d formatted like time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Size is a number of bytes, written as a plain number or with a unit:
+// B, decimal KB, MB, GB and TB, binary KiB, MiB, GiB and TiB, or the
+// binary shorthands K, M, G and T. Units are case-insensitive and may have
+// a fraction, such as 1.5GiB.
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	n      int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+	{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9}, {"tb", 1e12},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+	{"b", 1},
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Size) UnmarshalText(text []byte) error {
+	v, err := parseSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = v
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Size) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// String returns s in the largest binary unit that represents it exactly,
+// such as "512MiB", or in bytes, such as "1500B".
+func (s Size) String() string {
+	for _, u := range []struct {
+		suffix string
+		n      Size
+	}{{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if s != 0 && s%u.n == 0 {
+			return strconv.FormatInt(int64(s/u.n), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(s), 10) + "B"
+}
+
+func parseSize(value string) (Size, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+
+	n := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, n = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.n
+			break
+		}
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if i < 0 || i > math.MaxInt64/n {
+			return 0, errors.New("size out of range: " + strconv.Quote(value))
+		}
+		return Size(i * n), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 || f*float64(n) >= math.MaxInt64 {
+		return 0, errors.New("invalid size: " + strconv.Quote(value))
+	}
+	return Size(f * float64(n)), nil
+}
+
+// URL is an absolute URL with a scheme and a host, such as
+// "https://api.example.com/v1", that marshals to and from its string form.
+type URL struct {
+	url.URL
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Empty text is the
+// zero URL, which MarshalText returns it for, so that optional URLs
+// survive a round trip.
+func (u *URL) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		u.URL = url.URL{}
+		return nil
+	}
+
+	v, err := parseURL(string(text))
+	if err != nil {
+		return err
+	}
+
+	u.URL = *v
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// String returns u in its string form.
+func (u URL) String() string {
+	return u.URL.String()
+}
@@ -0,0 +1,106 @@
+package env
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParse_WrapperTypes(t *testing.T) {
+	type Config struct {
+		Timeout  Duration `env:"TIMEOUT" json:"timeout"`
+		MaxBody  Size     `env:"MAX_BODY" json:"max_body"`
+		Endpoint URL      `env:"ENDPOINT" json:"endpoint"`
+	}
+
+	src := mapSource{"TIMEOUT": "1m30s", "MAX_BODY": "10MiB", "ENDPOINT": "https://api.example.com/v1"}
+
+	var config Config
+	if err := Parse(&config, WithNoOSEnv(), WithSource(src)); err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+
+	if time.Duration(config.Timeout) != 90*time.Second || config.MaxBody != 10<<20 || config.Endpoint.Host != "api.example.com" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	expected := `{"timeout":"1m30s","max_body":"10MiB","endpoint":"https://api.example.com/v1"}`
+	if string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	var decoded Config
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	if decoded.Timeout != config.Timeout || decoded.MaxBody != config.MaxBody || decoded.Endpoint.String() != config.Endpoint.String() {
+		t.Errorf("Expected %+v after round-tripping, got %+v", config, decoded)
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := map[string]Size{
+		"512":    512,
+		"512B":   512,
+		"10kb":   10000,
+		"10KiB":  10240,
+		"1.5GiB": 3 << 29,
+		"64m":    64 << 20,
+		"2 TB":   2e12,
+	}
+
+	for value, expected := range tests {
+		var s Size
+		if err := s.UnmarshalText([]byte(value)); err != nil {
+			t.Errorf("Failed to parse %q: %v", value, err)
+		} else if s != expected {
+			t.Errorf("Expected %d for %q, got %d", expected, value, s)
+		}
+	}
+
+	for _, value := range []string{"", "MB", "-1", "ten", "1.5.2K", "9999999999TiB", "nan", "NaN KiB", "inf", "+Inf", "-inf", "infinity"} {
+		var s Size
+		if err := s.UnmarshalText([]byte(value)); err == nil {
+			t.Errorf("Expected an error for %q, got %d", value, s)
+		}
+	}
+
+	for s, expected := range map[Size]string{0: "0B", 1500: "1500B", 2048: "2KiB", 3 << 30: "3GiB"} {
+		if got := s.String(); got != expected {
+			t.Errorf("Expected %q for %d, got %q", expected, int64(s), got)
+		}
+	}
+}
+
+func TestURL_Zero(t *testing.T) {
+	type Config struct {
+		Endpoint URL `json:"endpoint"`
+	}
+
+	b, err := json.Marshal(Config{})
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if expected := `{"endpoint":""}`; string(b) != expected {
+		t.Errorf("Expected %s, got %s", expected, b)
+	}
+
+	decoded := Config{Endpoint: URL{url.URL{Scheme: "https", Host: "stale"}}}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	if decoded != (Config{}) {
+		t.Errorf("Expected the zero URL, got %+v", decoded)
+	}
+
+	var u URL
+	if err := u.UnmarshalText([]byte("example.com")); err == nil {
+		t.Error("Expected an error for a URL without a scheme")
+	}
+}
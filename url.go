@@ -0,0 +1,36 @@
+package env
+
+import (
+	"errors"
+	"net/url"
+)
+
+// GetURL returns the value of the environment variable named by the key
+// parsed as an absolute URL with a scheme and a host, such as
+// "https://api.example.com/v1". If the variable is not present, def is
+// returned. If its value is not such a URL, an error is returned.
+func GetURL(key string, def *url.URL) (*url.URL, error) {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def, nil
+	}
+
+	u, err := parseURL(value)
+	if err != nil {
+		return nil, errors.New("invalid value for environment variable: " + key + ": " + err.Error())
+	}
+	return u, nil
+}
+
+func parseURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	switch {
+	case err != nil:
+		return nil, err
+	case u.Scheme == "":
+		return nil, errors.New("missing scheme")
+	case u.Host == "":
+		return nil, errors.New("missing host")
+	}
+	return u, nil
+}
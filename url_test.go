@@ -0,0 +1,31 @@
+package env
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestGetURL(t *testing.T) {
+	clearenv(t)
+	t.Setenv("API_URL", "https://api.example.com/v1")
+
+	u, err := GetURL("API_URL", nil)
+	if err != nil {
+		t.Fatalf("Failed to get URL: %v", err)
+	}
+	if u.Host != "api.example.com" || u.Path != "/v1" {
+		t.Errorf("Unexpected URL %v", u)
+	}
+
+	def := &url.URL{Scheme: "http", Host: "localhost"}
+	if u, err := GetURL("MISSING", def); err != nil || u != def {
+		t.Errorf("Expected the default, got %v, %v", u, err)
+	}
+
+	for _, value := range []string{"api.example.com", "/v1", "https://", "http://a b", "mailto:ops@example.com"} {
+		t.Setenv("API_URL", value)
+		if _, err := GetURL("API_URL", def); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
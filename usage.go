@@ -0,0 +1,151 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/caleflat/env/spec"
+)
+
+// Usage returns a description of the variables read by config, a struct or
+// a pointer to one, in the style of flag.PrintDefaults:
+//
+//	DB_HOST string
+//	    	Database host (default "localhost")
+//	DB_PASSWORD string (required, secret)
+//	LOG_LEVEL string
+//	    	one of debug, info, warn (default "info")
+//
+// Descriptions come from `desc` tags. Defaults come from `default` tags and
+// from SetDefaults methods; secret defaults are not shown. Usage panics if
+// config is not a struct or a pointer to one.
+func Usage(config interface{}) string {
+	var b strings.Builder
+	if err := WriteUsage(&b, config); err != nil {
+		panic("env: " + err.Error())
+	}
+	return b.String()
+}
+
+// WriteUsage writes the description of Usage to w.
+func WriteUsage(w io.Writer, config interface{}) error {
+	docs, err := describe(config)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, d := range docs {
+		b.WriteString("  " + d.Name + " " + d.Type)
+
+		var flags []string
+		if d.Required {
+			flags = append(flags, "required")
+		}
+		if d.Secret {
+			flags = append(flags, "secret")
+		}
+		if len(flags) > 0 {
+			b.WriteString(" (" + strings.Join(flags, ", ") + ")")
+		}
+		b.WriteString("\n")
+
+		var details []string
+		if d.Description != "" {
+			details = append(details, d.Description)
+		}
+		if len(d.allowed) > 0 {
+			details = append(details, "one of "+strings.Join(d.allowed, ", "))
+		}
+		if len(d.Fallbacks) > 0 {
+			details = append(details, "or "+strings.Join(d.Fallbacks, ", "))
+		}
+		if d.Default != nil && !d.Secret {
+			details = append(details, "(default "+strconv.Quote(*d.Default)+")")
+		}
+		if len(details) > 0 {
+			b.WriteString("    \t" + strings.ReplaceAll(strings.Join(details, " "), "\n", "\n    \t") + "\n")
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// varDoc is a variable as shown in generated documentation.
+type varDoc struct {
+	spec.Variable
+
+	// allowed lists the values the variable may hold, from its `oneof` or
+	// `envValues` tag.
+	allowed []string
+}
+
+// describe returns the variables read by config with the defaults set by
+// its SetDefaults methods filled in.
+func describe(config interface{}) ([]varDoc, error) {
+	vars, err := spec.For(config)
+	if err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf(config)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	preset := reflect.New(t)
+	callSetDefaults(preset)
+
+	var docs []varDoc
+	for _, v := range vars {
+		d := varDoc{Variable: v, allowed: v.OneOf}
+		if names := valueNames(v.StructField); names != nil {
+			d.allowed = names
+		}
+
+		value := preset.Elem().FieldByIndex(v.Index)
+		if d.Default == nil && !value.IsZero() {
+			def := formatValue(v.StructField, value)
+			d.Default, d.Required = &def, false
+		}
+		docs = append(docs, d)
+
+		if isCredential(v.StructField.Type) {
+			docs = append(docs, expiryDoc(v))
+		}
+	}
+	return docs, nil
+}
+
+// expiryDoc documents the variable holding the expiry of the Credential
+// read from v.
+func expiryDoc(v spec.Variable) varDoc {
+	return varDoc{Variable: spec.Variable{
+		Name:        v.Name + ExpirySuffix,
+		Type:        "time.Time",
+		Description: "Expiry of " + v.Name + ", as an RFC 3339 time or in seconds since the Unix epoch.",
+		Field:       v.Field,
+		Index:       v.Index,
+		StructField: v.StructField,
+	}}
+}
+
+// formatValue returns value, the value of field, as a variable would hold
+// it.
+func formatValue(field reflect.StructField, value reflect.Value) string {
+	if name, ok := valueName(field, value); ok {
+		return name
+	}
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if m, ok := value.Interface().(interface{ MarshalText() ([]byte, error) }); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,50 @@
+package env
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type usageConfig struct {
+	Host     string        `env:"DB_HOST" default:"localhost" desc:"Database host"`
+	Password Secret        `env:"DB_PASSWORD" default:"hunter2"`
+	Port     int           `env:"DB_PORT" fallback:"DATABASE_PORT"`
+	Level    string        `env:"LOG_LEVEL" oneof:"debug,info" default:"info"`
+	Priority int           `env:"PRIORITY" envValues:"low=1,high=10"`
+	Timeout  time.Duration `env:"TIMEOUT"`
+	Token    Credential    `env:"TOKEN"`
+}
+
+func (c *usageConfig) SetDefaults() {
+	c.Priority = 10
+	c.Timeout = 5 * time.Second
+}
+
+func TestUsage(t *testing.T) {
+	expected := `  DB_HOST string
+    	Database host (default "localhost")
+  DB_PASSWORD env.Secret (secret)
+  DB_PORT int (required)
+    	or DATABASE_PORT
+  LOG_LEVEL string
+    	one of debug, info (default "info")
+  PRIORITY int
+    	one of low, high (default "high")
+  TIMEOUT time.Duration
+    	(default "5s")
+  TOKEN env.Credential (required)
+  TOKEN_EXPIRES_AT time.Time
+    	Expiry of TOKEN, as an RFC 3339 time or in seconds since the Unix epoch.
+`
+	if usage := Usage(&usageConfig{}); usage != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, usage)
+	}
+}
+
+func TestWriteUsage_InvalidConfig(t *testing.T) {
+	var b strings.Builder
+	if err := WriteUsage(&b, 42); err == nil {
+		t.Error("Expected an error for a non-struct config")
+	}
+}
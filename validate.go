@@ -0,0 +1,190 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// patterns caches the compiled `match` patterns by their source.
+var patterns sync.Map
+
+// checkTags reports malformed validation tags on field, independently of
+// whether its variable is set.
+func checkTags(field reflect.StructField, env string) error {
+	if pattern := field.Tag.Get("match"); pattern != "" {
+		if _, err := compilePattern(pattern); err != nil {
+			return errors.New("invalid match tag for environment variable: " + env + ": " + err.Error())
+		}
+	}
+
+	if transform := field.Tag.Get("transform"); transform != "" {
+		if err := checkTransformers(splitList(transform, ",")); err != nil {
+			return errors.New("invalid transform tag for environment variable: " + env + ": " + err.Error())
+		}
+	}
+
+	if field.Tag.Get("secret") == "once" && field.Type != secretType {
+		return errors.New("secret:\"once\" requires a Secret field: " + env)
+	}
+
+	if values, ok := field.Tag.Lookup("envValues"); ok {
+		if err := checkValues(field, env, values); err != nil {
+			return err
+		}
+	}
+
+	if bits, ok := field.Tag.Lookup("bits"); ok {
+		if err := checkBits(field, env, bits); err != nil {
+			return err
+		}
+	}
+
+	if refresh := field.Tag.Get("refresh"); refresh != "" {
+		if d, err := parseDuration(refresh); err != nil || d <= 0 {
+			return errors.New("invalid refresh tag for environment variable: " + env + ": " + strconv.Quote(refresh))
+		}
+	}
+
+	return nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// validate checks the raw value of the environment variable env against the
+// validation tags of field.
+func validate(field reflect.StructField, env, raw string) error {
+	if oneof := field.Tag.Get("oneof"); oneof != "" {
+		if err := checkOneOf(env, raw, oneof); err != nil {
+			return err
+		}
+	}
+
+	if pattern := field.Tag.Get("match"); pattern != "" {
+		if err := checkMatch(env, raw, pattern); err != nil {
+			return err
+		}
+	}
+
+	min, max := field.Tag.Get("min"), field.Tag.Get("max")
+	if min != "" || max != "" {
+		if err := checkRange(field.Type, env, raw, min, max); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkOneOf reports an error if raw is not one of the comma-separated values
+// in oneof.
+func checkOneOf(env, raw, oneof string) error {
+	allowed := strings.Split(oneof, ",")
+	for i := range allowed {
+		allowed[i] = strings.TrimSpace(allowed[i])
+	}
+	return checkAllowed(env, raw, allowed)
+}
+
+// checkAllowed reports an error if raw is not one of allowed.
+func checkAllowed(env, raw string, allowed []string) error {
+	for _, a := range allowed {
+		if raw == a {
+			return nil
+		}
+	}
+
+	return errors.New("invalid value for environment variable: " + env +
+		": " + strconv.Quote(raw) + " is not one of " + strings.Join(allowed, ", "))
+}
+
+// checkMatch reports an error if raw does not match the regular expression
+// pattern.
+func checkMatch(env, raw, pattern string) error {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return errors.New("invalid match tag for environment variable: " + env + ": " + err.Error())
+	}
+
+	if !re.MatchString(raw) {
+		return errors.New("invalid value for environment variable: " + env +
+			": " + strconv.Quote(raw) + " does not match " + pattern)
+	}
+
+	return nil
+}
+
+// checkRange reports an error if raw, converted to the numeric or duration
+// type t, lies outside of the inclusive bounds min and max. Empty bounds are
+// not checked. Values that cannot be converted are left for setField to
+// report.
+func checkRange(t reflect.Type, env, raw, min, max string) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		return inRange(parseDuration, env, raw, min, max)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return inRange(parseInt, env, raw, min, max)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return inRange(parseUint, env, raw, min, max)
+	case reflect.Float32, reflect.Float64:
+		return inRange(parseFloat, env, raw, min, max)
+	}
+
+	return errors.New("min and max tags require a numeric or duration field: " + env)
+}
+
+type number interface {
+	~int64 | ~uint64 | ~float64
+}
+
+func inRange[T number](parse func(string) (T, error), env, raw, min, max string) error {
+	v, err := parse(raw)
+	if err != nil {
+		return nil
+	}
+
+	if min != "" {
+		m, err := parse(min)
+		if err != nil {
+			return errors.New("invalid min tag for environment variable: " + env)
+		}
+		if v < m {
+			return errors.New("invalid value for environment variable: " + env +
+				": " + raw + " is less than the minimum " + min)
+		}
+	}
+
+	if max != "" {
+		m, err := parse(max)
+		if err != nil {
+			return errors.New("invalid max tag for environment variable: " + env)
+		}
+		if v > m {
+			return errors.New("invalid value for environment variable: " + env +
+				": " + raw + " is greater than the maximum " + max)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package env
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_OneOf(t *testing.T) {
+	type Config struct {
+		LogFormat string `env:"LOG_FORMAT" oneof:"json, text, console"`
+	}
+
+	t.Setenv("LOG_FORMAT", "text")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	if config.LogFormat != "text" {
+		t.Errorf("Expected log format text, got %q", config.LogFormat)
+	}
+
+	t.Setenv("LOG_FORMAT", "xml")
+
+	config = Config{}
+	err := Parse(&config)
+	if err == nil {
+		t.Fatal("Expected an error for a value outside of oneof")
+	}
+
+	if !strings.Contains(err.Error(), "json, text, console") {
+		t.Errorf("Expected error to list the allowed values, got %q", err)
+	}
+
+	if config.LogFormat != "" {
+		t.Errorf("Expected field to stay unset, got %q", config.LogFormat)
+	}
+}
+
+func TestParse_MinMax(t *testing.T) {
+	type Config struct {
+		Workers int           `env:"WORKERS" min:"1" max:"256"`
+		Ratio   float64       `env:"RATIO" max:"1"`
+		Timeout time.Duration `env:"TIMEOUT" min:"1s" max:"1m"`
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"in range", map[string]string{"WORKERS": "256", "RATIO": "0.5", "TIMEOUT": "30s"}, ""},
+		{"below min", map[string]string{"WORKERS": "0", "RATIO": "0.5", "TIMEOUT": "30s"}, "WORKERS"},
+		{"above max", map[string]string{"WORKERS": "8", "RATIO": "1.5", "TIMEOUT": "30s"}, "RATIO"},
+		{"duration", map[string]string{"WORKERS": "8", "RATIO": "0.5", "TIMEOUT": "2m"}, "TIMEOUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearenv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var config Config
+			err := Parse(&config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Failed to parse environment variables: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error for %s, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParse_MinMaxInvalidTag(t *testing.T) {
+	type Config struct {
+		Name string `env:"NAME" min:"1"`
+	}
+
+	t.Setenv("NAME", "value")
+
+	var config Config
+	if err := Parse(&config); err == nil {
+		t.Error("Expected an error for min on a string field")
+	}
+}
+
+func TestParse_Match(t *testing.T) {
+	type Config struct {
+		Service string `env:"SERVICE" match:"^[a-z0-9-]+$"`
+	}
+
+	t.Setenv("SERVICE", "billing-api")
+
+	var config Config
+	if err := Parse(&config); err != nil {
+		t.Errorf("Failed to parse environment variables: %v", err)
+	}
+
+	t.Setenv("SERVICE", "Billing API")
+
+	config = Config{}
+	if err := Parse(&config); err == nil {
+		t.Error("Expected an error for a value not matching the pattern")
+	}
+}
+
+func TestParse_MatchInvalidPattern(t *testing.T) {
+	type Config struct {
+		Service string `env:"SERVICE" match:"^[a-z"`
+	}
+
+	clearenv(t)
+
+	var config Config
+	err := Parse(&config)
+	if err == nil || !strings.Contains(err.Error(), "invalid match tag") {
+		t.Errorf("Expected an invalid match tag error, got %v", err)
+	}
+}
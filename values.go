@@ -0,0 +1,115 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// namedValue is one name=value pair of an `envValues` tag.
+type namedValue struct {
+	name, value string
+}
+
+// parseValues parses an `envValues` tag such as "low=1,medium=5,high=10".
+func parseValues(tag string) ([]namedValue, error) {
+	var values []namedValue
+	seen := make(map[string]bool)
+	for _, pair := range splitList(tag, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, errors.New("malformed pair " + strconv.Quote(pair))
+		}
+		if seen[name] {
+			return nil, errors.New("duplicate name " + strconv.Quote(name))
+		}
+		seen[name] = true
+		values = append(values, namedValue{name, value})
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no values")
+	}
+	return values, nil
+}
+
+// checkValues reports a malformed `envValues` tag on field, or one whose
+// values cannot be stored in the field.
+func checkValues(field reflect.StructField, env, tag string) error {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return errors.New("envValues tag requires a numeric field: " + env)
+	}
+
+	values, err := parseValues(tag)
+	if err != nil {
+		return errors.New("invalid envValues tag for environment variable: " + env + ": " + err.Error())
+	}
+	for _, v := range values {
+		if err := setField(reflect.New(field.Type).Elem(), env, v.value); err != nil {
+			return errors.New("invalid envValues tag for environment variable: " + env + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+// mapValue returns the value the `envValues` tag of field maps the name raw
+// to, or raw if field has no such tag.
+func mapValue(field reflect.StructField, env, raw string) (string, error) {
+	tag := field.Tag.Get("envValues")
+	if tag == "" {
+		return raw, nil
+	}
+
+	values, _ := parseValues(tag) // checked by checkTags
+	names := make([]string, len(values))
+	for i, v := range values {
+		if raw == v.name {
+			return v.value, nil
+		}
+		names[i] = v.name
+	}
+
+	return "", errors.New("invalid value for environment variable: " + env +
+		": " + strconv.Quote(raw) + " is not one of " + strings.Join(names, ", "))
+}
+
+// valueName returns the name the `envValues` tag of field gives to value,
+// the reverse of mapValue, so that values are shown the way they are
+// written.
+func valueName(field reflect.StructField, value reflect.Value) (string, bool) {
+	values, err := parseValues(field.Tag.Get("envValues"))
+	if err != nil {
+		return "", false
+	}
+
+	for _, v := range values {
+		named := reflect.New(field.Type).Elem()
+		if setField(named, "", v.value) == nil && reflect.DeepEqual(reflect.Indirect(named).Interface(), reflect.Indirect(value).Interface()) {
+			return v.name, true
+		}
+	}
+	return "", false
+}
+
+// valueNames returns the names of the `envValues` tag of field, if any.
+func valueNames(field reflect.StructField) []string {
+	values, err := parseValues(field.Tag.Get("envValues"))
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = v.name
+	}
+	return names
+}
@@ -0,0 +1,91 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_EnvValues(t *testing.T) {
+	type Config struct {
+		Priority int      `env:"PRIORITY" envValues:"low=1,medium=5,high=10"`
+		Ratio    *float64 `env:"RATIO" envValues:"half=0.5, full=1" default:"full"`
+	}
+
+	var c Config
+	err := Parse(&c, WithNoOSEnv(), WithSource(mapSource{"PRIORITY": "high"}))
+	if err != nil {
+		t.Fatalf("Failed to parse environment variables: %v", err)
+	}
+	if c.Priority != 10 {
+		t.Errorf("Expected Priority to be 10, got %d", c.Priority)
+	}
+	if c.Ratio == nil || *c.Ratio != 1 {
+		t.Errorf("Expected Ratio to be 1, got %v", c.Ratio)
+	}
+
+	err = Parse(&c, WithNoOSEnv(), WithSource(mapSource{"PRIORITY": "10"}))
+	expected := `invalid value for environment variable: PRIORITY: "10" is not one of low, medium, high`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestParse_EnvValuesInvalidTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   interface{}
+		expected string
+	}{
+		{
+			name: "not numeric",
+			config: &struct {
+				Level string `env:"LEVEL" envValues:"low=1"`
+			}{},
+			expected: "envValues tag requires a numeric field: LEVEL",
+		},
+		{
+			name: "malformed",
+			config: &struct {
+				Level int `env:"LEVEL" envValues:"low=1,high"`
+			}{},
+			expected: `invalid envValues tag for environment variable: LEVEL: malformed pair "high"`,
+		},
+		{
+			name: "duplicate",
+			config: &struct {
+				Level int `env:"LEVEL" envValues:"low=1,low=2"`
+			}{},
+			expected: `invalid envValues tag for environment variable: LEVEL: duplicate name "low"`,
+		},
+		{
+			name: "not a number",
+			config: &struct {
+				Level int `env:"LEVEL" envValues:"low=1,high=lots"`
+			}{},
+			expected: "invalid envValues tag for environment variable: LEVEL: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Parse(tt.config, WithNoOSEnv(), WithSource(mapSource{"LEVEL": "low"}))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestExplain_EnvValues(t *testing.T) {
+	type Config struct {
+		Priority int `env:"PRIORITY" envValues:"low=1,high=10"`
+	}
+
+	r, err := Explain(&Config{}, "PRIORITY", WithNoOSEnv(), WithSource(mapSource{"PRIORITY": "urgent"}))
+	if err != nil {
+		t.Fatalf("Failed to explain PRIORITY: %v", err)
+	}
+	if r.Err == nil || !strings.Contains(r.Err.Error(), "is not one of low, high") {
+		t.Errorf("Expected an error naming the allowed words, got %v", r.Err)
+	}
+}
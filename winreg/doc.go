@@ -0,0 +1,34 @@
+// Package winreg provides an env.Source reading values from a key of the
+// Windows registry, a common configuration channel for Windows services.
+//
+// New and Source are only available on Windows; elsewhere the package only
+// declares Root, so that code naming it need not be gated itself:
+//
+//	src := winreg.New(winreg.LocalMachine, `SOFTWARE\MyCompany\MyService`)
+//	err := env.Parse(&config, env.WithSource(src))
+//
+// String values (REG_SZ and REG_EXPAND_SZ) are returned as stored;
+// environment references in REG_EXPAND_SZ values are expanded. Integer values
+// (REG_DWORD and REG_QWORD) are returned in decimal. Values of other types
+// are reported as missing.
+package winreg
+
+// Root is a predefined registry key under which keys are opened.
+type Root int
+
+const (
+	// LocalMachine is HKEY_LOCAL_MACHINE.
+	LocalMachine Root = iota
+	// CurrentUser is HKEY_CURRENT_USER.
+	CurrentUser
+)
+
+func (r Root) String() string {
+	switch r {
+	case LocalMachine:
+		return "HKLM"
+	case CurrentUser:
+		return "HKCU"
+	}
+	return "Root(?)"
+}
@@ -0,0 +1,133 @@
+//go:build windows
+
+package winreg
+
+import (
+	"encoding/binary"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/caleflat/env"
+)
+
+// Source is an env.Source reading the values of a registry key. The key is
+// opened on every lookup, so values and the key itself may change while the
+// program runs.
+type Source struct {
+	root Root
+	path string
+}
+
+var _ env.Source = (*Source)(nil)
+
+// New returns a Source reading the values of the key path under root.
+func New(root Root, path string) *Source {
+	return &Source{root: root, path: path}
+}
+
+// Lookup returns the value named key.
+func (s *Source) Lookup(key string) (string, bool) {
+	value, err := s.query(key)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+func (s *Source) String() string {
+	return "registry " + s.root.String() + `\` + s.path
+}
+
+func (s *Source) query(name string) (string, error) {
+	root := syscall.Handle(syscall.HKEY_LOCAL_MACHINE)
+	if s.root == CurrentUser {
+		root = syscall.HKEY_CURRENT_USER
+	}
+
+	path, err := syscall.UTF16PtrFromString(s.path)
+	if err != nil {
+		return "", err
+	}
+
+	var h syscall.Handle
+	if err := syscall.RegOpenKeyEx(root, path, 0, syscall.KEY_READ, &h); err != nil {
+		return "", err
+	}
+	defer syscall.RegCloseKey(h)
+
+	valueName, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return "", err
+	}
+
+	var typ, size uint32
+	if err := syscall.RegQueryValueEx(h, valueName, nil, &typ, nil, &size); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, size)
+	if size > 0 {
+		if err := syscall.RegQueryValueEx(h, valueName, nil, &typ, &buf[0], &size); err != nil {
+			return "", err
+		}
+		buf = buf[:size]
+	}
+
+	switch typ {
+	case syscall.REG_SZ:
+		return utf16String(buf), nil
+	case syscall.REG_EXPAND_SZ:
+		return expand(utf16String(buf)), nil
+	case syscall.REG_DWORD:
+		if len(buf) == 4 {
+			return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(buf)), 10), nil
+		}
+	case syscall.REG_QWORD:
+		if len(buf) == 8 {
+			return strconv.FormatUint(binary.LittleEndian.Uint64(buf), 10), nil
+		}
+	}
+
+	return "", syscall.ERROR_FILE_NOT_FOUND
+}
+
+// utf16String decodes a NUL-terminated UTF-16 registry string.
+func utf16String(buf []byte) string {
+	if len(buf) < 2 {
+		return ""
+	}
+	u := unsafe.Slice((*uint16)(unsafe.Pointer(&buf[0])), len(buf)/2)
+	return syscall.UTF16ToString(u)
+}
+
+// expand replaces the %NAME% references in s with the value of the
+// environment variable NAME. References to unset variables are kept, as
+// Windows does.
+func expand(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '%')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start+1:], '%')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+
+		b.WriteString(s[:start])
+		if value, ok := os.LookupEnv(s[start+1 : end]); ok && end > start+1 {
+			b.WriteString(value)
+		} else {
+			b.WriteString(s[start : end+1])
+		}
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+
+	return b.String()
+}
@@ -0,0 +1,125 @@
+//go:build windows
+
+package winreg
+
+import (
+	"encoding/binary"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	regCreateKeyEx = advapi32.NewProc("RegCreateKeyExW")
+	regSetValueEx  = advapi32.NewProc("RegSetValueExW")
+	regDeleteTree  = advapi32.NewProc("RegDeleteTreeW")
+	regDeleteKey   = advapi32.NewProc("RegDeleteKeyW")
+)
+
+// testKey creates a key under HKEY_CURRENT_USER that is deleted when the
+// test ends, and returns its path and a handle to it.
+func testKey(t *testing.T) (string, syscall.Handle) {
+	path := `Software\caleflat-env-test-` + strconv.Itoa(os.Getpid())
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var h syscall.Handle
+	r, _, _ := regCreateKeyEx.Call(uintptr(syscall.HKEY_CURRENT_USER), uintptr(unsafe.Pointer(p)), 0, 0, 0,
+		uintptr(syscall.KEY_ALL_ACCESS), 0, uintptr(unsafe.Pointer(&h)), 0)
+	if r != 0 {
+		t.Skipf("Cannot create test key: %v", syscall.Errno(r))
+	}
+	t.Cleanup(func() {
+		syscall.RegCloseKey(h)
+		regDeleteTree.Call(uintptr(syscall.HKEY_CURRENT_USER), uintptr(unsafe.Pointer(p)))
+		regDeleteKey.Call(uintptr(syscall.HKEY_CURRENT_USER), uintptr(unsafe.Pointer(p)))
+	})
+	return path, h
+}
+
+func setValue(t *testing.T, h syscall.Handle, name string, typ uint32, data []byte) {
+	n, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _, _ := regSetValueEx.Call(uintptr(h), uintptr(unsafe.Pointer(n)), 0, uintptr(typ),
+		uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)))
+	if r != 0 {
+		t.Fatalf("Failed to set %s: %v", name, syscall.Errno(r))
+	}
+}
+
+// utf16Bytes encodes s as a NUL-terminated UTF-16 registry string.
+func utf16Bytes(s string) []byte {
+	u, _ := syscall.UTF16FromString(s)
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+func TestLookup(t *testing.T) {
+	t.Setenv("WINREG_TEST_DIR", `C:\Data`)
+	path, h := testKey(t)
+
+	dword := make([]byte, 4)
+	binary.LittleEndian.PutUint32(dword, 8080)
+	qword := make([]byte, 8)
+	binary.LittleEndian.PutUint64(qword, 1<<40)
+
+	setValue(t, h, "HOST", syscall.REG_SZ, utf16Bytes("db.internal"))
+	setValue(t, h, "DATA_DIR", syscall.REG_EXPAND_SZ, utf16Bytes(`%WINREG_TEST_DIR%\app`))
+	setValue(t, h, "PORT", syscall.REG_DWORD, dword)
+	setValue(t, h, "MAX_SIZE", syscall.REG_QWORD, qword)
+	setValue(t, h, "BLOB", syscall.REG_BINARY, []byte{1, 2, 3})
+
+	s := New(CurrentUser, path)
+	tests := map[string]string{
+		"HOST":     "db.internal",
+		"DATA_DIR": `C:\Data\app`,
+		"PORT":     "8080",
+		"MAX_SIZE": "1099511627776",
+	}
+	for key, expected := range tests {
+		if value, ok := s.Lookup(key); !ok || value != expected {
+			t.Errorf("Expected %s to be %q, got %q (%v)", key, expected, value, ok)
+		}
+	}
+
+	for _, key := range []string{"BLOB", "UNSET"} {
+		if value, ok := s.Lookup(key); ok {
+			t.Errorf("Expected %s to be missing, got %q", key, value)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Setenv("WINREG_TEST_DIR", `C:\Data`)
+
+	tests := map[string]string{
+		`%WINREG_TEST_DIR%\app`: `C:\Data\app`,
+		`%WINREG_TEST_UNSET%\x`: `%WINREG_TEST_UNSET%\x`,
+		`100%`:                  `100%`,
+		`%%`:                    `%%`,
+		`no references`:         `no references`,
+	}
+
+	for input, want := range tests {
+		if got := expand(input); got != want {
+			t.Errorf("expand(%q) = %q, expected %q", input, got, want)
+		}
+	}
+}
+
+func TestLookupMissing(t *testing.T) {
+	s := New(CurrentUser, `Software\caleflat-env-test-missing`)
+	if _, ok := s.Lookup("ANY"); ok {
+		t.Error("Expected a missing key to report values as missing")
+	}
+}
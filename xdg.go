@@ -0,0 +1,88 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// XDG holds the directories of the XDG Base Directory Specification.
+type XDG struct {
+	// ConfigHome is XDG_CONFIG_HOME, or $HOME/.config.
+	ConfigHome string
+	// CacheHome is XDG_CACHE_HOME, or $HOME/.cache.
+	CacheHome string
+	// DataHome is XDG_DATA_HOME, or $HOME/.local/share.
+	DataHome string
+	// StateHome is XDG_STATE_HOME, or $HOME/.local/state.
+	StateHome string
+	// RuntimeDir is XDG_RUNTIME_DIR. It has no fallback and may be empty.
+	RuntimeDir string
+	// ConfigDirs is XDG_CONFIG_DIRS, or /etc/xdg.
+	ConfigDirs []string
+	// DataDirs is XDG_DATA_DIRS, or /usr/local/share and /usr/share.
+	DataDirs []string
+}
+
+// GetXDG returns the XDG base directories of the environment. As the
+// specification requires, variables that are unset, empty or hold a relative
+// path are ignored in favour of the fallback.
+func GetXDG() XDG {
+	home, _ := os.UserHomeDir()
+
+	return XDG{
+		ConfigHome: xdgDir("XDG_CONFIG_HOME", home, ".config"),
+		CacheHome:  xdgDir("XDG_CACHE_HOME", home, ".cache"),
+		DataHome:   xdgDir("XDG_DATA_HOME", home, ".local", "share"),
+		StateHome:  xdgDir("XDG_STATE_HOME", home, ".local", "state"),
+		RuntimeDir: xdgDir("XDG_RUNTIME_DIR", ""),
+		ConfigDirs: xdgDirs("XDG_CONFIG_DIRS", "/etc/xdg"),
+		DataDirs:   xdgDirs("XDG_DATA_DIRS", "/usr/local/share", "/usr/share"),
+	}
+}
+
+// FindConfig returns the first existing file named name, relative to
+// ConfigHome and then to each of ConfigDirs.
+func (x XDG) FindConfig(name string) (string, bool) {
+	for _, dir := range append([]string{x.ConfigHome}, x.ConfigDirs...) {
+		if dir == "" {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+func xdgDir(key, home string, elem ...string) string {
+	if dir, ok := GetString(key); ok && filepath.IsAbs(dir) {
+		return dir
+	}
+
+	if home == "" {
+		return ""
+	}
+
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+func xdgDirs(key string, fallback ...string) []string {
+	value, _ := GetString(key)
+
+	var dirs []string
+	for _, dir := range strings.Split(value, string(os.PathListSeparator)) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	if len(dirs) == 0 {
+		return fallback
+	}
+
+	return dirs
+}
@@ -0,0 +1,44 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetXDG(t *testing.T) {
+	clearenv(t)
+	t.Setenv("HOME", "/home/gopher")
+	t.Setenv("XDG_CACHE_HOME", "/var/cache/gopher")
+	t.Setenv("XDG_DATA_HOME", "relative/data")
+	t.Setenv("XDG_CONFIG_DIRS", "/etc/xdg/app:relative:/opt/xdg")
+
+	expected := XDG{
+		ConfigHome: "/home/gopher/.config",
+		CacheHome:  "/var/cache/gopher",
+		DataHome:   "/home/gopher/.local/share",
+		StateHome:  "/home/gopher/.local/state",
+		ConfigDirs: []string{"/etc/xdg/app", "/opt/xdg"},
+		DataDirs:   []string{"/usr/local/share", "/usr/share"},
+	}
+	if got := GetXDG(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetXDG does not match.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+}
+
+func TestXDG_FindConfig(t *testing.T) {
+	home, system := t.TempDir(), t.TempDir()
+	path := filepath.Join(system, "app", "config.toml")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.WriteFile(path, nil, 0o644)
+
+	x := XDG{ConfigHome: home, ConfigDirs: []string{system}}
+	if got, ok := x.FindConfig("app/config.toml"); !ok || got != path {
+		t.Errorf("FindConfig returned %q, %v, expected %q", got, ok, path)
+	}
+
+	if _, ok := x.FindConfig("app/missing.toml"); ok {
+		t.Error("Expected FindConfig to report a missing file")
+	}
+}